@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/AstraBert/arxiv-cli/internal/names"
+	"github.com/spf13/cobra"
+)
+
+func newAuthorsCmd() *cobra.Command {
+	var (
+		query  string
+		limit  int
+		format string
+		merge  bool
+		rules  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "authors",
+		Short: "List the most active authors for a query",
+		Long:  "Fetch the metadata of the papers matching a query, without downloading anything, and rank their distinct authors by paper count, then by most recent paper.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("unknown format %q (expected table or json)", format)
+			}
+			opts := download.AuthorsOptions{
+				Query: query,
+				Limit: limit,
+				JSON:  format == "json",
+				Out:   os.Stdout,
+			}
+			if merge {
+				parsed, err := names.ParseRules(rules)
+				if err != nil {
+					return fmt.Errorf("invalid --author-name-rules: %w", err)
+				}
+				opts.Merge = &parsed
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return download.Authors(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&query, "query", "q", "", "The search query to aggregate authors for")
+	cmd.Flags().IntVarP(&limit, "limit", "l", download.DefaultAuthorsLimit, "The number of papers to aggregate")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	cmd.Flags().BoolVar(&merge, "merge-variants", false, "Count variants of the same name (e.g. J. Smith and John Smith) as one author")
+	cmd.Flags().StringVar(&rules, "author-name-rules", "initials,middle-names", "Rules for merging names with --merge-variants: initials, middle-names or none")
+	_ = cmd.MarkFlagRequired("query")
+	return cmd
+}
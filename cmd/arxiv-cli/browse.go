@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/AstraBert/arxiv-cli/internal/browse"
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/spf13/cobra"
+)
+
+func newBrowseCmd() *cobra.Command {
+	var (
+		query     string
+		limit     int
+		outputDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "browse",
+		Short: "Browse search results in the terminal and download PDFs",
+		Long:  "Fetch the newest papers matching the query and list their titles beside the selected paper's abstract. Move with the arrow keys (or j/k), press d or Enter to download the selected paper's PDF and q to quit.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if query == "" {
+				return fmt.Errorf("--query is required")
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			papers, err := download.Search(ctx, query, limit)
+			if err != nil {
+				return err
+			}
+			if len(papers) == 0 {
+				return fmt.Errorf("no papers match %q", query)
+			}
+
+			term, restore, err := browse.OpenTerminal(os.Stdin, os.Stdout)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = restore() }()
+
+			pdfDir := filepath.Join(outputDir, download.PDFDirectory)
+			return browse.Run(ctx, term, papers, func(ctx context.Context, paper download.ArxivPaper) (string, error) {
+				if err := os.MkdirAll(pdfDir, 0o755); err != nil {
+					return "", fmt.Errorf("failed to create PDF directory: %w", err)
+				}
+				path := filepath.Join(pdfDir, download.FormatFilename("", paper)+".pdf")
+				if err := paper.FetchPDF(ctx, path); err != nil {
+					return "", err
+				}
+				return path, nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&query, "query", "q", "", "Search query (e.g., \"cat:cs.CL\")")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 50, "The number of papers to browse")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory to save PDFs under, in pdfs/ (default: the current directory)")
+	return cmd
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AstraBert/arxiv-cli/internal/bundle"
+	"github.com/spf13/cobra"
+)
+
+func newBundleCmd() *cobra.Command {
+	var (
+		workspace        string
+		idsFrom          string
+		output           string
+		filenameTemplate string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Share papers between workspaces as a single archive",
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Pack the metadata, PDFs and summaries of selected papers into a bundle",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if idsFrom == "" || output == "" {
+				return fmt.Errorf("--ids-from and -o are required")
+			}
+			list, err := os.Open(idsFrom)
+			if err != nil {
+				return fmt.Errorf("failed to open ID list: %w", err)
+			}
+			defer func() { _ = list.Close() }()
+			ids, err := bundle.ReadIDs(list)
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create bundle: %w", err)
+			}
+			manifest, err := bundle.Export(file, bundle.ExportOptions{
+				Workspace:        workspace,
+				IDs:              ids,
+				FilenameTemplate: filenameTemplate,
+			})
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(output)
+				return err
+			}
+			fmt.Printf("exported %d papers to %s\n", len(manifest.Papers), output)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVar(&idsFrom, "ids-from", "", "File with one arXiv ID per line")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Path of the bundle to write (.tar.zst)")
+	exportCmd.Flags().StringVar(&filenameTemplate, "filename-template", "", "Template the workspace's PDFs and summaries were saved with (default {title})")
+
+	importCmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Merge a bundle into a workspace, skipping papers it already has",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open bundle: %w", err)
+			}
+			defer func() { _ = file.Close() }()
+
+			if err := os.MkdirAll(workspace, 0755); err != nil {
+				return fmt.Errorf("failed to create workspace: %w", err)
+			}
+			result, err := bundle.Import(file, workspace, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("imported %d papers, skipped %d already in the workspace\n", len(result.Imported), len(result.Skipped))
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&workspace, "workspace", ".", "Directory holding metadata.jsonl, pdfs/ and texts/")
+	cmd.AddCommand(exportCmd, importCmd)
+	return cmd
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/AstraBert/arxiv-cli/internal/daemon"
+	"github.com/AstraBert/arxiv-cli/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+func newDaemonCmd() *cobra.Command {
+	var (
+		configPath string
+		stateDir   string
+		jitterArg  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the searches in a profiles file on their schedules",
+		Long:  "Run every profile in the profiles file on its interval or cron schedule, one at a time, logging each profile's runs to its own file in the state directory.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := daemon.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			jitter, err := parsePercent(jitterArg)
+			if err != nil {
+				return fmt.Errorf("invalid --poll-jitter: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return daemon.Run(ctx, cfg, stateDir, schedule.RealClock{}, jitter)
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the next and last run of each profile",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := daemon.ReadStatus(stateDir)
+			if err != nil {
+				return err
+			}
+			return daemon.PrintStatus(os.Stdout, state)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&stateDir, "state-dir", daemon.DefaultStateDir, "Directory holding the daemon status file and per-profile logs")
+	cmd.Flags().StringVar(&configPath, "config", daemon.DefaultConfigFile, "Path to the JSON profiles file")
+	cmd.Flags().StringVar(&jitterArg, "poll-jitter", "0%", "Move each next run by up to this share of the profile's interval either way, e.g. 10%")
+	cmd.AddCommand(statusCmd)
+
+	return cmd
+}
+
+// parsePercent parses a share given as a percentage such as "10%" or as a
+// fraction such as "0.1".
+func parsePercent(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+	f, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a percentage", value)
+	}
+	if percent {
+		f /= 100
+	}
+	return f, nil
+}
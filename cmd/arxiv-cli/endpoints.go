@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/spf13/cobra"
+)
+
+func newEndpointsCmd() *cobra.Command {
+	var (
+		llmURL  string
+		ttsURL  string
+		webhook string
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "endpoints",
+		Short: "List every external endpoint arxiv-cli could contact",
+		Long:  "List the services arxiv-cli could send requests to under the given configuration, with the proxy each request would go through, without contacting any of them. Pass the same --llm-api-url, --tts-api-url, --webhook and --proxy as your runs.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("unknown format %q (expected table or json)", format)
+			}
+			endpoints := download.Endpoints(download.Options{
+				Podcast: &download.PodcastOptions{APIURL: llmURL, TTSAPIURL: ttsURL},
+				Webhook: webhook,
+			})
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(endpoints)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "NAME\tHOST\tURL\tUSED FOR\tPROXY")
+			for _, e := range endpoints {
+				proxy := e.Proxy
+				if proxy == "" {
+					proxy = "-"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Host, e.URL, e.UsedFor, proxy)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&llmURL, "llm-api-url", download.DefaultLLMAPIURL, "The chat completions endpoint runs use for --generate-podcast-script")
+	cmd.Flags().StringVar(&ttsURL, "tts-api-url", "", "The speech endpoint runs use for podcast audio")
+	cmd.Flags().StringVar(&webhook, "webhook", "", "The webhook runs post their summary to")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	return cmd
+}
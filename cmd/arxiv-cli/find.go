@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/spf13/cobra"
+)
+
+func newFindCmd() *cobra.Command {
+	var (
+		limit  int
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "find <title>",
+		Short: "Look up papers by a half-remembered title",
+		Long:  "Search arXiv titles for the words given and list the best matches first: the exact title, then titles starting with it, then titles containing every word, then the remaining results by relevance.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("unknown format %q (expected table or json)", format)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return download.Find(ctx, strings.Join(args, " "), download.FindOptions{
+				Limit: limit,
+				JSON:  format == "json",
+				Out:   os.Stdout,
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "l", download.DefaultFindResults, "The number of matches to show")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	return cmd
+}
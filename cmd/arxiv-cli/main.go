@@ -2,57 +2,770 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/AstraBert/arxiv-cli/internal/citation"
 	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/AstraBert/arxiv-cli/internal/format"
+	"github.com/AstraBert/arxiv-cli/internal/names"
 	"github.com/spf13/cobra"
 )
 
 var (
-	query      string
-	limit      int
-	pdf        bool
-	summary    bool
-	noMetadata bool
+	queries     []string
+	queryFile   string
+	rawQuery    string
+	phrase      bool
+	limit       int
+	perQueryLim int
+	limitTotal  int
+	pdf         bool
+	summary     bool
+	kindle      bool
+	pdfToText   bool
+	source      bool
+	noMetadata  bool
+	inclSummary bool
+	mdIndex     bool
+	normDOI     bool
+	all         bool
+	pageSize    int
+	resumePages bool
+	appendMeta  bool
+	dedupTitle  bool
+	titleDist   float64
+	dedupeRep   string
+	dateFrom    string
+	dateTo      string
+	skipExist   bool
+	paperType   string
+	author      string
+	fromDate    string
+	updatedAft  string
+	filterCat   string
+	filenameTpl string
+	maxPerHost  string
+	concurrency int
+	findPubVer  bool
+	storeRaw    bool
+	dublinCore  bool
+	formulas    bool
+	acronyms    bool
+	wordCloud   bool
+	withNotes   bool
+	require     []string
+	toStdout    bool
+	idArgs      []string
+	paperVer    int
+	oai         bool
+	oaiSet      string
+	oaiFrom     string
+	oaiUntil    string
+	podcast     bool
+	llmURL      string
+	llmModel    string
+	ttsURL      string
+	ttsVoice    string
+	mergeAuth   bool
+	detectDups  bool
+	topJournals bool
+	printTree   bool
+	webhook     string
+	dupThresh   float64
+	nameRules   string
+	excludeCats []string
+	excludeFrom []string
+	citeStyle   string
+	styleSheet  string
+	quiet       bool
+	verbose     bool
+	table       bool
+	showAbs     int
+	wrapAbs     bool
+	titleMatch  string
+	absMatch    string
+	caseSens    bool
+	formatNames []string
+	minReading  float64
+	maxReading  float64
+	toDate      string
+	monthArg    string
+	printURLs   bool
+	printIDs    bool
+	aria2       bool
+	dryRun      bool
+	contactMail string
+	proxyURL    string
+	auditPath   string
+	absOnly     bool
+	idsStdin    bool
+	idFile      string
+	idCategory  string
+	outputDir   string
+	metaFile    string
+	failEmpty   bool
+	comparePrev bool
+	pdfDir      string
+	textDir     string
+	stampDir    bool
+	stampFormat string
+	fileModeArg string
+	dirModeArg  string
+	readOnly    bool
 )
 
+// version is reported by --version and in the User-Agent of every request.
+const version = "1.0.0"
+
+// exitNoResults is the exit status of a --fail-on-empty run whose search
+// found no papers, so scripts can tell it from other failures.
+const exitNoResults = 2
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:     "arxiv-cli",
 		Short:   "Download papers from arXiv by category or search query",
 		Long:    "Intuitive command-line tool to download the most recent number of papers belonging a specific category from arXiv.",
-		Version: "1.0.0",
+		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if contactMail != "" {
+				if _, err := mail.ParseAddress(contactMail); err != nil {
+					return fmt.Errorf("invalid --contact-email: %w", err)
+				}
+			}
+			download.SetUserAgent(version, contactMail)
+			if auditPath != "" {
+				// Left open until the process exits, as requests are made
+				// up to the end.
+				file, err := os.OpenFile(expandHome(auditPath), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+				if err != nil {
+					return fmt.Errorf("failed to open audit log: %w", err)
+				}
+				download.SetAuditLog(file)
+			}
+			return download.SetProxy(proxyURL)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if query == "" {
-				return fmt.Errorf("query is required (use --query or -q)")
-			}
-
-			ctx := context.Background()
-			return download.DownloadArxivPapers(
-				ctx,
-				query,
-				limit,
-				!noMetadata,
-				pdf,
-				summary,
-			)
+			if slices.Contains(queries, "") {
+				return fmt.Errorf("query must not be empty")
+			}
+			if queryFile != "" {
+				fromFile, err := readQueryFile(queryFile)
+				if err != nil {
+					return err
+				}
+				queries = append(queries, fromFile...)
+			}
+			var ids []string
+			for _, arg := range idArgs {
+				id, err := download.ParseID(arg)
+				if err != nil {
+					return fmt.Errorf("invalid --id: %w", err)
+				}
+				ids = append(ids, id)
+			}
+			if idFile != "" {
+				fromFile, err := readIDFile(idFile)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, fromFile...)
+			}
+			if idsStdin {
+				fromStdin, err := readStdinIDs()
+				if err != nil {
+					return err
+				}
+				ids = append(ids, fromStdin...)
+			}
+			if rawQuery != "" {
+				if len(queries) > 0 || len(ids) > 0 || oai {
+					return fmt.Errorf("--raw-query cannot be combined with --query, --query-file, --id, --id-file, --ids-from-stdin or --oai")
+				}
+				if fromDate != "" || toDate != "" || monthArg != "" || phrase {
+					return fmt.Errorf("--raw-query is sent as it is and cannot be combined with --from, --to, --month or --phrase")
+				}
+				queries = []string{rawQuery}
+			}
+			if len(ids) > 0 && len(queries) > 0 {
+				return fmt.Errorf("--id, --id-file and --ids-from-stdin cannot be combined with --query or --query-file")
+			}
+			if oai && (len(ids) > 0 || len(queries) > 0) {
+				return fmt.Errorf("--oai harvests instead of searching and cannot be combined with --query, --query-file, --id, --id-file or --ids-from-stdin")
+			}
+			if !oai && len(ids) == 0 && len(queries) == 0 {
+				return fmt.Errorf("query is required (use --query, -q, --query-file, --raw-query, --id, --id-file, --ids-from-stdin or --oai)")
+			}
+			var idQuery string
+			if idCategory != "" {
+				if len(ids) == 0 {
+					return fmt.Errorf("--category filters an ID list and requires --id, --id-file or --ids-from-stdin (use --filter-category with a search)")
+				}
+				idQuery = "cat:" + idCategory
+			}
+			var harvest *download.OAIHarvest
+			if oai {
+				from, err := parseDateFlag("oai-from", oaiFrom, false)
+				if err != nil {
+					return err
+				}
+				until, err := parseDateFlag("oai-until", oaiUntil, false)
+				if err != nil {
+					return err
+				}
+				if !from.IsZero() && !until.IsZero() && until.Before(from) {
+					return fmt.Errorf("--oai-until must not be before --oai-from")
+				}
+				harvest = &download.OAIHarvest{Set: oaiSet, From: from, Until: until}
+			} else if oaiSet != "" || oaiFrom != "" || oaiUntil != "" {
+				return fmt.Errorf("--oai-set, --oai-from and --oai-until require --oai")
+			}
+			if paperVer < 0 || (paperVer > 0 && len(ids) == 0) {
+				return fmt.Errorf("--paper-version must be a positive version and requires --id, --id-file or --ids-from-stdin")
+			}
+			var query string
+			if len(queries) == 1 {
+				query, queries = queries[0], nil
+			}
+
+			from, err := parseDateFlag("date-from", dateFrom, false)
+			if err != nil {
+				return err
+			}
+			to, err := parseDateFlag("date-to", dateTo, true)
+			if err != nil {
+				return err
+			}
+
+			fileMode, err := parseModeFlag("file-mode", fileModeArg)
+			if err != nil {
+				return err
+			}
+			dirMode, err := parseModeFlag("dir-mode", dirModeArg)
+			if err != nil {
+				return err
+			}
+
+			updatedAfter, err := parseDateFlag("updated-after", updatedAft, false)
+			if err != nil {
+				return err
+			}
+			submittedFrom, submittedTo, err := parseSubmittedRange(fromDate, toDate)
+			if err != nil {
+				return err
+			}
+			var month time.Time
+			if monthArg != "" {
+				if fromDate != "" || toDate != "" {
+					return fmt.Errorf("--month cannot be combined with --from or --to")
+				}
+				if len(ids) > 0 || harvest != nil {
+					return fmt.Errorf("--month cannot be combined with --id, --id-file, --ids-from-stdin or --oai")
+				}
+				if month, err = download.ParseMonth(monthArg); err != nil {
+					return fmt.Errorf("invalid --month: %w", err)
+				}
+			}
+			if err := download.ValidateRequiredFields(require); err != nil {
+				return fmt.Errorf("invalid --require: %w", err)
+			}
+			if paperType != "" {
+				if err := download.ValidatePaperType(paperType); err != nil {
+					return err
+				}
+			}
+			var outputFormats []format.Format
+			for _, name := range formatNames {
+				f, err := format.Parse(name)
+				if err != nil {
+					return err
+				}
+				outputFormats = append(outputFormats, f)
+			}
+			if err := download.ValidateFilenameTemplate(filenameTpl); err != nil {
+				return err
+			}
+			var cite func(download.ArxivPaper) (string, error)
+			if citeStyle != "" || styleSheet != "" {
+				styles := citation.NewStyles()
+				if styleSheet != "" {
+					if err := styles.LoadStyleSheet(styleSheet); err != nil {
+						return err
+					}
+				}
+				if citeStyle == "" {
+					return fmt.Errorf("--citation-style-sheet requires --citation-style")
+				}
+				if !styles.Has(citeStyle) {
+					return fmt.Errorf("unknown citation style %q (expected one of %s)", citeStyle, strings.Join(styles.Names(), ", "))
+				}
+				cite = func(paper download.ArxivPaper) (string, error) {
+					return styles.Format(citeStyle, paper)
+				}
+			}
+			titleRe, err := compileMatch("title-match", titleMatch)
+			if err != nil {
+				return err
+			}
+			abstractRe, err := compileMatch("abstract-match", absMatch)
+			if err != nil {
+				return err
+			}
+			hostLimits, err := download.ParseHostLimits(maxPerHost)
+			if err != nil {
+				return err
+			}
+			download.SetHostLimits(hostLimits)
+
+			var mergeAuthors *names.Rules
+			if mergeAuth {
+				rules, err := names.ParseRules(nameRules)
+				if err != nil {
+					return fmt.Errorf("invalid --author-name-rules: %w", err)
+				}
+				mergeAuthors = &rules
+			}
+
+			var podcastOpts *download.PodcastOptions
+			if podcast {
+				podcastOpts = &download.PodcastOptions{
+					APIURL:    llmURL,
+					APIKey:    os.Getenv("OPENAI_API_KEY"),
+					Model:     llmModel,
+					TTSAPIURL: ttsURL,
+					TTSVoice:  ttsVoice,
+					TTSAPIKey: os.Getenv("TTS_API_KEY"),
+				}
+			} else if ttsURL != "" || ttsVoice != "" {
+				return fmt.Errorf("--tts-api-url and --tts-voice require --generate-podcast-script")
+			}
+			if (ttsURL == "") != (ttsVoice == "") {
+				return fmt.Errorf("--tts-api-url and --tts-voice must be set together")
+			}
+			if pdfToText && !pdf {
+				return fmt.Errorf("--pdf-to-text requires --pdf")
+			}
+			if kindle && !summary {
+				return fmt.Errorf("--output-kindle requires --summary")
+			}
+
+			var timestampLayout string
+			if stampDir {
+				if stampFormat == "" {
+					return fmt.Errorf("--timestamp-format must not be empty")
+				}
+				timestampLayout = stampFormat
+			}
+
+			// With --stdout the metadata owns stdout and every message goes
+			// to stderr instead.
+			var metadataOut io.Writer
+			messages, progress := io.Writer(os.Stdout), progressWriter(os.Stdout)
+			if toStdout {
+				if noMetadata || printURLs || printIDs || dryRun || table || cite != nil {
+					return fmt.Errorf("--stdout cannot be combined with --no-metadata, --print-urls, --arxiv-id-list, --dry-run, --table or --citation-style")
+				}
+				if len(outputFormats) > 1 {
+					return fmt.Errorf("--stdout writes a single --format")
+				}
+				metadataOut = os.Stdout
+				messages, progress = os.Stderr, progressWriter(os.Stderr)
+			}
+
+			references := make([]string, len(excludeFrom))
+			for i, path := range excludeFrom {
+				references[i] = expandHome(path)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return download.Run(ctx, download.Options{
+				Query:          query,
+				RawQuery:       rawQuery != "",
+				Phrase:         phrase,
+				Queries:        queries,
+				IDs:            ids,
+				IDQuery:        idQuery,
+				PaperVersion:   paperVer,
+				OAI:            harvest,
+				Limit:          limit,
+				PerQueryLimit:  perQueryLim,
+				LimitTotal:     limitTotal,
+				SaveMetadata:   !noMetadata,
+				SavePDFs:       pdf,
+				SaveSummaries:  summary,
+				SaveEPUB:       kindle,
+				PDFToText:      pdfToText,
+				SaveSources:    source,
+				IncludeSummary: inclSummary,
+				MarkdownIndex:  mdIndex,
+				RawDOIs:        !normDOI,
+				AbstractOnly:   absOnly,
+				All:            all,
+				PageSize:       pageSize,
+
+				ResumePagination: resumePages,
+				Append:           appendMeta,
+
+				DeduplicateByTitle: dedupTitle,
+				TitleDistance:      titleDist,
+				DedupeReport:       expandHome(dedupeRep),
+
+				SubmittedFrom:        submittedFrom,
+				SubmittedTo:          submittedTo,
+				Month:                month,
+				UpdatedAfter:         updatedAfter,
+				FilenameTemplate:     filenameTpl,
+				OutputDir:            outputDir,
+				FailOnEmpty:          failEmpty,
+				CompareToPrevious:    comparePrev,
+				MetadataFile:         expandHome(metaFile),
+				PDFDir:               expandHome(pdfDir),
+				TextDir:              expandHome(textDir),
+				TimestampLayout:      timestampLayout,
+				FileMode:             fileMode,
+				DirMode:              dirMode,
+				FinalizeReadOnly:     readOnly,
+				Concurrency:          concurrency,
+				FindPublishedVersion: findPubVer,
+				StoreRawEntry:        storeRaw,
+				DublinCore:           dublinCore,
+				ExtractFormulas:      formulas,
+				ExtractAcronyms:      acronyms,
+				WordCloudData:        wordCloud,
+				IncludeNotes:         withNotes,
+				Podcast:              podcastOpts,
+				MergeAuthors:         mergeAuthors,
+				Webhook:              webhook,
+
+				DetectDuplicateSubmissions: detectDups,
+				TopJournals:                topJournals,
+				PrintTree:                  printTree,
+				DuplicateThreshold:         dupThresh,
+
+				Cite:              cite,
+				OutputFormats:     outputFormats,
+				Table:             table,
+				AbstractWidth:     showAbs,
+				WrapAbstract:      wrapAbs,
+				Progress:          progress,
+				Out:               messages,
+				Logger:            newLogger(),
+				MetadataOut:       metadataOut,
+				ExcludeCategories: excludeCats,
+				ExcludeFrom:       references,
+				TitleMatch:        titleRe,
+				AbstractMatch:     abstractRe,
+				Category:          filterCat,
+				MinReadingLevel:   minReading,
+				MaxReadingLevel:   maxReading,
+				DateFrom:          from,
+				DateTo:            to,
+
+				SkipExisting: skipExist,
+				PaperType:    paperType,
+				Require:      require,
+				Author:       author,
+				PrintURLs:    printURLs,
+				PrintIDs:     printIDs,
+				Aria2:        aria2,
+				DryRun:       dryRun,
+			})
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&query, "query", "q", "", "Search query (e.g., \"graphrag\", \"machine learning\") (required)")
+	rootCmd.Flags().StringArrayVarP(&queries, "query", "q", nil, "Search query (e.g., \"graphrag\", \"machine learning\") (repeatable; required unless --query-file, --id, --id-file, --ids-from-stdin or --oai is given)")
+	rootCmd.Flags().StringVar(&rawQuery, "raw-query", "", "arXiv search_query sent verbatim, only URL-encoded, e.g. '(ti:\"graph neural\" OR abs:GNN) ANDNOT cat:cs.CV'; cannot be combined with --query, --from, --to or --month")
+	rootCmd.Flags().BoolVar(&phrase, "phrase", false, "Search multi-word queries as a phrase, e.g. -q \"graph neural networks\" as all:\"graph neural networks\"")
+	rootCmd.Flags().StringVar(&queryFile, "query-file", "", "File with one search query per line (blank lines and # comments are ignored), run like repeated --query flags")
 	rootCmd.Flags().IntVarP(&limit, "limit", "l", 5, "The maximum number of papers to fetch")
+	rootCmd.Flags().IntVar(&perQueryLim, "per-query-limit", 0, "The maximum number of papers to fetch for each query (default: --limit)")
+	rootCmd.Flags().IntVar(&limitTotal, "limit-total", 0, "The maximum number of papers to fetch across all queries (default: no cap)")
 	rootCmd.Flags().BoolVarP(&pdf, "pdf", "p", false, "Whether or not to fetch and save the PDF paper")
 	rootCmd.Flags().BoolVarP(&summary, "summary", "s", false, "Whether or not to save the summary of the papers txt files")
+	rootCmd.Flags().BoolVar(&pdfToText, "pdf-to-text", false, "Whether or not to extract the full text of each saved PDF into texts/<title>-fulltext.txt (requires --pdf)")
+	rootCmd.Flags().BoolVar(&kindle, "output-kindle", false, "Whether or not to also save each paper as an EPUB book in epub/, for e-readers and Send to Kindle (requires --summary)")
+	rootCmd.Flags().BoolVar(&source, "source", false, "Whether or not to fetch and save the e-print source (usually a LaTeX .tar.gz) of each paper")
 	rootCmd.Flags().BoolVar(&noMetadata, "no-metadata", false, "Whether or not to disable fetching and saving the metadata of the paper to a JSONL file")
+	rootCmd.Flags().BoolVar(&all, "all", false, "Whether or not to page through every matching paper, ignoring --limit")
+	rootCmd.Flags().IntVar(&pageSize, "page-size", 200, "The number of papers requested per API call when using --all or a larger --limit")
+	rootCmd.Flags().BoolVar(&appendMeta, "append", false, "Add to the existing metadata files instead of overwriting them, skipping papers metadata.jsonl already lists")
+	rootCmd.Flags().BoolVar(&resumePages, "resume-pagination", false, "Whether or not to resume an interrupted --all run from its saved offset")
+	rootCmd.Flags().BoolVar(&dedupTitle, "deduplicate-by-title", false, "Whether or not to drop papers with nearly identical titles, keeping the latest version within a page and the first seen across pages")
+	rootCmd.Flags().Float64Var(&titleDist, "title-distance", download.DefaultTitleDistance, "The normalized edit distance under which two titles count as duplicates")
+	rootCmd.Flags().StringVar(&dedupeRep, "dedupe-report", "", "Write each paper dropped as a duplicate, with the reason and the paper it matched, to this JSONL file")
+	rootCmd.Flags().StringVar(&fromDate, "from", "", "Only search papers submitted on or after this date (YYYY-MM-DD or relative, e.g. 30d; UTC)")
+	rootCmd.Flags().StringVar(&monthArg, "month", "", "Fetch every paper announced in this month (YYYY-MM), paging as with --all, into metadata-YYYY-MM files")
+	rootCmd.Flags().StringVar(&toDate, "to", "", "Only search papers submitted on or before this date (YYYY-MM-DD or relative, e.g. 7d; UTC)")
+	rootCmd.Flags().StringVar(&updatedAft, "updated-after", "", "Only keep papers whose latest revision is on or after this date (YYYY-MM-DD)")
+	rootCmd.Flags().StringVar(&titleMatch, "title-match", "", "Only keep papers whose title matches this regular expression")
+	rootCmd.Flags().StringVar(&absMatch, "abstract-match", "", "Only keep papers whose abstract matches this regular expression")
+	rootCmd.Flags().BoolVar(&caseSens, "case-sensitive", false, "Whether or not --title-match and --abstract-match are case-sensitive")
+	rootCmd.Flags().StringVar(&filterCat, "filter-category", "", "Only keep papers listed under this arXiv category (e.g. cs.LG), checked after the search")
+	rootCmd.Flags().StringArrayVar(&excludeCats, "exclude-category", nil, "Drop papers whose primary category is this one (repeatable)")
+	rootCmd.Flags().StringArrayVar(&excludeFrom, "exclude-from", nil, "Drop papers already in this JSONL metadata file, BibTeX file or workspace directory, matched by arXiv ID, DOI or title (repeatable)")
+	rootCmd.Flags().Float64Var(&minReading, "min-reading-level", 0, "Only keep papers whose abstract has at least this Flesch-Kincaid grade level (0 disables)")
+	rootCmd.Flags().Float64Var(&maxReading, "max-reading-level", 0, "Only keep papers whose abstract has at most this Flesch-Kincaid grade level (0 disables)")
+	rootCmd.Flags().StringVar(&dateFrom, "date-from", "", "Only keep papers published on or after this date (YYYY-MM-DD)")
+	rootCmd.Flags().StringVar(&dateTo, "date-to", "", "Only keep papers published on or before this date (YYYY-MM-DD)")
+	rootCmd.Flags().StringVar(&filenameTpl, "filename-template", download.DefaultFilenameTemplate, "Name for saved PDFs and summaries, using {id}, {title}, {year} and {primary_category}")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of papers to download at once")
+	rootCmd.Flags().StringVar(&maxPerHost, "max-per-host", "", "Maximum concurrent requests per host when downloading with --concurrency, e.g. arxiv.org=4,api.semanticscholar.org=1 (0 lifts the cap)")
+	rootCmd.Flags().BoolVar(&findPubVer, "find-preprint-version", false, "Look up papers without a journal reference on CrossRef and record their published version in the metadata")
+	rootCmd.Flags().BoolVar(&wordCloud, "word-cloud-data", false, "Write the word frequencies of the abstracts to wordcloud.json and wordcloud.tsv")
+	rootCmd.Flags().BoolVar(&acronyms, "extract-acronyms", false, "Record the acronyms each abstract defines, e.g. \"Graph Retrieval-Augmented Generation (GraphRAG)\", as acronyms in the metadata")
+	rootCmd.Flags().BoolVar(&formulas, "extract-formulas", false, "Record the LaTeX math found in each abstract as formulas in the metadata")
+	rootCmd.Flags().BoolVar(&storeRaw, "store-raw-entry", false, "Save each paper's original Atom entry to raw/<id>.xml")
+	rootCmd.Flags().BoolVar(&dublinCore, "dublin-core", false, "Save a Dublin Core XML record of each paper to dublincore/<id>.xml, for library catalogs")
+	rootCmd.Flags().BoolVar(&podcast, "generate-podcast-script", false, "Write a short podcast intro for each paper to podcasts/, using the LLM API (key read from OPENAI_API_KEY)")
+	rootCmd.Flags().StringVar(&llmURL, "llm-api-url", download.DefaultLLMAPIURL, "OpenAI-compatible chat completions endpoint for --generate-podcast-script")
+	rootCmd.Flags().StringVar(&llmModel, "llm-model", download.DefaultLLMModel, "Model used by --generate-podcast-script")
+	rootCmd.Flags().StringVar(&ttsURL, "tts-api-url", "", "OpenAI-compatible speech endpoint used to read podcast scripts aloud (key read from TTS_API_KEY)")
+	rootCmd.Flags().StringVar(&ttsVoice, "tts-voice", "", "Voice used with --tts-api-url")
+	rootCmd.Flags().BoolVar(&detectDups, "detect-duplicate-submissions", false, "Report pairs of papers whose abstracts are nearly identical, e.g. re-submissions under a new title")
+	rootCmd.Flags().Float64Var(&dupThresh, "duplicate-threshold", download.DefaultDuplicateThreshold, "The abstract similarity (0-1) above which --detect-duplicate-submissions reports a pair")
+	rootCmd.Flags().BoolVar(&topJournals, "top-journals", false, "Print the journals the fetched papers appeared in, ranked by number of papers")
+	rootCmd.Flags().BoolVar(&printTree, "print-tree", false, "Print a tree of the metadata files, PDFs and summaries written, with their sizes")
+	rootCmd.Flags().BoolVar(&mergeAuth, "merge-authors-dedupe", false, "Add the authors of saved papers to authors.json, merging variants of the same name")
+	rootCmd.Flags().StringVar(&nameRules, "author-name-rules", "initials,middle-names", "Rules for merging author names with --merge-authors-dedupe: initials, middle-names or none")
+	rootCmd.Flags().BoolVar(&skipExist, "skip-existing", false, "Whether or not to skip PDFs and summaries that already exist on disk")
+	rootCmd.Flags().StringVar(&author, "author", "", "Only keep papers with an author whose name contains this string (case-insensitive)")
+	rootCmd.Flags().StringSliceVar(&require, "require", nil, "Only keep papers that have these metadata fields set, e.g. doi,pdf_url (repeatable)")
+	rootCmd.Flags().StringVar(&paperType, "paper-type", "", "Only keep papers of this inferred type (conference, journal, workshop, preprint)")
+	rootCmd.Flags().BoolVar(&printURLs, "print-urls", false, "Whether or not to print one PDF URL per paper to stdout instead of saving anything")
+	rootCmd.Flags().BoolVar(&aria2, "aria2", false, "Whether or not to follow each printed URL with an aria2c \"out=\" line naming the file")
+	rootCmd.Flags().BoolVar(&printIDs, "arxiv-id-list", false, "Print the arXiv ID (without version) of each paper to stdout, one per line, instead of saving anything")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Whether or not to only list what would be downloaded, without writing anything")
+	rootCmd.Flags().StringVar(&webhook, "webhook", "", "URL to POST a JSON summary of the run to when it ends (e.g. a Slack incoming webhook)")
+	rootCmd.Flags().BoolVar(&quiet, "quiet", false, "Whether or not to hide the progress bar and log only warnings")
+	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "Log each request, each paper fetched and each file written to stderr (hides the progress bar)")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.Flags().BoolVar(&table, "table", false, "Whether or not to print the papers as a table instead of saving anything")
+	rootCmd.Flags().IntVar(&showAbs, "show-abstract", 0, "Add an abstract column of this width to --table (0 hides it)")
+	rootCmd.Flags().BoolVar(&wrapAbs, "wrap-abstract", false, "Whether or not to wrap the --show-abstract column instead of truncating it")
+	rootCmd.Flags().StringVar(&citeStyle, "citation-style", "", "Print a citation for each paper in this style (apa, mla, chicago or one from --citation-style-sheet) instead of downloading")
+	rootCmd.Flags().StringVar(&styleSheet, "citation-style-sheet", "", "YAML file of custom citation styles written as Go templates")
+	rootCmd.Flags().BoolVar(&toStdout, "stdout", false, "Write the metadata to stdout instead of a file, e.g. to pipe it into jq; messages go to stderr")
+	rootCmd.Flags().StringSliceVar(&formatNames, "format", []string{format.JSONL.String()}, "Metadata format: jsonl (metadata.jsonl), bibtex (papers.bib), ris (papers.ris), csv (metadata.csv) or markdown (papers.md); repeat or separate with commas to write several")
+	rootCmd.Flags().BoolVar(&withNotes, "include-notes", false, "Add your notes from notes.json (see the note command) under each paper of the markdown metadata")
+	rootCmd.Flags().StringArrayVar(&idArgs, "id", nil, "Fetch the paper with this arXiv ID, URL or arXiv DOI instead of searching (repeatable)")
+	rootCmd.Flags().IntVar(&paperVer, "paper-version", 0, "Fetch this version of the --id papers instead of the latest, e.g. 1 for 2401.12345v1")
+	rootCmd.Flags().BoolVar(&oai, "oai", false, "Harvest metadata from arXiv's OAI-PMH interface instead of searching, for bulk downloads")
+	rootCmd.Flags().StringVar(&oaiSet, "oai-set", "", "OAI-PMH set to harvest with --oai, e.g. cs or physics:hep-th (default: every set)")
+	rootCmd.Flags().StringVar(&oaiFrom, "oai-from", "", "Harvest only records created or changed on or after this date (YYYY-MM-DD) with --oai")
+	rootCmd.Flags().StringVar(&oaiUntil, "oai-until", "", "Harvest only records created or changed on or before this date (YYYY-MM-DD) with --oai")
+	rootCmd.Flags().BoolVar(&idsStdin, "ids-from-stdin", false, "Fetch the arXiv IDs, URLs or arXiv DOIs piped to stdin, one per line, instead of searching")
+	rootCmd.Flags().StringVar(&idFile, "id-file", "", "Fetch the arXiv IDs, URLs or arXiv DOIs listed in this file, one per line, instead of searching")
+	rootCmd.Flags().StringVar(&idCategory, "category", "", "Only fetch the IDs listed under this arXiv category (e.g. cs.LG), filtered by the API in the same request")
+	rootCmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory to write all outputs under (default: the current directory)")
+	rootCmd.Flags().BoolVar(&failEmpty, "fail-on-empty", false, "Exit with status 2 when a search finds no papers (a warning is printed either way)")
+	rootCmd.Flags().BoolVar(&comparePrev, "compare-to-previous", false, "Output only the papers the previous run of the same search did not return")
+	rootCmd.Flags().StringVar(&metaFile, "metadata-file", "", "Path of the JSONL metadata file, relative to --output-dir unless absolute (default: metadata.jsonl)")
+	rootCmd.Flags().StringVar(&pdfDir, "pdf-dir", "", "Directory to save PDFs to, relative to --output-dir unless absolute (default: pdfs/)")
+	rootCmd.Flags().StringVar(&textDir, "text-dir", "", "Directory to save summaries to, relative to --output-dir unless absolute (default: texts/)")
+	rootCmd.Flags().BoolVar(&stampDir, "timestamp-dir", false, "Nest the outputs in a new directory under --output-dir named after the time of the run")
+	rootCmd.Flags().StringVar(&stampFormat, "timestamp-format", "2006-01-02_15-04-05", "Go time layout naming the --timestamp-dir directory")
+	rootCmd.Flags().StringVar(&fileModeArg, "file-mode", "", "Octal mode of every file written, e.g. 0644, regardless of the umask (default: 0644 less the umask)")
+	rootCmd.Flags().StringVar(&dirModeArg, "dir-mode", "", "Octal mode of every directory created, e.g. 0755, regardless of the umask (default: 0755 less the umask)")
+	rootCmd.Flags().BoolVar(&readOnly, "finalize-readonly", false, "Remove the write bits from each saved PDF, source, summary, raw entry, Dublin Core record and podcast file once its metadata is recorded")
+	rootCmd.Flags().BoolVar(&absOnly, "abstract-only", false, "Whether or not to parse only the ID, title, authors and summary of each paper, which is faster on large result sets")
+	rootCmd.Flags().BoolVar(&normDOI, "normalize-doi", true, "Whether or not to strip any doi.org URL or doi: prefix from each DOI and lowercase it")
+	rootCmd.Flags().BoolVar(&inclSummary, "include-summary", false, "Whether or not to include the summary of the paper in the JSONL metadata and the --markdown index")
+	rootCmd.Flags().BoolVar(&mdIndex, "markdown", false, "Write index.md, a Markdown reading list of the saved papers")
 
-	if err := rootCmd.MarkFlagRequired("query"); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "HTTP(S) or SOCKS5 proxy URL for every request, e.g. http://proxy.example.com:3128 (default: HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment)")
+	rootCmd.PersistentFlags().StringVar(&contactMail, "contact-email", "", "Email address added to the User-Agent of every request, so arXiv can reach you about your traffic")
+	rootCmd.PersistentFlags().StringVar(&auditPath, "audit-log", "", "Append a line per outbound request (time, method, host, path, status) to this file, without query strings, headers or webhook paths")
+
+	rootCmd.AddCommand(newWatchAuthorsCmd())
+	rootCmd.AddCommand(newDaemonCmd())
+	rootCmd.AddCommand(newBundleCmd())
+	rootCmd.AddCommand(newFindCmd())
+	rootCmd.AddCommand(newAuthorsCmd())
+	rootCmd.AddCommand(newBrowseCmd())
+	rootCmd.AddCommand(newNoteCmd())
+	rootCmd.AddCommand(newEndpointsCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, download.ErrNoResults) {
+			os.Exit(exitNoResults)
+		}
 		os.Exit(1)
 	}
 }
+
+// readQueryFile reads the queries listed in the --query-file file.
+func readQueryFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	queries, err := download.ReadQueries(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("query file %s lists no queries", path)
+	}
+	return queries, nil
+}
+
+// readIDFile reads the IDs listed in the --id-file file.
+func readIDFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ID file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	ids, err := download.ReadIDs(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ID file %s lists no IDs", path)
+	}
+	return ids, nil
+}
+
+// readStdinIDs reads the IDs piped in with --ids-from-stdin. A terminal on
+// stdin is refused rather than waited on.
+func readStdinIDs() ([]string, error) {
+	info, err := os.Stdin.Stat()
+	if err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return nil, fmt.Errorf("--ids-from-stdin reads IDs from a pipe or file, but stdin is a terminal (try: cat ids.txt | arxiv-cli --ids-from-stdin)")
+	}
+	ids, err := download.ReadIDs(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no arXiv IDs on stdin")
+	}
+	return ids, nil
+}
+
+// compileMatch compiles the regular expression of a match flag, ignoring
+// case unless --case-sensitive is set. An empty pattern yields nil.
+func compileMatch(name, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if !caseSens {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	return re, nil
+}
+
+// newLogger returns the logger of a run, writing to stderr at the level
+// chosen with --verbose or --quiet.
+func newLogger() *slog.Logger {
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelWarn
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// progressWriter returns stream for the progress bar, or nil when --quiet
+// or --verbose is set or stream is not a terminal.
+func progressWriter(stream *os.File) io.Writer {
+	if quiet || verbose {
+		return nil
+	}
+	info, err := stream.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return stream
+}
+
+// parseDateFlag parses a YYYY-MM-DD flag value as a UTC date. An empty value
+// yields the zero time; with endOfDay the last instant of the day is returned
+// so that the date is inclusive as an upper bound.
+func parseDateFlag(name, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: expected YYYY-MM-DD", name, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory,
+// for paths the shell did not expand, as in --metadata-file=~/papers.jsonl.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// parseModeFlag parses an octal permission flag value such as 0644. An
+// empty value yields 0, which keeps the default mode.
+func parseModeFlag(name, value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid --%s %q: expected octal permission bits such as 0644", name, value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// parseSubmittedRange parses the --from and --to flags and checks that they
+// form a valid range.
+func parseSubmittedRange(fromValue, toValue string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	now := time.Now()
+	if fromValue != "" {
+		if from, err = download.ParseDateBound(fromValue, now); err != nil {
+			return from, to, fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if toValue != "" {
+		if to, err = download.ParseDateBound(toValue, now); err != nil {
+			return from, to, fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if _, err := download.SubmittedDateClause(from, to); err != nil {
+		return from, to, err
+	}
+	return from, to, nil
+}
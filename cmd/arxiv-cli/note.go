@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/spf13/cobra"
+)
+
+func newNoteCmd() *cobra.Command {
+	var workspace string
+
+	// update loads the workspace's notes, applies change and saves them.
+	update := func(change func(store *download.NoteStore) error) error {
+		path := filepath.Join(workspace, download.NotesFile)
+		store, err := download.LoadNotes(path)
+		if err != nil {
+			return err
+		}
+		if err := change(store); err != nil {
+			return err
+		}
+		return store.Save(path)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "note <id> <text>",
+		Short: "Add a note to a paper",
+		Long:  "Append a timestamped note to a paper in the workspace's notes.json. Notes are kept by arXiv ID without version, survive metadata refreshes, and only appear in exports made with --include-notes.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return update(func(store *download.NoteStore) error {
+				if err := store.Add(args[0], strings.Join(args[1:], " "), time.Now()); err != nil {
+					return err
+				}
+				fmt.Printf("added note %d to %s\n", len(store.Notes(args[0])), args[0])
+				return nil
+			})
+		},
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show the notes on a paper, or on every paper",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := download.LoadNotes(filepath.Join(workspace, download.NotesFile))
+			if err != nil {
+				return err
+			}
+			ids := store.IDs()
+			if len(args) == 1 {
+				id, err := download.ParseID(args[0])
+				if err != nil {
+					return err
+				}
+				ids = []string{download.BaseID(id)}
+			}
+			for _, id := range ids {
+				notes := store.Notes(id)
+				if len(notes) == 0 {
+					fmt.Printf("%s has no notes\n", id)
+					continue
+				}
+				fmt.Println(id)
+				for i, note := range notes {
+					fmt.Printf("  %d. %s  %s\n", i+1, note.Time.Local().Format("2006-01-02 15:04"), note.Text)
+				}
+			}
+			return nil
+		},
+	}
+
+	editCmd := &cobra.Command{
+		Use:   "edit <id> <n> <text>",
+		Short: "Replace the text of a paper's n-th note",
+		Args:  cobra.MinimumNArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid note number %q", args[1])
+			}
+			return update(func(store *download.NoteStore) error {
+				return store.Edit(args[0], n, strings.Join(args[2:], " "))
+			})
+		},
+	}
+
+	rmCmd := &cobra.Command{
+		Use:   "rm <id> <n>",
+		Short: "Delete a paper's n-th note",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid note number %q", args[1])
+			}
+			return update(func(store *download.NoteStore) error {
+				return store.Remove(args[0], n)
+			})
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&workspace, "workspace", ".", "The workspace whose notes to change (the --output-dir of its runs)")
+	cmd.AddCommand(showCmd, editCmd, rmCmd)
+	return cmd
+}
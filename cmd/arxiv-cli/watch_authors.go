@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/spf13/cobra"
+)
+
+func newWatchAuthorsCmd() *cobra.Command {
+	var (
+		authors     []string
+		orcids      []string
+		interval    time.Duration
+		limit       int
+		strictMatch bool
+		once        bool
+		flushEvery  string
+		fileModeArg string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch-authors",
+		Short: "Poll arXiv for new papers by specific authors",
+		Long:  "Periodically search arXiv for papers by the watched authors and report each paper not seen in a previous poll, labeled with the watched authors who appear on it.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(authors) == 0 && len(orcids) == 0 {
+				return fmt.Errorf("at least one --author or --orcid is required")
+			}
+
+			flush, err := download.ParseFlushPolicy(flushEvery)
+			if err != nil {
+				return fmt.Errorf("invalid --flush-every: %w", err)
+			}
+			fileMode, err := parseModeFlag("file-mode", fileModeArg)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			opts := download.AuthorWatchOptions{
+				Limit:       limit,
+				StrictMatch: strictMatch,
+				StatePath:   download.AuthorStateFile,
+				Interval:    interval,
+				Flush:       flush,
+				FileMode:    fileMode,
+				Out:         os.Stdout,
+			}
+			if once {
+				opts.Interval = 0
+			}
+			for _, name := range authors {
+				opts.Authors = append(opts.Authors, download.WatchedAuthor{Name: name})
+			}
+			for _, orcid := range orcids {
+				author, err := download.AuthorFromORCID(ctx, orcid)
+				if err != nil {
+					return fmt.Errorf("failed to resolve ORCID %s: %w", orcid, err)
+				}
+				opts.Authors = append(opts.Authors, author)
+			}
+
+			return download.WatchAuthors(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&authors, "author", nil, "Name of an author to watch (repeatable)")
+	cmd.Flags().StringArrayVar(&orcids, "orcid", nil, "ORCID iD of an author to watch; name variants are taken from the ORCID record (repeatable)")
+	cmd.Flags().DurationVar(&interval, "interval", 12*time.Hour, "How long to wait between polls")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "The maximum number of papers to fetch per author name and poll")
+	cmd.Flags().BoolVar(&strictMatch, "strict-match", false, "Whether or not to require an exact normalized author name match")
+	cmd.Flags().StringVar(&flushEvery, "flush-every", "10s", "When to write new metadata to disk: always, a number of papers (e.g. 20) or an interval (e.g. 10s)")
+	cmd.Flags().StringVar(&fileModeArg, "file-mode", "", "Octal mode of the metadata and state files, e.g. 0600, regardless of the umask (default: 0644 less the umask)")
+	cmd.Flags().BoolVar(&once, "once", false, "Whether or not to poll a single time and exit")
+
+	return cmd
+}
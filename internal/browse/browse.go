@@ -0,0 +1,280 @@
+// Package browse is a terminal browser over search results: a list of
+// titles beside a preview of the selected paper's abstract, navigated with
+// the arrow keys, from which PDFs can be downloaded.
+//
+// The state and layout live in Browser, which knows nothing about
+// terminals; Run drives it through a Terminal, so that everything but the
+// raw terminal handling can be tested.
+package browse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+)
+
+// Key is a key press, reduced to what the browser responds to.
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyDownload
+	KeyQuit
+)
+
+// Action is what the caller of Browser.Handle has to do next.
+type Action int
+
+const (
+	ActionNone     Action = iota
+	ActionDownload        // download the selected paper
+	ActionQuit
+)
+
+// Terminal is the screen the browser draws on and reads keys from.
+type Terminal interface {
+	// Size returns the width and height of the screen in cells.
+	Size() (width, height int, err error)
+	// Draw replaces the screen content with lines.
+	Draw(lines []string) error
+	// ReadKey waits for the next key press.
+	ReadKey() (Key, error)
+}
+
+// helpLine is shown at the bottom of the screen when there is no status.
+const helpLine = "↑/↓ move · PgUp/PgDn page · d download PDF · q quit"
+
+// Browser is the state of the browser: the papers, the selected one and the
+// part of the list on screen.
+type Browser struct {
+	papers []download.ArxivPaper
+	cursor int
+	top    int // index of the first paper on screen
+	rows   int // list rows on screen
+	status string
+}
+
+// New returns a browser over papers with the first one selected.
+func New(papers []download.ArxivPaper) *Browser {
+	return &Browser{papers: papers, rows: 1}
+}
+
+// Selected returns the selected paper; ok is false when there are none.
+func (b *Browser) Selected() (paper download.ArxivPaper, ok bool) {
+	if len(b.papers) == 0 {
+		return download.ArxivPaper{}, false
+	}
+	return b.papers[b.cursor], true
+}
+
+// SetStatus shows message in place of the help line until the next key.
+func (b *Browser) SetStatus(message string) {
+	b.status = message
+}
+
+// Resize fits the list to a screen height rows high, keeping the selected
+// paper visible.
+func (b *Browser) Resize(height int) {
+	b.rows = max(height-1, 1) // the last line holds help or status
+	b.scroll()
+}
+
+// Handle moves the selection according to key and reports what else the
+// key asks for.
+func (b *Browser) Handle(key Key) Action {
+	b.status = ""
+	switch key {
+	case KeyUp:
+		b.cursor--
+	case KeyDown:
+		b.cursor++
+	case KeyPageUp:
+		b.cursor -= b.rows
+	case KeyPageDown:
+		b.cursor += b.rows
+	case KeyHome:
+		b.cursor = 0
+	case KeyEnd:
+		b.cursor = len(b.papers) - 1
+	case KeyDownload:
+		if len(b.papers) > 0 {
+			return ActionDownload
+		}
+	case KeyQuit:
+		return ActionQuit
+	}
+	b.cursor = min(max(b.cursor, 0), max(len(b.papers)-1, 0))
+	b.scroll()
+	return ActionNone
+}
+
+// scroll moves the visible part of the list as little as needed to show
+// the selected paper.
+func (b *Browser) scroll() {
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+b.rows {
+		b.top = b.cursor - b.rows + 1
+	}
+	b.top = max(min(b.top, len(b.papers)-b.rows), 0)
+}
+
+// View renders the screen, width cells wide and height lines high: the list
+// of titles on the left, the selected paper on the right and the help or
+// status line at the bottom.
+func (b *Browser) View(width, height int) []string {
+	listWidth := max(width*2/5, 10)
+	previewWidth := max(width-listWidth-3, 10)
+
+	var list []string
+	for i := b.top; i < len(b.papers) && len(list) < b.rows; i++ {
+		marker := "  "
+		if i == b.cursor {
+			marker = "> "
+		}
+		list = append(list, marker+truncate(b.papers[i].Title, listWidth-2))
+	}
+	if len(b.papers) == 0 {
+		list = append(list, "no papers")
+	}
+
+	var preview []string
+	if paper, ok := b.Selected(); ok {
+		preview = append(preview, wrap(paper.Title, previewWidth)...)
+		preview = append(preview, wrap(strings.Join(paper.Authors, ", "), previewWidth)...)
+		preview = append(preview, wrap(details(paper), previewWidth)...)
+		preview = append(preview, "")
+		preview = append(preview, wrap(paper.Summary, previewWidth)...)
+	}
+
+	lines := make([]string, 0, height)
+	for row := 0; row < height-1; row++ {
+		var left, right string
+		if row < len(list) {
+			left = list[row]
+		}
+		if row < len(preview) {
+			right = preview[row]
+		}
+		lines = append(lines, pad(left, listWidth)+" │ "+right)
+	}
+	status := b.status
+	if status == "" {
+		status = helpLine
+	}
+	return append(lines, truncate(status, width))
+}
+
+// details is the line of the preview giving the ID, date and categories.
+func details(paper download.ArxivPaper) string {
+	id := "arXiv:" + download.BaseID(paper.ID)
+	if paper.Version > 0 {
+		id += fmt.Sprintf("v%d", paper.Version)
+	}
+	parts := []string{id}
+	if len(paper.Published) >= len("2006-01-02") {
+		parts = append(parts, paper.Published[:len("2006-01-02")])
+	}
+	if len(paper.Categories) > 0 {
+		parts = append(parts, strings.Join(paper.Categories, " "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Run shows papers in the browser on term until the user quits, calling
+// fetchPDF for each paper whose download is asked for; it returns the path
+// saved to, which is shown in the status line.
+func Run(ctx context.Context, term Terminal, papers []download.ArxivPaper, fetchPDF func(context.Context, download.ArxivPaper) (string, error)) error {
+	b := New(papers)
+	for ctx.Err() == nil {
+		width, height, err := term.Size()
+		if err != nil {
+			return fmt.Errorf("failed to get terminal size: %w", err)
+		}
+		b.Resize(height)
+		if err := term.Draw(b.View(width, height)); err != nil {
+			return fmt.Errorf("failed to draw: %w", err)
+		}
+		key, err := term.ReadKey()
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+
+		switch b.Handle(key) {
+		case ActionQuit:
+			return nil
+		case ActionDownload:
+			paper, _ := b.Selected()
+			b.SetStatus("downloading " + paper.Title + "...")
+			if err := term.Draw(b.View(width, height)); err != nil {
+				return fmt.Errorf("failed to draw: %w", err)
+			}
+			if path, err := fetchPDF(ctx, paper); err != nil {
+				b.SetStatus(err.Error())
+			} else {
+				b.SetStatus("saved " + path)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// truncate shortens s to at most width runes, marking the cut with "...".
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(r[:max(width, 0)])
+	}
+	return string(r[:width-3]) + "..."
+}
+
+// pad fills s with spaces to width runes.
+func pad(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// wrap breaks s into lines of at most width runes at spaces, splitting
+// words longer than width.
+func wrap(s string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		switch {
+		case len(line) == 0:
+			line = w
+		case len(line)+1+len(w) <= width:
+			line = append(append(line, ' '), w...)
+		default:
+			lines = append(lines, string(line))
+			line = w
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,171 @@
+package browse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+)
+
+func testPapers(n int) []download.ArxivPaper {
+	papers := make([]download.ArxivPaper, n)
+	for i := range papers {
+		papers[i] = download.ArxivPaper{
+			ID:      fmt.Sprintf("http://arxiv.org/abs/2401.%05dv1", i),
+			Version: 1,
+			Title:   fmt.Sprintf("Paper %d", i),
+			Authors: []string{"Jane Doe"},
+			Summary: fmt.Sprintf("Abstract of paper %d.", i),
+		}
+	}
+	return papers
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	b := New(testPapers(10))
+	b.Resize(5) // four list rows
+
+	steps := []struct {
+		key        Key
+		wantCursor int
+		wantTop    int
+	}{
+		{KeyUp, 0, 0},
+		{KeyDown, 1, 0},
+		{KeyDown, 2, 0},
+		{KeyDown, 3, 0},
+		{KeyDown, 4, 1},
+		{KeyPageDown, 8, 5},
+		{KeyPageDown, 9, 6},
+		{KeyDown, 9, 6},
+		{KeyPageUp, 5, 5},
+		{KeyHome, 0, 0},
+		{KeyEnd, 9, 6},
+	}
+	for _, step := range steps {
+		if action := b.Handle(step.key); action != ActionNone {
+			t.Errorf("Handle(%d) = %d, want ActionNone", step.key, action)
+		}
+		if b.cursor != step.wantCursor || b.top != step.wantTop {
+			t.Errorf("after key %d: cursor %d, top %d; want %d, %d", step.key, b.cursor, b.top, step.wantCursor, step.wantTop)
+		}
+	}
+	if paper, ok := b.Selected(); !ok || paper.Title != "Paper 9" {
+		t.Errorf("Selected() = %q, %v", paper.Title, ok)
+	}
+
+	if action := b.Handle(KeyDownload); action != ActionDownload {
+		t.Errorf("Handle(KeyDownload) = %d, want ActionDownload", action)
+	}
+	if action := b.Handle(KeyQuit); action != ActionQuit {
+		t.Errorf("Handle(KeyQuit) = %d, want ActionQuit", action)
+	}
+
+	// Growing the screen shows as much of the list as fits.
+	b.Resize(20)
+	if b.top != 0 {
+		t.Errorf("top = %d after growing the screen, want 0", b.top)
+	}
+}
+
+func TestBrowserEmpty(t *testing.T) {
+	b := New(nil)
+	b.Resize(10)
+	for _, key := range []Key{KeyDown, KeyEnd, KeyPageDown, KeyDownload} {
+		if action := b.Handle(key); action != ActionNone {
+			t.Errorf("Handle(%d) = %d without papers, want ActionNone", key, action)
+		}
+	}
+	if _, ok := b.Selected(); ok {
+		t.Error("Selected() found a paper in an empty list")
+	}
+	if lines := b.View(80, 10); !strings.Contains(lines[0], "no papers") {
+		t.Errorf("View() = %q", lines)
+	}
+}
+
+func TestBrowserView(t *testing.T) {
+	b := New(testPapers(3))
+	b.Resize(6)
+	b.Handle(KeyDown)
+
+	lines := b.View(60, 6)
+	if len(lines) != 6 {
+		t.Fatalf("View() has %d lines, want 6", len(lines))
+	}
+	for i, want := range []string{"  Paper 0", "> Paper 1", "  Paper 2"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d = %q, want it to start with %q", i, lines[i], want)
+		}
+	}
+	preview := strings.Join(lines, "\n")
+	for _, want := range []string{"│ Paper 1", "│ Jane Doe", "│ arXiv:2401.00001v1", "│ Abstract of paper 1."} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("preview lacks %q:\n%s", want, preview)
+		}
+	}
+	if lines[5] != helpLine {
+		t.Errorf("last line = %q, want the help", lines[5])
+	}
+}
+
+// fakeTerminal replays keys and records what was drawn.
+type fakeTerminal struct {
+	keys  []Key
+	drawn [][]string
+}
+
+func (f *fakeTerminal) Size() (int, int, error) { return 80, 8, nil }
+
+func (f *fakeTerminal) Draw(lines []string) error {
+	f.drawn = append(f.drawn, lines)
+	return nil
+}
+
+func (f *fakeTerminal) ReadKey() (Key, error) {
+	if len(f.keys) == 0 {
+		return KeyNone, io.EOF
+	}
+	key := f.keys[0]
+	f.keys = f.keys[1:]
+	return key, nil
+}
+
+func TestRun(t *testing.T) {
+	term := &fakeTerminal{keys: []Key{KeyDown, KeyDownload, KeyDown, KeyDownload, KeyNone, KeyQuit}}
+	var fetched []string
+	fetch := func(ctx context.Context, paper download.ArxivPaper) (string, error) {
+		fetched = append(fetched, paper.Title)
+		if paper.Title == "Paper 2" {
+			return "", errors.New("HTTP 503")
+		}
+		return "pdfs/" + paper.Title + ".pdf", nil
+	}
+
+	if err := Run(context.Background(), term, testPapers(3), fetch); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if strings.Join(fetched, ",") != "Paper 1,Paper 2" {
+		t.Errorf("fetched %v, want Paper 1 and Paper 2", fetched)
+	}
+
+	var statuses []string
+	for _, lines := range term.drawn {
+		statuses = append(statuses, lines[len(lines)-1])
+	}
+	all := strings.Join(statuses, "\n")
+	for _, want := range []string{"downloading Paper 1...", "saved pdfs/Paper 1.pdf", "HTTP 503"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("status lines lack %q:\n%s", want, all)
+		}
+	}
+
+	term = &fakeTerminal{}
+	if err := Run(context.Background(), term, testPapers(1), fetch); !errors.Is(err, io.EOF) {
+		t.Errorf("Run() error = %v, want the read error", err)
+	}
+}
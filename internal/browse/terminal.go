@@ -0,0 +1,112 @@
+package browse
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ttyTerminal is a Terminal on the process's terminal, in raw mode and on
+// the alternate screen while it is open.
+type ttyTerminal struct {
+	in    *os.File
+	out   *os.File
+	keys  *bufio.Reader
+	state *term.State
+}
+
+// OpenTerminal puts the terminal of in and out in raw mode and switches to
+// the alternate screen; the function it returns restores both.
+func OpenTerminal(in, out *os.File) (Terminal, func() error, error) {
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return nil, nil, fmt.Errorf("browsing needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	t := &ttyTerminal{in: in, out: out, keys: bufio.NewReader(in), state: state}
+	// Switch to the alternate screen and hide the cursor.
+	_, _ = fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	return t, t.close, nil
+}
+
+func (t *ttyTerminal) close() error {
+	_, _ = fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	return term.Restore(int(t.in.Fd()), t.state)
+}
+
+func (t *ttyTerminal) Size() (int, int, error) {
+	return term.GetSize(int(t.out.Fd()))
+}
+
+func (t *ttyTerminal) Draw(lines []string) error {
+	// Raw mode does not turn "\n" into "\r\n".
+	_, err := fmt.Fprint(t.out, "\x1b[H\x1b[2J"+strings.Join(lines, "\x1b[K\r\n"))
+	return err
+}
+
+func (t *ttyTerminal) ReadKey() (Key, error) {
+	r, _, err := t.keys.ReadRune()
+	if err != nil {
+		return KeyNone, err
+	}
+	switch r {
+	case 'k':
+		return KeyUp, nil
+	case 'j':
+		return KeyDown, nil
+	case 'g':
+		return KeyHome, nil
+	case 'G':
+		return KeyEnd, nil
+	case 'd', '\r':
+		return KeyDownload, nil
+	case 'q', 0x03: // Ctrl-C
+		return KeyQuit, nil
+	case 0x1b:
+		return t.readEscape()
+	}
+	return KeyNone, nil
+}
+
+// readEscape decodes the rest of an escape sequence such as "\x1b[A" for
+// the up arrow. A lone Escape, with nothing buffered after it, quits.
+func (t *ttyTerminal) readEscape() (Key, error) {
+	if t.keys.Buffered() == 0 {
+		return KeyQuit, nil
+	}
+	if b, err := t.keys.ReadByte(); err != nil || (b != '[' && b != 'O') {
+		return KeyNone, err
+	}
+	var seq []byte
+	for {
+		b, err := t.keys.ReadByte()
+		if err != nil {
+			return KeyNone, err
+		}
+		seq = append(seq, b)
+		// Sequences end with a letter or a tilde.
+		if b == '~' || (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') {
+			break
+		}
+	}
+	switch string(seq) {
+	case "A":
+		return KeyUp, nil
+	case "B":
+		return KeyDown, nil
+	case "5~":
+		return KeyPageUp, nil
+	case "6~":
+		return KeyPageDown, nil
+	case "H", "1~":
+		return KeyHome, nil
+	case "F", "4~":
+		return KeyEnd, nil
+	}
+	return KeyNone, nil
+}
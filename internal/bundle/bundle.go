@@ -0,0 +1,526 @@
+// Package bundle packs papers from an arxiv-cli workspace into a shareable
+// archive and merges such archives into another workspace.
+//
+// A bundle is a zstd-compressed tar file. Its first member, manifest.json,
+// lists the papers it carries with a SHA-256 checksum for every file; the
+// metadata lines of those papers follow in metadata.jsonl, together with
+// whichever of their files the exporting workspace had: those listed in
+// each paper's "files" metadata, or else its PDF and summary. Bundles
+// compressed with gzip, as written by earlier versions, can still be
+// imported.
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ProvenanceFile records, per paper, where a workspace got it from.
+const ProvenanceFile = "provenance.jsonl"
+
+const (
+	manifestName = "manifest.json"
+	formatV1     = 1
+)
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	Format  int           `json:"format"`
+	Created time.Time     `json:"created"`
+	Papers  []PaperRecord `json:"papers"`
+}
+
+// PaperRecord lists the files a bundle carries for one paper, keyed by their
+// slash-separated path inside the bundle, with their SHA-256 checksums.
+type PaperRecord struct {
+	ID    string            `json:"id"`
+	Title string            `json:"title"`
+	Files map[string]string `json:"files"`
+}
+
+// Provenance is a line of ProvenanceFile.
+type Provenance struct {
+	ID         string    `json:"id"`
+	Source     string    `json:"source"`
+	ImportedAt time.Time `json:"imported_at"`
+}
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Workspace is the directory holding metadata.jsonl, pdfs/ and texts/.
+	Workspace string
+	// IDs selects the papers to export; an ID without version matches any
+	// version.
+	IDs []string
+	// FilenameTemplate is the template the workspace's files were saved
+	// with; empty means download.DefaultFilenameTemplate. It only names the
+	// PDF and summary of papers whose metadata lists no files.
+	FilenameTemplate string
+	// Now stamps the manifest; tests fix it.
+	Now func() time.Time
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported []string // IDs merged into the workspace
+	Skipped  []string // IDs the workspace already had
+}
+
+// metadataLine is a line of a metadata file, kept verbatim so that fields
+// such as an inlined summary survive the round trip.
+type metadataLine struct {
+	paper download.ArxivPaper
+	raw   []byte
+}
+
+// Export writes a bundle of the selected papers to w and returns its
+// manifest. Papers whose files are not on disk are exported without them;
+// requesting an ID the workspace does not have is an error.
+func Export(w io.Writer, opts ExportOptions) (*Manifest, error) {
+	lines, err := readMetadata(filepath.Join(opts.Workspace, download.JSONFile))
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectPapers(lines, opts.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	manifest := &Manifest{Format: formatV1, Created: now().UTC()}
+
+	var metadata bytes.Buffer
+	type member struct{ name, source string }
+	var members []member
+	for _, line := range selected {
+		record := PaperRecord{ID: line.paper.ID, Title: line.paper.Title, Files: map[string]string{}}
+		for _, file := range paperFiles(line.paper, opts.FilenameTemplate) {
+			if !safePath(file) {
+				return nil, fmt.Errorf("refusing to export %s of %s: path leaves the workspace", file, line.paper.ID)
+			}
+			source := filepath.Join(opts.Workspace, filepath.FromSlash(file))
+			sum, err := fileChecksum(source)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			record.Files[file] = sum
+			members = append(members, member{file, source})
+		}
+		manifest.Papers = append(manifest.Papers, record)
+		metadata.Write(line.raw)
+		metadata.WriteByte('\n')
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	defer func() { _ = zw.Close() }()
+	tw := tar.NewWriter(zw)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeMember(tw, manifestName, bytes.NewReader(manifestData), int64(len(manifestData)), manifest.Created); err != nil {
+		return nil, err
+	}
+	if err := writeMember(tw, download.JSONFile, &metadata, int64(metadata.Len()), manifest.Created); err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if err := writeFileMember(tw, m.name, m.source); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// Import merges the bundle read from r into workspace. Every file is checked
+// against the manifest before anything is written; papers the workspace
+// already has (by versionless ID) are skipped, and each imported paper is
+// recorded in ProvenanceFile with source as its origin.
+func Import(r io.Reader, workspace, source string) (*ImportResult, error) {
+	staging, err := os.MkdirTemp(workspace, ".bundle-import-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	manifest, err := extract(r, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, err := readMetadata(filepath.Join(staging, download.JSONFile))
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]metadataLine{}
+	for _, line := range incoming {
+		byID[line.paper.ID] = line
+	}
+
+	existing, err := readMetadata(filepath.Join(workspace, download.JSONFile))
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, line := range existing {
+		have[download.BaseID(line.paper.ID)] = true
+	}
+
+	result := &ImportResult{}
+	var metadata, provenance bytes.Buffer
+	for _, record := range manifest.Papers {
+		if have[download.BaseID(record.ID)] {
+			result.Skipped = append(result.Skipped, record.ID)
+			continue
+		}
+		line, ok := byID[record.ID]
+		if !ok {
+			return nil, fmt.Errorf("bundle has no metadata for %s", record.ID)
+		}
+		for file := range record.Files {
+			target := filepath.Join(workspace, filepath.FromSlash(file))
+			if _, err := os.Stat(target); err == nil {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := os.Rename(filepath.Join(staging, filepath.FromSlash(file)), target); err != nil {
+				return nil, fmt.Errorf("failed to move %s into the workspace: %w", file, err)
+			}
+		}
+		metadata.Write(line.raw)
+		metadata.WriteByte('\n')
+
+		entry, err := json.Marshal(Provenance{ID: record.ID, Source: source, ImportedAt: time.Now().UTC()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal provenance: %w", err)
+		}
+		provenance.Write(entry)
+		provenance.WriteByte('\n')
+
+		have[download.BaseID(record.ID)] = true
+		result.Imported = append(result.Imported, record.ID)
+	}
+
+	if err := appendFile(filepath.Join(workspace, download.JSONFile), metadata.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := appendFile(filepath.Join(workspace, ProvenanceFile), provenance.Bytes()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompress returns the tar stream of a bundle, telling a gzip-compressed
+// one from a zstd-compressed one by its first bytes.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		return gzip.NewReader(br)
+	}
+	zr, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// extract unpacks the bundle into dir and verifies every file against the
+// manifest, which must come first.
+func extract(r io.Reader, dir string) (*Manifest, error) {
+	archive, err := decompress(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+	tr := tar.NewReader(archive)
+
+	var manifest *Manifest
+	expected := map[string]string{}
+	seen := map[string]bool{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected bundle member %s", header.Name)
+		}
+
+		if manifest == nil {
+			if header.Name != manifestName {
+				return nil, fmt.Errorf("bundle does not start with %s", manifestName)
+			}
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			if manifest.Format != formatV1 {
+				return nil, fmt.Errorf("unsupported bundle format %d", manifest.Format)
+			}
+			for _, record := range manifest.Papers {
+				for file, sum := range record.Files {
+					expected[file] = sum
+				}
+			}
+			continue
+		}
+
+		name := header.Name
+		if !safePath(name) {
+			return nil, fmt.Errorf("refusing bundle member with unsafe path %s", name)
+		}
+		sum, listed := expected[name]
+		if !listed && name != download.JSONFile {
+			return nil, fmt.Errorf("bundle member %s is not in the manifest", name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		got, err := writeChecked(target, tr)
+		if err != nil {
+			return nil, err
+		}
+		if listed && got != sum {
+			return nil, fmt.Errorf("checksum mismatch for %s", name)
+		}
+		seen[name] = true
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("bundle is empty")
+	}
+	if !seen[download.JSONFile] {
+		return nil, fmt.Errorf("bundle has no %s", download.JSONFile)
+	}
+	for file := range expected {
+		if !seen[file] {
+			return nil, fmt.Errorf("bundle is missing %s", file)
+		}
+	}
+	return manifest, nil
+}
+
+// readMetadata reads a metadata file, returning no lines if it does not
+// exist.
+func readMetadata(path string) ([]metadataLine, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []metadataLine
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line metadataLine
+		if err := json.Unmarshal(raw, &line.paper); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		line.raw = append([]byte(nil), raw...)
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return lines, nil
+}
+
+// paperFiles returns the slash-separated paths, relative to the workspace,
+// of the files of paper: those its metadata lists, or for older metadata
+// the PDF and summary named after template.
+func paperFiles(paper download.ArxivPaper, template string) []string {
+	if len(paper.Files) > 0 {
+		files := make([]string, 0, len(paper.Files))
+		for _, file := range paper.Files {
+			files = append(files, file)
+		}
+		slices.Sort(files)
+		return slices.Compact(files)
+	}
+	name := download.FormatFilename(template, paper)
+	return []string{
+		path.Join(strings.TrimSuffix(download.PDFDirectory, "/"), name+".pdf"),
+		path.Join(strings.TrimSuffix(download.TextDirectory, "/"), name+".txt"),
+	}
+}
+
+// selectPapers returns the lines matching ids, in the order of ids.
+func selectPapers(lines []metadataLine, ids []string) ([]metadataLine, error) {
+	var selected []metadataLine
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		id = shortID(id)
+		versioned := download.BaseID(id) != id
+		found := false
+		for _, line := range lines {
+			if (versioned && shortID(line.paper.ID) == id) || (!versioned && download.BaseID(line.paper.ID) == id) {
+				selected = append(selected, line)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("paper %s is not in the workspace", id)
+		}
+	}
+	return selected, nil
+}
+
+// shortID strips the abs URL prefix from an entry ID.
+func shortID(id string) string {
+	if i := strings.Index(id, "/abs/"); i >= 0 {
+		return id[i+len("/abs/"):]
+	}
+	return id
+}
+
+// ReadIDs reads one arXiv ID per line from r, ignoring blank lines and lines
+// starting with #.
+func ReadIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IDs: %w", err)
+	}
+	return ids, nil
+}
+
+func safePath(name string) bool {
+	return name != "" && !path.IsAbs(name) && !strings.Contains(name, "\\") && path.Clean(name) == name && !strings.HasPrefix(name, "../") && name != ".."
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeChecked copies r to path and returns the SHA-256 of what was written.
+func writeChecked(path string, r io.Reader) (string, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(path), err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeMember(tw *tar.Writer, name string, r io.Reader, size int64, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+func writeFileMember(tw *tar.Writer, name, source string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", source, err)
+	}
+	return writeMember(tw, name, file, info.Size(), info.ModTime())
+}
+
+func appendFile(path string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
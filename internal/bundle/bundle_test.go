@@ -0,0 +1,226 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// writeWorkspace creates a workspace holding three papers: "Alpha" with a
+// PDF and a summary, "Beta" with a PDF only and "Gamma" with metadata only.
+func writeWorkspace(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	metadata := strings.Join([]string{
+		`{"id":"http://arxiv.org/abs/2401.00001v2","title":"Alpha","summary":"Inlined abstract."}`,
+		`{"id":"http://arxiv.org/abs/2401.00002v1","title":"Beta"}`,
+		`{"id":"http://arxiv.org/abs/2401.00003v1","title":"Gamma"}`,
+	}, "\n") + "\n"
+	files := map[string]string{
+		"metadata.jsonl":  metadata,
+		"pdfs/Alpha.pdf":  "%PDF-1.4 alpha",
+		"texts/Alpha.txt": "Alpha summary",
+		"pdfs/Beta.pdf":   "%PDF-1.4 beta",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	from := writeWorkspace(t)
+	to := t.TempDir()
+	// The receiving workspace already has Beta, at another version.
+	if err := os.WriteFile(filepath.Join(to, "metadata.jsonl"), []byte(`{"id":"http://arxiv.org/abs/2401.00002v3","title":"Beta"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := Export(&archive, ExportOptions{
+		Workspace: from,
+		IDs:       []string{"2401.00001", "2401.00002v1", "2401.00003v1"},
+		Now:       func() time.Time { return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) },
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(manifest.Papers) != 3 || len(manifest.Papers[0].Files) != 2 || len(manifest.Papers[2].Files) != 0 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+
+	result, err := Import(&archive, to, "colleague.tar.zst")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if got := strings.Join(result.Imported, ","); got != "http://arxiv.org/abs/2401.00001v2,http://arxiv.org/abs/2401.00003v1" {
+		t.Errorf("imported %s", got)
+	}
+	if got := strings.Join(result.Skipped, ","); got != "http://arxiv.org/abs/2401.00002v1" {
+		t.Errorf("skipped %s", got)
+	}
+
+	for _, name := range []string{"pdfs/Alpha.pdf", "texts/Alpha.txt"} {
+		want, _ := os.ReadFile(filepath.Join(from, name))
+		got, err := os.ReadFile(filepath.Join(to, name))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s = %q (%v), want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(to, "pdfs/Beta.pdf")); !os.IsNotExist(err) {
+		t.Errorf("skipped paper's PDF was imported")
+	}
+
+	metadata, _ := os.ReadFile(filepath.Join(to, "metadata.jsonl"))
+	lines := strings.Split(strings.TrimSpace(string(metadata)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"summary":"Inlined abstract."`) {
+		t.Errorf("metadata after import:\n%s", metadata)
+	}
+
+	provenance, _ := os.ReadFile(filepath.Join(to, ProvenanceFile))
+	if strings.Count(string(provenance), `"source":"colleague.tar.zst"`) != 2 {
+		t.Errorf("provenance after import:\n%s", provenance)
+	}
+
+	entries, _ := os.ReadDir(to)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".bundle-import-") {
+			t.Errorf("staging directory %s was left behind", entry.Name())
+		}
+	}
+}
+
+func TestImportRejectsChecksumMismatch(t *testing.T) {
+	from := writeWorkspace(t)
+	var archive bytes.Buffer
+	if _, err := Export(&archive, ExportOptions{Workspace: from, IDs: []string{"2401.00002"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// Rewrite the bundle with a tampered PDF of the same size.
+	tampered := regzip(t, &archive, func(name string, data []byte) []byte {
+		if name == "pdfs/Beta.pdf" {
+			return bytes.ToUpper(data)
+		}
+		return data
+	})
+
+	to := t.TempDir()
+	if _, err := Import(tampered, to, "bad.tar.zst"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Import() error = %v, want checksum mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(to, "metadata.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("a failed import wrote metadata")
+	}
+}
+
+// regzip rewrites a bundle as the gzip-compressed tar files of earlier
+// versions, passing the data of each member through edit.
+func regzip(t *testing.T, archive io.Reader, edit func(name string, data []byte) []byte) *bytes.Buffer {
+	t.Helper()
+	zr, err := zstd.NewReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		_ = tw.WriteHeader(header)
+		_, _ = tw.Write(edit(header.Name, data))
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	return &out
+}
+
+func TestImportGzipBundle(t *testing.T) {
+	from := writeWorkspace(t)
+	var archive bytes.Buffer
+	if _, err := Export(&archive, ExportOptions{Workspace: from, IDs: []string{"2401.00002"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	old := regzip(t, &archive, func(_ string, data []byte) []byte { return data })
+
+	result, err := Import(old, t.TempDir(), "old.tar.gz")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Imported) != 1 {
+		t.Errorf("imported %v, want one paper", result.Imported)
+	}
+}
+
+func TestExportUnknownID(t *testing.T) {
+	if _, err := Export(io.Discard, ExportOptions{Workspace: writeWorkspace(t), IDs: []string{"2401.99999"}}); err == nil {
+		t.Error("Export() succeeded for an ID missing from the workspace")
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	for name, want := range map[string]bool{
+		"pdfs/Alpha.pdf": true,
+		"../escape.pdf":  false,
+		"/etc/passwd":    false,
+		"pdfs/../../x":   false,
+		"pdfs\\x.pdf":    false,
+	} {
+		if got := safePath(name); got != want {
+			t.Errorf("safePath(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExportUsesRecordedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"metadata.jsonl":              `{"id":"http://arxiv.org/abs/2401.00001v1","title":"Alpha","files":{"pdf":"pdfs/2401.00001v1.pdf","source":"sources/2401.00001v1.tar.gz"}}` + "\n",
+		"pdfs/2401.00001v1.pdf":       "%PDF-1.4 alpha",
+		"sources/2401.00001v1.tar.gz": "source",
+		"pdfs/Alpha.pdf":              "%PDF-1.4 named after the default template",
+		"texts/Alpha.txt":             "Alpha summary",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest, err := Export(io.Discard, ExportOptions{Workspace: dir, IDs: []string{"2401.00001"}})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	got := manifest.Papers[0].Files
+	if len(got) != 2 || got["pdfs/2401.00001v1.pdf"] == "" || got["sources/2401.00001v1.tar.gz"] == "" {
+		t.Errorf("exported files = %v, want the recorded PDF and source", got)
+	}
+}
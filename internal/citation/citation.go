@@ -0,0 +1,249 @@
+// Package citation formats papers as reference-list entries. Styles are Go
+// text/template templates executed on a Data value; APA, MLA and Chicago
+// are built in and more can be loaded from a style sheet.
+package citation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+)
+
+// Built-in style names.
+const (
+	StyleAPA     = "apa"
+	StyleMLA     = "mla"
+	StyleChicago = "chicago"
+)
+
+var builtinStyles = map[string]string{
+	StyleAPA:     `{{apaAuthors .Authors}} ({{.Year}}). {{.Title}} (arXiv:{{.ArxivID}}). arXiv. https://doi.org/10.48550/arXiv.{{.ArxivID}}`,
+	StyleMLA:     `{{mlaAuthors .Authors}}. "{{.Title}}." arXiv, {{mlaDate .Date}}, arxiv.org/abs/{{.ArxivID}}.`,
+	StyleChicago: `{{chicagoAuthors .Authors}}. {{.Year}}. "{{.Title}}." arXiv preprint arXiv:{{.ArxivID}}.`,
+}
+
+// Author is an author name split for citation purposes.
+type Author struct {
+	Given  string
+	Family string
+}
+
+// Initials returns the given names as initials, e.g. "J. R." for "John
+// Ronald".
+func (a Author) Initials() string {
+	var initials []string
+	for _, name := range strings.Fields(a.Given) {
+		var parts []string
+		for _, part := range strings.Split(name, "-") {
+			if r := []rune(part); len(r) > 0 {
+				parts = append(parts, string(r[0])+".")
+			}
+		}
+		initials = append(initials, strings.Join(parts, "-"))
+	}
+	return strings.Join(initials, " ")
+}
+
+// Data is what a style template is executed on.
+type Data struct {
+	Title           string
+	Authors         []Author
+	AuthorNames     []string // as listed on arXiv
+	ArxivID         string   // without version, e.g. "2401.00001"
+	URL             string
+	DOI             string
+	JournalRef      string
+	PrimaryCategory string
+	Date            time.Time // publication date
+	Year            int
+	Paper           download.ArxivPaper
+}
+
+// NewData prepares paper for formatting.
+func NewData(paper download.ArxivPaper) Data {
+	data := Data{
+		Title:           paper.Title,
+		AuthorNames:     paper.Authors,
+		ArxivID:         download.BaseID(paper.ID),
+		URL:             paper.HTMLURL,
+		DOI:             paper.DOI,
+		JournalRef:      paper.JournalRef,
+		PrimaryCategory: paper.PrimaryCategory,
+		Date:            paper.PublishedTime(),
+		Paper:           paper,
+	}
+	if !data.Date.IsZero() {
+		data.Year = data.Date.Year()
+	}
+	for _, name := range paper.Authors {
+		data.Authors = append(data.Authors, splitName(name))
+	}
+	return data
+}
+
+// splitName treats the last word of name as the family name.
+func splitName(name string) Author {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return Author{}
+	}
+	return Author{Given: strings.Join(words[:len(words)-1], " "), Family: words[len(words)-1]}
+}
+
+// Styles is a set of named citation styles.
+type Styles struct {
+	templates map[string]*template.Template
+}
+
+// NewStyles returns the built-in styles.
+func NewStyles() *Styles {
+	s := &Styles{templates: map[string]*template.Template{}}
+	for name, text := range builtinStyles {
+		if err := s.Register(name, text); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// Register adds a style, replacing any style of the same name.
+func (s *Styles) Register(name, text string) error {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse citation style %s: %w", name, err)
+	}
+	s.templates[strings.ToLower(name)] = tmpl
+	return nil
+}
+
+// Has reports whether a style is registered under name.
+func (s *Styles) Has(name string) bool {
+	_, ok := s.templates[strings.ToLower(name)]
+	return ok
+}
+
+// Names returns the registered style names in sorted order.
+func (s *Styles) Names() []string {
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Format renders paper in the named style.
+func (s *Styles) Format(style string, paper download.ArxivPaper) (string, error) {
+	tmpl, ok := s.templates[strings.ToLower(style)]
+	if !ok {
+		return "", fmt.Errorf("unknown citation style %q (expected one of %s)", style, strings.Join(s.Names(), ", "))
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, NewData(paper)); err != nil {
+		return "", fmt.Errorf("failed to format citation in style %s: %w", style, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+var funcs = template.FuncMap{
+	"apaAuthors":     apaAuthors,
+	"mlaAuthors":     mlaAuthors,
+	"chicagoAuthors": chicagoAuthors,
+	"mlaDate":        mlaDate,
+	"join":           strings.Join,
+	"upper":          strings.ToUpper,
+	"lower":          strings.ToLower,
+}
+
+// apaAuthors lists up to 20 authors as "Doe, J., Roe, R., & Poe, E.".
+func apaAuthors(authors []Author) string {
+	var names []string
+	for _, a := range authors {
+		name := a.Family
+		if initials := a.Initials(); initials != "" {
+			name += ", " + initials
+		}
+		names = append(names, name)
+	}
+	switch {
+	case len(names) == 0:
+		return ""
+	case len(names) == 1:
+		return names[0]
+	case len(names) > 20:
+		return strings.Join(names[:19], ", ") + ", ... " + names[len(names)-1]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + ", & " + names[len(names)-1]
+	}
+}
+
+// mlaAuthors gives "Doe, Jane", "Doe, Jane, and Richard Roe" or
+// "Doe, Jane, et al.".
+func mlaAuthors(authors []Author) string {
+	switch len(authors) {
+	case 0:
+		return ""
+	case 1:
+		return invertedName(authors[0])
+	case 2:
+		return invertedName(authors[0]) + ", and " + fullName(authors[1])
+	default:
+		return invertedName(authors[0]) + ", et al"
+	}
+}
+
+// chicagoAuthors gives "Doe, Jane, Richard Roe, and Edgar Poe", listing up
+// to ten authors and abbreviating longer lists to seven followed by "et al".
+func chicagoAuthors(authors []Author) string {
+	if len(authors) == 0 {
+		return ""
+	}
+	names := []string{invertedName(authors[0])}
+	if len(authors) > 10 {
+		for _, a := range authors[1:7] {
+			names = append(names, fullName(a))
+		}
+		return strings.Join(names, ", ") + ", et al"
+	}
+	for _, a := range authors[1:] {
+		names = append(names, fullName(a))
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	if len(names) == 2 {
+		return names[0] + ", and " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", and " + names[len(names)-1]
+}
+
+func invertedName(a Author) string {
+	if a.Given == "" {
+		return a.Family
+	}
+	return a.Family + ", " + a.Given
+}
+
+func fullName(a Author) string {
+	return strings.TrimSpace(a.Given + " " + a.Family)
+}
+
+// mlaDate formats t as "2 Jan. 2024"; months with short names are not
+// abbreviated.
+func mlaDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	month := t.Month().String()
+	if len(month) > 4 {
+		month = month[:3] + "."
+	}
+	if t.Month() == time.September {
+		month = "Sept."
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), month, t.Year())
+}
@@ -0,0 +1,127 @@
+package citation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+)
+
+func testPaper(authors ...string) download.ArxivPaper {
+	return download.ArxivPaper{
+		ID:        "http://arxiv.org/abs/2401.00001v2",
+		Title:     "Attention Is All You Need",
+		Published: "2024-01-02T00:00:00Z",
+		Authors:   authors,
+		HTMLURL:   "http://arxiv.org/abs/2401.00001v2",
+	}
+}
+
+func TestBuiltinStyles(t *testing.T) {
+	styles := NewStyles()
+	tests := []struct {
+		style   string
+		authors []string
+		want    string
+	}{
+		{StyleAPA, []string{"Jane Doe"}, "Doe, J. (2024). Attention Is All You Need (arXiv:2401.00001). arXiv. https://doi.org/10.48550/arXiv.2401.00001"},
+		{StyleAPA, []string{"Jane Ann Doe", "Richard Roe", "Jean-Luc Picard"}, "Doe, J. A., Roe, R., & Picard, J.-L. (2024). Attention Is All You Need (arXiv:2401.00001). arXiv. https://doi.org/10.48550/arXiv.2401.00001"},
+		{StyleMLA, []string{"Jane Doe", "Richard Roe"}, `Doe, Jane, and Richard Roe. "Attention Is All You Need." arXiv, 2 Jan. 2024, arxiv.org/abs/2401.00001.`},
+		{StyleMLA, []string{"Jane Doe", "Richard Roe", "Edgar Poe"}, `Doe, Jane, et al. "Attention Is All You Need." arXiv, 2 Jan. 2024, arxiv.org/abs/2401.00001.`},
+		{StyleChicago, []string{"Jane Doe", "Richard Roe", "Edgar Poe"}, `Doe, Jane, Richard Roe, and Edgar Poe. 2024. "Attention Is All You Need." arXiv preprint arXiv:2401.00001.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			got, err := styles.Format(tt.style, testPaper(tt.authors...))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() =\n  %s\nwant\n  %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUnknownStyle(t *testing.T) {
+	_, err := NewStyles().Format("harvard", testPaper("Jane Doe"))
+	if err == nil || !strings.Contains(err.Error(), "apa, chicago, mla") {
+		t.Errorf("Format() error = %v, want the known styles listed", err)
+	}
+}
+
+func TestLoadStyleSheet(t *testing.T) {
+	sheet := `# House styles
+styles:
+  ieee: '{{join .AuthorNames ", "}}, "{{.Title}}," arXiv:{{.ArxivID}}, {{.Year}}.'
+  short: "{{.ArxivID}}\t{{.Year}}"   # tab separated
+  "lab report": |
+    {{apaAuthors .Authors}} ({{.Year}})
+    # not a comment inside a block
+    {{.Title}}
+  folded: >-
+    {{.Title}}
+    ({{.Year}})
+  APA: "{{.Title}} overrides the built-in"
+`
+	path := filepath.Join(t.TempDir(), "styles.yaml")
+	if err := os.WriteFile(path, []byte(sheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	styles := NewStyles()
+	if err := styles.LoadStyleSheet(path); err != nil {
+		t.Fatalf("LoadStyleSheet() error = %v", err)
+	}
+
+	paper := testPaper("Jane Doe", "Richard Roe")
+	tests := map[string]string{
+		"ieee":       `Jane Doe, Richard Roe, "Attention Is All You Need," arXiv:2401.00001, 2024.`,
+		"short":      "2401.00001\t2024",
+		"lab report": "Doe, J., & Roe, R. (2024)\n# not a comment inside a block\nAttention Is All You Need",
+		"folded":     "Attention Is All You Need (2024)",
+		"apa":        "Attention Is All You Need overrides the built-in",
+		"mla":        `Doe, Jane, and Richard Roe. "Attention Is All You Need." arXiv, 2 Jan. 2024, arxiv.org/abs/2401.00001.`,
+	}
+	for style, want := range tests {
+		got, err := styles.Format(style, paper)
+		if err != nil {
+			t.Errorf("Format(%q) error = %v", style, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Format(%q) = %q, want %q", style, got, want)
+		}
+	}
+}
+
+func TestParseStyleSheet(t *testing.T) {
+	for name, sheet := range map[string]string{
+		"nested": "styles:\n  short: '{{.ArxivID}}'\n",
+		"flat":   "short: '{{.ArxivID}}'\n",
+	} {
+		styles, err := parseStyleSheet([]byte(sheet))
+		if err != nil {
+			t.Errorf("%s: parseStyleSheet() error = %v", name, err)
+			continue
+		}
+		if len(styles) != 1 || styles["short"] != "{{.ArxivID}}" {
+			t.Errorf("%s: parseStyleSheet() = %q", name, styles)
+		}
+	}
+
+	for name, sheet := range map[string]string{
+		"not a mapping":     "- ieee\n",
+		"no styles":         "# nothing yet\n",
+		"empty styles":      "styles: {}\n",
+		"empty template":    "a:\n",
+		"template not text": "styles:\n  a:\n    b: c\n",
+		"invalid YAML":      "a: 'x\n",
+	} {
+		if _, err := parseStyleSheet([]byte(sheet)); err == nil {
+			t.Errorf("%s: parseStyleSheet() succeeded, want error", name)
+		}
+	}
+}
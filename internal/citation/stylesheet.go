@@ -0,0 +1,64 @@
+package citation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadStyleSheet registers the styles defined in a YAML style sheet, which
+// maps style names to templates, optionally under a top-level "styles" key:
+//
+//	styles:
+//	  ieee: '{{join .AuthorNames ", "}}, "{{.Title}}," arXiv:{{.ArxivID}}, {{.Year}}.'
+//	  house: |
+//	    {{apaAuthors .Authors}} ({{.Year}}) {{.Title}}
+func (s *Styles) LoadStyleSheet(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read citation style sheet: %w", err)
+	}
+	styles, err := parseStyleSheet(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse citation style sheet %s: %w", path, err)
+	}
+	names := make([]string, 0, len(styles))
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.Register(name, styles[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// styleSheet is a style sheet with its styles under a "styles" key.
+type styleSheet struct {
+	Styles map[string]string `yaml:"styles"`
+}
+
+// parseStyleSheet returns the templates of a style sheet by style name.
+func parseStyleSheet(data []byte) (map[string]string, error) {
+	var sheet styleSheet
+	if err := yaml.Unmarshal(data, &sheet); err != nil || sheet.Styles == nil {
+		// Not nested: the whole document maps names to templates.
+		if err := yaml.Unmarshal(data, &sheet.Styles); err != nil {
+			return nil, err
+		}
+	}
+	if len(sheet.Styles) == 0 {
+		return nil, errors.New("no styles defined")
+	}
+	for name, template := range sheet.Styles {
+		if template == "" {
+			return nil, fmt.Errorf("style %s has an empty template", name)
+		}
+	}
+	return sheet.Styles, nil
+}
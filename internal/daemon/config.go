@@ -0,0 +1,112 @@
+// Package daemon runs the searches described in a profiles file on their own
+// schedules, so periodic downloads work without cron.
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/AstraBert/arxiv-cli/internal/schedule"
+)
+
+// DefaultConfigFile is the profiles file read when none is given.
+const DefaultConfigFile = "arxiv-cli-profiles.json"
+
+// Config is the content of a profiles file.
+type Config struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// Profile is one scheduled search. Exactly one of Interval (a Go duration
+// such as "6h") or Cron (a five-field cron expression) must be set. Outputs
+// go to OutputDir, which defaults to the profile name.
+type Profile struct {
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	Limit      int    `json:"limit"`
+	PDF        bool   `json:"pdf"`
+	Summary    bool   `json:"summary"`
+	NoMetadata bool   `json:"no_metadata"`
+	Interval   string `json:"interval"`
+	Cron       string `json:"cron"`
+	OutputDir  string `json:"output_dir"`
+}
+
+// LoadConfig reads and validates the profiles file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles file: %w", err)
+	}
+	if len(cfg.Profiles) == 0 {
+		return nil, fmt.Errorf("profiles file %s defines no profiles", path)
+	}
+
+	seen := map[string]bool{}
+	for i, profile := range cfg.Profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("profile %d has no name", i+1)
+		}
+		if seen[profile.Name] {
+			return nil, fmt.Errorf("profile %q is defined twice", profile.Name)
+		}
+		seen[profile.Name] = true
+		if profile.Query == "" {
+			return nil, fmt.Errorf("profile %q has no query", profile.Name)
+		}
+		if _, err := profile.schedule(); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// schedule parses the profile's interval or cron expression.
+func (p Profile) schedule() (schedule.Schedule, error) {
+	switch {
+	case p.Interval != "" && p.Cron != "":
+		return nil, fmt.Errorf("profile %q sets both interval and cron", p.Name)
+	case p.Interval != "":
+		d, err := time.ParseDuration(p.Interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("profile %q has invalid interval %q", p.Name, p.Interval)
+		}
+		return schedule.Every(d), nil
+	case p.Cron != "":
+		c, err := schedule.ParseCron(p.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("profile %q needs an interval or a cron expression", p.Name)
+	}
+}
+
+// options maps the profile to a download run.
+func (p Profile) options() download.Options {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+	outputDir := p.OutputDir
+	if outputDir == "" {
+		outputDir = p.Name
+	}
+	return download.Options{
+		Query:         p.Query,
+		Limit:         limit,
+		SaveMetadata:  !p.NoMetadata,
+		SavePDFs:      p.PDF,
+		SaveSummaries: p.Summary,
+		OutputDir:     outputDir,
+	}
+}
@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/download"
+	"github.com/AstraBert/arxiv-cli/internal/schedule"
+)
+
+// DefaultStateDir holds the daemon's status file and per-profile logs.
+const DefaultStateDir = ".arxiv-cli-daemon"
+
+// statusFile is the name of the status file inside the state directory.
+const statusFile = "status.json"
+
+// runProfile performs one run of a profile; tests replace it.
+var runProfile = func(ctx context.Context, profile Profile, log io.Writer) error {
+	opts := profile.options()
+	opts.Out = log
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return download.Run(ctx, opts)
+}
+
+// Run schedules every profile in cfg until ctx is cancelled. Runs happen one
+// at a time, so together with the download package's shared rate limiter the
+// API is never hit concurrently. Each profile logs to <stateDir>/<name>.log
+// and the schedule is persisted to the status file after every change, so a
+// restarted daemon picks up where it left off, staggering the profiles that
+// came due meanwhile (see schedule.WarmStart). Each next run is moved by up
+// to jitter, a fraction of the profile's period, either way.
+func Run(ctx context.Context, cfg *Config, stateDir string, clock schedule.Clock, jitter float64) error {
+	if jitter < 0 || jitter >= 1 {
+		return fmt.Errorf("poll jitter must be at least 0%% and below 100%%")
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	state, err := ReadStatus(stateDir)
+	if err != nil {
+		return err
+	}
+
+	scheduler := &schedule.Scheduler{
+		Clock:    clock,
+		State:    map[string]*schedule.JobState{},
+		OnUpdate: func(state map[string]*schedule.JobState) error { return writeStatus(stateDir, state) },
+		Jitter:   jitter,
+	}
+	for _, profile := range cfg.Profiles {
+		sched, err := profile.schedule()
+		if err != nil {
+			return err
+		}
+		if saved, ok := state[profile.Name]; ok {
+			scheduler.State[profile.Name] = saved
+		}
+		scheduler.Jobs = append(scheduler.Jobs, schedule.Job{
+			Name:     profile.Name,
+			Schedule: sched,
+			Run:      profileJob(profile, stateDir, clock),
+		})
+	}
+
+	err = scheduler.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// profileJob wraps a profile run with its log file.
+func profileJob(profile Profile, stateDir string, clock schedule.Clock) func(context.Context) error {
+	return func(ctx context.Context) error {
+		logPath := filepath.Join(stateDir, sanitizeName(profile.Name)+".log")
+		log, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		defer func() { _ = log.Close() }()
+
+		_, _ = fmt.Fprintf(log, "%s run started\n", clock.Now().Format(time.RFC3339))
+		err = runProfile(ctx, profile, log)
+		result := schedule.ResultOK
+		if err != nil {
+			result = err.Error()
+		}
+		_, _ = fmt.Fprintf(log, "%s run finished: %s\n", clock.Now().Format(time.RFC3339), result)
+		return err
+	}
+}
+
+// ReadStatus loads the persisted schedule from stateDir; a missing status
+// file yields an empty map.
+func ReadStatus(stateDir string) (map[string]*schedule.JobState, error) {
+	state := map[string]*schedule.JobState{}
+	data, err := os.ReadFile(filepath.Join(stateDir, statusFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon status: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon status: %w", err)
+	}
+	return state, nil
+}
+
+// writeStatus persists the schedule via a temporary file and a rename, so a
+// reader (or a daemon killed mid-write) never sees a half-written file.
+func writeStatus(stateDir string, state map[string]*schedule.JobState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal daemon status: %w", err)
+	}
+	tmp := filepath.Join(stateDir, statusFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write daemon status: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(stateDir, statusFile)); err != nil {
+		return fmt.Errorf("failed to write daemon status: %w", err)
+	}
+	return nil
+}
+
+// PrintStatus renders the schedule as a table of next and last runs.
+func PrintStatus(w io.Writer, state map[string]*schedule.JobState) error {
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROFILE\tNEXT RUN\tLAST RUN\tLAST RESULT")
+	for _, name := range names {
+		job := state[name]
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, formatTime(job.NextRun), formatTime(job.LastRun), job.LastResult)
+	}
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// sanitizeName makes a profile name safe to use as a file name.
+func sanitizeName(name string) string {
+	out := []rune(name)
+	for i, r := range out {
+		if r == '/' || r == '\\' || r == ':' {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/schedule"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	return nil
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultConfigFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no profiles", `{"profiles": []}`, "no profiles"},
+		{"missing query", `{"profiles": [{"name": "a", "interval": "1h"}]}`, "no query"},
+		{"missing schedule", `{"profiles": [{"name": "a", "query": "q"}]}`, "interval or a cron"},
+		{"both schedules", `{"profiles": [{"name": "a", "query": "q", "interval": "1h", "cron": "* * * * *"}]}`, "both"},
+		{"bad cron", `{"profiles": [{"name": "a", "query": "q", "cron": "61 * * * *"}]}`, "out of range"},
+		{"duplicate", `{"profiles": [{"name": "a", "query": "q", "interval": "1h"}, {"name": "a", "query": "q", "interval": "1h"}]}`, "twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfig() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	cfg, err := LoadConfig(writeConfig(t, `{"profiles": [{"name": "cl", "query": "cat:cs.CL", "pdf": true, "cron": "0 6 * * *"}]}`))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	opts := cfg.Profiles[0].options()
+	if opts.OutputDir != "cl" || opts.Limit != 5 || !opts.SavePDFs || !opts.SaveMetadata {
+		t.Errorf("options() = %+v, want defaults applied", opts)
+	}
+}
+
+func TestRunPersistsStatusAndLogs(t *testing.T) {
+	stateDir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs []string
+	oldRun := runProfile
+	runProfile = func(ctx context.Context, profile Profile, log io.Writer) error {
+		runs = append(runs, profile.Name)
+		_, _ = io.WriteString(log, "fetched papers\n")
+		if profile.Name == "broken" {
+			return errors.New("arXiv API returned HTTP 503")
+		}
+		if len(runs) == 3 {
+			cancel()
+		}
+		return nil
+	}
+	t.Cleanup(func() { runProfile = oldRun })
+
+	cfg := &Config{Profiles: []Profile{
+		{Name: "cl", Query: "cat:cs.CL", Interval: "2h"},
+		{Name: "broken", Query: "cat:cs.AI", Interval: "3h"},
+	}}
+	if err := Run(ctx, cfg, stateDir, clock, 0); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := strings.Join(runs, ","); got != "cl,broken,cl" {
+		t.Errorf("runs = %s, want cl,broken,cl", got)
+	}
+
+	state, err := ReadStatus(stateDir)
+	if err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	if state["broken"].LastResult != "arXiv API returned HTTP 503" {
+		t.Errorf("broken last result = %q", state["broken"].LastResult)
+	}
+	if state["cl"].LastResult != schedule.ResultInterrupted {
+		t.Errorf("cl last result = %q, want interrupted", state["cl"].LastResult)
+	}
+	if !state["cl"].NextRun.Equal(time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("cl next run = %v, want the interrupted run to stay due", state["cl"].NextRun)
+	}
+
+	log, err := os.ReadFile(filepath.Join(stateDir, "broken.log"))
+	if err != nil {
+		t.Fatalf("Failed to read profile log: %v", err)
+	}
+	if !strings.Contains(string(log), "fetched papers") || !strings.Contains(string(log), "HTTP 503") {
+		t.Errorf("profile log = %q, want run output and result", log)
+	}
+
+	var out strings.Builder
+	if err := PrintStatus(&out, state); err != nil {
+		t.Fatalf("PrintStatus() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "broken") || !strings.Contains(out.String(), "NEXT RUN") {
+		t.Errorf("PrintStatus() = %q", out.String())
+	}
+}
@@ -0,0 +1,84 @@
+package download
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// abstractFeed is Feed without the parts of each entry that abstract-only
+// mode skips.
+type abstractFeed struct {
+	XMLName      xml.Name        `xml:"feed"`
+	TotalResults int             `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   *int            `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	ItemsPerPage int             `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage"`
+	Links        []Link          `xml:"link"`
+	Entries      []abstractEntry `xml:"entry"`
+}
+
+type abstractEntry struct {
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Summary string   `xml:"summary"`
+	Authors []Author `xml:"author"`
+}
+
+// fetchAbstracts is fetchArxivPapers in abstract-only mode.
+func fetchAbstracts(ctx context.Context, searchQuery string, start, numResults int) (*searchPage, error) {
+	return fetchFeed(ctx, searchParams(searchQuery, start, numResults, "submittedDate"), parseAbstractFeed)
+}
+
+// parseAbstractFeed is the fast path of parseFeed: it decodes the feed as it
+// streams in and fills only the ID (and Version), title, authors and
+// summary of each paper. Links, categories, dates and the arXiv extension elements are
+// skipped, as are the derived fields (paper type, reading level) and the raw
+// entries kept for --store-raw-entry.
+func parseAbstractFeed(r io.Reader) (*searchPage, error) {
+	var feed abstractFeed
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse XML response: %w", err)
+	}
+
+	papers := make([]ArxivPaper, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		paper := ArxivPaper{
+			ID:      cleanField(entry.ID),
+			Title:   collapseWhitespace(entry.Title),
+			Summary: cleanField(entry.Summary),
+			Authors: make([]string, 0, len(entry.Authors)),
+		}
+		paper.setArxivID()
+		for _, author := range entry.Authors {
+			paper.Authors = append(paper.Authors, collapseWhitespace(author.Name))
+		}
+		papers = append(papers, paper)
+	}
+	return newSearchPage(papers, feed.TotalResults, feed.StartIndex, feed.ItemsPerPage, feed.Links), nil
+}
+
+// abstractOnlyConflicts lists the options that rely on fields abstract-only
+// mode leaves empty.
+func (o Options) abstractOnlyConflicts() []string {
+	var conflicts []string
+	add := func(set bool, name string) {
+		if set {
+			conflicts = append(conflicts, name)
+		}
+	}
+	add(o.SavePDFs, "PDF downloads")
+	add(o.PrintURLs, "printing PDF URLs")
+	add(o.StoreRawEntry, "raw entries")
+	add(o.FindPublishedVersion, "published version lookups")
+	add(!o.DateFrom.IsZero() || !o.DateTo.IsZero(), "the date filter")
+	add(!o.UpdatedAfter.IsZero(), "the updated-after filter")
+	add(o.Category != "" || len(o.ExcludeCategories) > 0, "the category filters")
+	add(o.PaperType != "", "the paper type filter")
+	add(o.MinReadingLevel != 0 || o.MaxReadingLevel != 0, "the reading level filter")
+	for _, field := range o.Require {
+		add(!slices.Contains([]string{"id", "title", "summary", "authors"}, field), "requiring "+field)
+	}
+	return conflicts
+}
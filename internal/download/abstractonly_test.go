@@ -0,0 +1,105 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAbstractFeedFields(t *testing.T) {
+	feed := fakeFeed(40, fakeEntry("2401.00001v2", "A  Fast\n  Path"), fakeEntry("2401.00002v1", "Second"))
+
+	full, err := parseFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	fast, err := parseAbstractFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("parseAbstractFeed() error = %v", err)
+	}
+	if len(fast.Papers) != len(full.Papers) {
+		t.Fatalf("parseAbstractFeed() returned %d papers, want %d", len(fast.Papers), len(full.Papers))
+	}
+	if fast.TotalResults != 40 {
+		t.Errorf("TotalResults = %d, want 40", fast.TotalResults)
+	}
+
+	for i, got := range fast.Papers {
+		want := full.Papers[i]
+		if got.ID != want.ID || got.Title != want.Title || got.Summary != want.Summary || !slices.Equal(got.Authors, want.Authors) {
+			t.Errorf("paper %d = %q %q %q %q, want %q %q %q %q", i,
+				got.ID, got.Title, got.Summary, got.Authors, want.ID, want.Title, want.Summary, want.Authors)
+		}
+		if got.PDFURL != "" || got.HTMLURL != "" || got.PrimaryCategory != "" || len(got.Categories) != 0 || got.Published != "" {
+			t.Errorf("paper %d has skipped fields set: %+v", i, got)
+		}
+	}
+	if fast.Papers[0].Title != "A Fast Path" {
+		t.Errorf("Title = %q, want whitespace collapsed", fast.Papers[0].Title)
+	}
+}
+
+func TestRunAbstractOnly(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(3))
+	chdirTemp(t)
+
+	err := Run(testingContext(t), Options{Query: "all:x", Limit: 3, SaveSummaries: true, AbstractOnly: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	summaries, _ := filepath.Glob(filepath.Join(TextDirectory, "*.txt"))
+	if len(summaries) != 3 {
+		t.Fatalf("saved %d summaries, want 3", len(summaries))
+	}
+	data, err := os.ReadFile(summaries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "Summary of Paper ") {
+		t.Errorf("summary = %q", data)
+	}
+}
+
+func TestRunAbstractOnlyRejectsConflicts(t *testing.T) {
+	err := Run(testingContext(t), Options{Query: "all:x", Limit: 1, SavePDFs: true, Category: "cs.CL", AbstractOnly: true})
+	if err == nil {
+		t.Fatal("Run() error = nil, want a conflict with PDF downloads and the category filter")
+	}
+	for _, want := range []string{"PDF downloads", "category filters"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+// benchmarkFeed is a feed of n entries shaped like the API's.
+func benchmarkFeed(n int) string {
+	entries := make([]string, n)
+	for i := range entries {
+		entries[i] = fakeEntry(fmt.Sprintf("2401.%05dv1", i), fmt.Sprintf("Paper %d on the reading level of long abstracts", i))
+	}
+	return fakeFeed(n, entries...)
+}
+
+func BenchmarkParseFeed(b *testing.B) {
+	feed := benchmarkFeed(2000)
+	b.SetBytes(int64(len(feed)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parseFeed(strings.NewReader(feed)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseAbstractFeed(b *testing.B) {
+	feed := benchmarkFeed(2000)
+	b.SetBytes(int64(len(feed)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parseAbstractFeed(strings.NewReader(feed)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
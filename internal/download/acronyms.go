@@ -0,0 +1,90 @@
+package download
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// acronymPattern matches a parenthesized acronym: at least two capitals,
+// possibly mixed with lowercase letters and digits as in "GraphRAG" or
+// "LLMs".
+var acronymPattern = regexp.MustCompile(`\(([A-Z][A-Za-z0-9]*[A-Z][A-Za-z0-9]*)\)`)
+
+// acronymFillers are words an expansion may contain without contributing a
+// letter, as in "Bidirectional Encoder Representations from Transformers".
+var acronymFillers = map[string]bool{
+	"a": true, "an": true, "and": true, "by": true, "for": true, "from": true,
+	"in": true, "of": true, "on": true, "the": true, "to": true, "with": true,
+}
+
+// ExtractAcronyms returns the acronyms defined in s, such as "Graph
+// Retrieval-Augmented Generation (GraphRAG)", mapped to their expansions.
+// An expansion is the shortest run of words right before the parentheses
+// whose initials, counting each part of a hyphenated word, spell the
+// acronym's capitals in order. Parenthesized capitals without such an
+// expansion are not definitions and are skipped; the first definition of
+// an acronym wins.
+func ExtractAcronyms(s string) map[string]string {
+	var acronyms map[string]string
+	for _, match := range acronymPattern.FindAllStringSubmatchIndex(s, -1) {
+		acronym := s[match[2]:match[3]]
+		if _, ok := acronyms[acronym]; ok {
+			continue
+		}
+		expansion := acronymExpansion(strings.Fields(s[:match[0]]), acronym)
+		if expansion == "" {
+			continue
+		}
+		if acronyms == nil {
+			acronyms = map[string]string{}
+		}
+		acronyms[acronym] = expansion
+	}
+	return acronyms
+}
+
+// acronymExpansion finds the expansion of acronym at the end of words.
+func acronymExpansion(words []string, acronym string) string {
+	var letters []rune
+	for _, r := range acronym {
+		if unicode.IsUpper(r) {
+			letters = append(letters, unicode.ToLower(r))
+		}
+	}
+
+	maxWords := 2 * len(letters)
+	for start := len(words) - 1; start >= 0 && len(words)-start <= maxWords; start-- {
+		if strings.ContainsAny(words[start], ".;:") && start < len(words)-1 {
+			// The expansion does not reach back into the previous sentence.
+			break
+		}
+		if spells(words[start:], letters) {
+			return strings.TrimRight(strings.Join(words[start:], " "), ",")
+		}
+	}
+	return ""
+}
+
+// spells reports whether the initials of words, skipping filler words,
+// are exactly letters.
+func spells(words []string, letters []rune) bool {
+	i := 0
+	for w, word := range words {
+		for _, part := range strings.Split(word, "-") {
+			part = strings.TrimFunc(part, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+			if part == "" {
+				continue
+			}
+			initial := unicode.ToLower([]rune(part)[0])
+			if i < len(letters) && initial == letters[i] {
+				i++
+				continue
+			}
+			if w == 0 || !acronymFillers[strings.ToLower(part)] {
+				return false
+			}
+		}
+	}
+	return i == len(letters)
+}
@@ -0,0 +1,75 @@
+package download
+
+import (
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractAcronyms(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		want    map[string]string
+	}{
+		{
+			name:    "hyphenated words",
+			summary: "We introduce Graph Retrieval-Augmented Generation (GraphRAG), a method.",
+			want:    map[string]string{"GraphRAG": "Graph Retrieval-Augmented Generation"},
+		},
+		{
+			name:    "lowercase expansion and plural",
+			summary: "Recent large language models (LLMs) and retrieval-augmented generation (RAG) help.",
+			want:    map[string]string{"LLMs": "large language models", "RAG": "retrieval-augmented generation"},
+		},
+		{
+			name:    "filler words",
+			summary: "Bidirectional Encoder Representations from Transformers (BERT) is a model.",
+			want:    map[string]string{"BERT": "Bidirectional Encoder Representations from Transformers"},
+		},
+		{
+			name:    "first definition wins",
+			summary: "Natural language processing (NLP) is hard. Nobody likes pain (NLP).",
+			want:    map[string]string{"NLP": "Natural language processing"},
+		},
+		{
+			name:    "mentions without a definition",
+			summary: "Results on the benchmark (SOTA) improve. See (AB).",
+			want:    nil,
+		},
+		{
+			name:    "stops at the previous sentence",
+			summary: "We use machine learning. Quantum (MLQ) too.",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractAcronyms(tt.summary); !maps.Equal(got, tt.want) {
+				t.Errorf("ExtractAcronyms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExtractsAcronyms(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := strings.Replace(fakeEntry("2401.00001v1", "Paper 1"), "Summary of Paper 1.", "We study large language models (LLMs).", 1)
+		_, _ = w.Write([]byte(fakeFeed(1, entry)))
+	}))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, ExtractAcronyms: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if !strings.Contains(string(content), `"acronyms":{"LLMs":"large language models"}`) {
+		t.Errorf("metadata lacks the acronym:\n%s", content)
+	}
+}
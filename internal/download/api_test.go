@@ -0,0 +1,181 @@
+package download
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var updateAPI = flag.Bool("update-api", false, "rewrite testdata/api.txt from the current sources")
+
+// apiFile lists the exported API of the package, one declaration per line.
+const apiFile = "testdata/api.txt"
+
+// TestPublicAPI fails when a declaration listed in apiFile was removed or
+// changed in a way that can break callers. Additions pass; record them with
+// go test -run TestPublicAPI -update-api.
+func TestPublicAPI(t *testing.T) {
+	current, err := exportedAPI(".")
+	if err != nil {
+		t.Fatalf("failed to read the package API: %v", err)
+	}
+	if *updateAPI {
+		if err := os.WriteFile(apiFile, []byte(strings.Join(current, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	content, err := os.ReadFile(apiFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := strings.Split(strings.TrimSpace(string(content)), "\n")
+	for _, line := range recorded {
+		if !slices.Contains(current, line) {
+			t.Errorf("incompatible API change: %q was removed or changed", line)
+		}
+	}
+	for _, line := range current {
+		if !slices.Contains(recorded, line) {
+			t.Logf("new API, not yet in %s: %s", apiFile, line)
+		}
+	}
+}
+
+// exportedAPI lists the exported declarations of the package in dir:
+// functions and methods with their signatures, the exported fields of
+// structs, the methods of interfaces, and constants and variables. Parameter
+// names are left out, as renaming them breaks no caller.
+func exportedAPI(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var api []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if !decl.Name.IsExported() {
+					continue
+				}
+				if decl.Recv == nil {
+					api = append(api, fmt.Sprintf("func %s%s", decl.Name.Name, signature(fset, decl.Type)))
+					continue
+				}
+				recv := render(fset, decl.Recv.List[0].Type)
+				if ast.IsExported(strings.TrimPrefix(recv, "*")) {
+					api = append(api, fmt.Sprintf("method (%s) %s%s", recv, decl.Name.Name, signature(fset, decl.Type)))
+				}
+			case *ast.GenDecl:
+				api = append(api, genDeclAPI(fset, decl)...)
+			}
+		}
+	}
+	slices.Sort(api)
+	return slices.Compact(api), nil
+}
+
+func genDeclAPI(fset *token.FileSet, decl *ast.GenDecl) []string {
+	var api []string
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if !spec.Name.IsExported() {
+				continue
+			}
+			name := spec.Name.Name
+			switch typ := spec.Type.(type) {
+			case *ast.StructType:
+				api = append(api, "type "+name+" struct")
+				for _, field := range typ.Fields.List {
+					fieldType := render(fset, field.Type)
+					if len(field.Names) == 0 && ast.IsExported(strings.TrimPrefix(fieldType, "*")) {
+						api = append(api, fmt.Sprintf("field %s embeds %s", name, fieldType))
+					}
+					for _, fieldName := range field.Names {
+						if fieldName.IsExported() {
+							api = append(api, fmt.Sprintf("field %s.%s %s", name, fieldName.Name, fieldType))
+						}
+					}
+				}
+			case *ast.InterfaceType:
+				api = append(api, "type "+name+" interface")
+				for _, method := range typ.Methods.List {
+					for _, methodName := range method.Names {
+						api = append(api, fmt.Sprintf("interface %s.%s%s", name, methodName.Name, signature(fset, method.Type.(*ast.FuncType))))
+					}
+				}
+			default:
+				if spec.Assign.IsValid() {
+					api = append(api, fmt.Sprintf("type %s = %s", name, render(fset, spec.Type)))
+				} else {
+					api = append(api, fmt.Sprintf("type %s %s", name, render(fset, spec.Type)))
+				}
+			}
+		case *ast.ValueSpec:
+			kind := decl.Tok.String()
+			for _, valueName := range spec.Names {
+				if !valueName.IsExported() {
+					continue
+				}
+				if spec.Type != nil {
+					api = append(api, fmt.Sprintf("%s %s %s", kind, valueName.Name, render(fset, spec.Type)))
+				} else {
+					api = append(api, fmt.Sprintf("%s %s", kind, valueName.Name))
+				}
+			}
+		}
+	}
+	return api
+}
+
+// signature renders a function type without parameter names, e.g.
+// "(context.Context, string, int) ([]ArxivPaper, error)".
+func signature(fset *token.FileSet, fn *ast.FuncType) string {
+	list := func(fields *ast.FieldList) []string {
+		var types []string
+		if fields == nil {
+			return types
+		}
+		for _, field := range fields.List {
+			for range max(len(field.Names), 1) {
+				types = append(types, render(fset, field.Type))
+			}
+		}
+		return types
+	}
+	params := "(" + strings.Join(list(fn.Params), ", ") + ")"
+	switch results := list(fn.Results); len(results) {
+	case 0:
+		return params
+	case 1:
+		return params + " " + results[0]
+	default:
+		return params + " (" + strings.Join(results, ", ") + ")"
+	}
+}
+
+func render(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
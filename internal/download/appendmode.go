@@ -0,0 +1,61 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// ReadMetadataIDs returns the arXiv IDs of the papers in the JSONL metadata
+// file at path. A missing file has none.
+func ReadMetadataIDs(path string) (map[string]struct{}, error) {
+	ids := map[string]struct{}{}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var record struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		if record.ID != "" {
+			ids[record.ID] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return ids, nil
+}
+
+// validateAppend checks that Append can tell which papers the metadata
+// already holds, which it reads from the JSONL file.
+func (o Options) validateAppend() error {
+	if o.MetadataOut != nil {
+		return fmt.Errorf("appending to the metadata file is not supported while streaming metadata")
+	}
+	if !slices.Contains(o.formats(), format.JSONL) {
+		return fmt.Errorf("appending to the metadata file needs the jsonl format, whose IDs tell which papers are already there")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+func TestRunAppendSkipsKnownPapers(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, Out: &strings.Builder{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+
+	var messages strings.Builder
+	opts.Limit, opts.Append, opts.Out = 3, true, &messages
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("appending Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertValidJSONL(t, string(content), 3)
+	if !strings.Contains(messages.String(), "skipped 2 paper(s) already in "+JSONFile) {
+		t.Errorf("messages = %q, want the skipped papers reported", messages.String())
+	}
+	ids, err := ReadMetadataIDs(JSONFile)
+	if err != nil {
+		t.Fatalf("ReadMetadataIDs() error = %v", err)
+	}
+	if len(ids) != 3 {
+		t.Errorf("ReadMetadataIDs() = %v, want 3 IDs", ids)
+	}
+}
+
+func TestReadMetadataIDs(t *testing.T) {
+	dir := t.TempDir()
+	if ids, err := ReadMetadataIDs(filepath.Join(dir, "missing.jsonl")); err != nil || len(ids) != 0 {
+		t.Errorf("ReadMetadataIDs(missing) = %v, %v, want no IDs", ids, err)
+	}
+
+	path := filepath.Join(dir, JSONFile)
+	if err := os.WriteFile(path, []byte("{\"id\":\"2401.00001v1\"}\n\n{\"id\":\"hep-th/9901001v2\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ReadMetadataIDs(path)
+	if err != nil {
+		t.Fatalf("ReadMetadataIDs() error = %v", err)
+	}
+	for _, id := range []string{"2401.00001v1", "hep-th/9901001v2"} {
+		if _, ok := ids[id]; !ok || len(ids) != 2 {
+			t.Errorf("ReadMetadataIDs() = %v, want %s among 2 IDs", ids, id)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("{\"id\":\"2401.00001v1\"}\nnot json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMetadataIDs(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadMetadataIDs() error = %v, want the bad line named", err)
+	}
+}
+
+func TestRunAppendNeedsJSONL(t *testing.T) {
+	err := Run(testingContext(t), Options{Query: "x", SaveMetadata: true, Append: true, OutputFormats: []format.Format{format.BibTeX}})
+	if err == nil {
+		t.Error("Run() accepted --append without the jsonl format")
+	}
+}
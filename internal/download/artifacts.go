@@ -0,0 +1,105 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of artifact listed in ArxivPaper.Files.
+const (
+	FilePDF           = "pdf"
+	FileFullText      = "fulltext"
+	FileSummary       = "summary"
+	FileEPUB          = "epub"
+	FileSource        = "source"
+	FileRawEntry      = "raw_entry"
+	FileDublinCore    = "dublin_core"
+	FilePodcastScript = "podcast_script"
+	FileAudio         = "audio"
+)
+
+// artifact is a file saved for a paper. One found on disk and left alone,
+// as with SkipExisting, is not written.
+type artifact struct {
+	kind    string
+	path    string
+	written bool
+}
+
+// recordFiles lists artifacts in paper.Files, by kind, as slash-separated
+// paths relative to the output directory. The files an earlier run recorded
+// for the paper (previous) are kept for the kinds this run did not produce,
+// as long as they are still on disk.
+func (o Options) recordFiles(paper *ArxivPaper, artifacts []artifact, previous map[string]string) {
+	files := map[string]string{}
+	for kind, rel := range previous {
+		if _, err := os.Stat(o.path(filepath.FromSlash(rel))); err == nil {
+			files[kind] = rel
+		}
+	}
+	for _, a := range artifacts {
+		files[a.kind] = o.relPath(a.path)
+	}
+	if len(files) > 0 {
+		paper.Files = files
+	}
+}
+
+// relPath returns path relative to the output directory and
+// slash-separated. A path outside it, as with an absolute PDFDir, is kept
+// absolute.
+func (o Options) relPath(path string) string {
+	base := o.OutputDir
+	if base == "" {
+		base = "."
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readRecordedFiles returns the Files of each paper in the JSONL metadata
+// file at path, by arXiv ID. A missing file records none.
+func readRecordedFiles(path string) (map[string]map[string]string, error) {
+	recorded := map[string]map[string]string{}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return recorded, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var record struct {
+			ID    string            `json:"id"`
+			Files map[string]string `json:"files"`
+		}
+		// A line this run cannot read only loses its file list.
+		if json.Unmarshal(raw, &record) == nil && len(record.Files) > 0 {
+			recorded[record.ID] = record.Files
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return recorded, nil
+}
@@ -0,0 +1,118 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// artifactsHandler serves a feed of two papers whose PDFs it also serves.
+func artifactsHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/pdf/") {
+		_, _ = w.Write([]byte("%PDF-1.5\n..."))
+		return
+	}
+	entries := []string{fakeEntry("2401.00001v1", "Paper 1"), fakeEntry("2401.00002v2", "Paper 2")}
+	feed := strings.ReplaceAll(fakeFeed(2, entries...), "http://arxiv.org/pdf/", "http://"+r.Host+"/pdf/")
+	_, _ = fmt.Fprint(w, feed)
+}
+
+// readFiles returns the Files of each paper in the metadata at path.
+func readFiles(t *testing.T, path string) []map[string]string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var paper ArxivPaper
+		if err := json.Unmarshal([]byte(line), &paper); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, paper.Files)
+	}
+	return files
+}
+
+func TestRunRecordsFiles(t *testing.T) {
+	useFakeAPI(t, http.HandlerFunc(artifactsHandler))
+	dir := chdirTemp(t)
+
+	opts := Options{
+		Query:         "cat:cs.CL",
+		Limit:         2,
+		SaveMetadata:  true,
+		SavePDFs:      true,
+		SaveSummaries: true,
+		StoreRawEntry: true,
+		OutputDir:     "library",
+		Out:           &strings.Builder{},
+	}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := map[string]string{
+		FilePDF:      "pdfs/Paper 1.pdf",
+		FileSummary:  "texts/Paper 1.txt",
+		FileRawEntry: "raw/2401.00001v1.xml",
+	}
+	files := readFiles(t, filepath.Join("library", JSONFile))
+	if !reflect.DeepEqual(files[0], want) {
+		t.Errorf("files = %v, want %v", files[0], want)
+	}
+	for _, rel := range files[1] {
+		if _, err := os.Stat(filepath.Join(dir, "library", filepath.FromSlash(rel))); err != nil {
+			t.Errorf("recorded file %s: %v", rel, err)
+		}
+	}
+
+	// A later run under another template records the summary it writes and
+	// keeps the PDF and raw entry the first run recorded.
+	opts.SavePDFs, opts.StoreRawEntry, opts.FilenameTemplate = false, false, "{id}"
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	want[FileSummary] = "texts/2401.00001v1.txt"
+	if files := readFiles(t, filepath.Join("library", JSONFile)); !reflect.DeepEqual(files[0], want) {
+		t.Errorf("files after second run = %v, want %v", files[0], want)
+	}
+}
+
+func TestRunCustomOutputPaths(t *testing.T) {
+	useFakeAPI(t, http.HandlerFunc(artifactsHandler))
+	dir := chdirTemp(t)
+	elsewhere := t.TempDir()
+
+	metadataFile := filepath.Join(elsewhere, "papers", "cs-cl.jsonl")
+	pdfDir := filepath.Join(elsewhere, "library")
+	err := Run(testingContext(t), Options{
+		Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, SavePDFs: true, SaveSummaries: true,
+		OutputDir: "out", MetadataFile: metadataFile, PDFDir: pdfDir, TextDir: "abstracts",
+		Out: &strings.Builder{},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "out", JSONFile)); err == nil {
+		t.Errorf("%s was written despite MetadataFile", JSONFile)
+	}
+	want := map[string]string{
+		FilePDF:     filepath.ToSlash(filepath.Join(pdfDir, "Paper 1.pdf")),
+		FileSummary: "abstracts/Paper 1.txt",
+	}
+	if files := readFiles(t, metadataFile); !reflect.DeepEqual(files[0], want) {
+		t.Errorf("files = %v, want %v", files[0], want)
+	}
+	for _, path := range []string{filepath.Join(pdfDir, "Paper 1.pdf"), filepath.Join(dir, "out", "abstracts", "Paper 1.txt")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("not saved: %v", err)
+		}
+	}
+}
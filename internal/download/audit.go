@@ -0,0 +1,81 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// redactedPath replaces the path of requests whose path carries a secret,
+// in the audit log and in Endpoints.
+const redactedPath = "/REDACTED"
+
+// auditLog receives a line per outbound request; see SetAuditLog.
+var auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetAuditLog makes every outbound request, redirects included, append a line to w once it
+// completes: the UTC time it was sent, the method, host, path and the HTTP
+// status, or "error" when no response came back, as in
+//
+//	2024-01-02T15:04:05Z GET export.arxiv.org /api/query 200
+//
+// Query strings and headers, which may carry keys, are never written, nor
+// are the paths of webhooks. A nil w turns the log off. It should be called
+// before any request is made.
+func SetAuditLog(w io.Writer) {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	auditLog.w = w
+}
+
+type secretPathKey struct{}
+
+// withSecretPath returns ctx marking the requests made with it as carrying
+// a secret in their path, which the audit log then redacts.
+func withSecretPath(ctx context.Context) context.Context {
+	return context.WithValue(ctx, secretPathKey{}, true)
+}
+
+// auditTransport writes a line to the audit log for each request it sends.
+type auditTransport struct {
+	next http.RoundTripper
+}
+
+func (t auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	audit(req.Context(), sent, req.Method, req.URL, status, err)
+	return resp, err
+}
+
+// audit writes the line of a request to the audit log, if any. status is
+// ignored when err is set.
+func audit(ctx context.Context, sent time.Time, method string, u *url.URL, status int, err error) {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.w == nil {
+		return
+	}
+	path := u.EscapedPath()
+	if secret, _ := ctx.Value(secretPathKey{}).(bool); secret {
+		path = redactedPath
+	} else if path == "" {
+		path = "/"
+	}
+	result := fmt.Sprint(status)
+	if err != nil {
+		result = "error"
+	}
+	_, _ = fmt.Fprintf(auditLog.w, "%s %s %s %s %s\n", sent.UTC().Format(time.RFC3339), method, u.Host, path, result)
+}
@@ -0,0 +1,58 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	var log bytes.Buffer
+	SetAuditLog(&log)
+	t.Cleanup(func() { SetAuditLog(nil) })
+
+	api := useFakeAPI(t, pagedFeedHandler(2))
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(webhook.Close)
+	chdirTemp(t)
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, Webhook: webhook.URL + "/services/T0/B0/token?key=secret", Out: &bytes.Buffer{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	apiHost, _ := url.Parse(api.URL)
+	hookHost, _ := url.Parse(webhook.URL)
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	want := []string{
+		fmt.Sprintf("GET %s / 200", apiHost.Host),
+		fmt.Sprintf("POST %s %s 204", hookHost.Host, redactedPath),
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("audit log =\n%s\nwant %d lines", log.String(), len(want))
+	}
+	timestamp := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z `)
+	for i, line := range lines {
+		if !timestamp.MatchString(line) || strings.TrimPrefix(line, timestamp.FindString(line)) != want[i] {
+			t.Errorf("audit line %d = %q, want a timestamp and %q", i, line, want[i])
+		}
+	}
+	if strings.Contains(log.String(), "secret") || strings.Contains(log.String(), "token") {
+		t.Errorf("audit log leaks a secret:\n%s", log.String())
+	}
+
+	// A request without a response is logged as an error.
+	log.Reset()
+	webhook.Close()
+	_ = postWebhook(testingContext(t), webhook.URL, RunSummary{})
+	if !strings.HasSuffix(strings.TrimSpace(log.String()), hookHost.Host+" "+redactedPath+" error") {
+		t.Errorf("audit log of a failed request = %q", log.String())
+	}
+}
@@ -0,0 +1,153 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+// DefaultAuthorsLimit is the number of papers the authors command
+// aggregates when no limit is given.
+const DefaultAuthorsLimit = 100
+
+// AuthorCount is one person in the output of the authors command. Latest is
+// the publication date of their most recent paper, as YYYY-MM-DD.
+type AuthorCount struct {
+	Name     string   `json:"name"`
+	Papers   int      `json:"papers"`
+	Latest   string   `json:"latest"`
+	Variants []string `json:"variants"`
+}
+
+// AuthorsOptions configures Authors.
+type AuthorsOptions struct {
+	Query string
+	Limit int // papers to aggregate; DefaultAuthorsLimit if zero
+
+	// Merge, when set, counts name variants that name the same person under
+	// these rules as one author (see names.Canonicalize). Otherwise only
+	// names that normalize identically are merged.
+	Merge *names.Rules
+
+	JSON bool      // print a JSON array instead of a table
+	Out  io.Writer // defaults to os.Stdout
+}
+
+// CountAuthors aggregates the distinct authors of papers, counting each
+// paper once per author even when it lists several variants of their name.
+// Authors are ranked by paper count, then by most recent paper, then by
+// normalized name, so ties always come out in the same order.
+func CountAuthors(papers []ArxivPaper, merge *names.Rules) []AuthorCount {
+	rules := names.Rules{}
+	if merge != nil {
+		rules = *merge
+	}
+
+	type tally struct {
+		papers   map[string]bool
+		latest   time.Time
+		variants map[string]bool
+	}
+	byVariant := map[string]*tally{}
+	var variants []string
+	for _, paper := range papers {
+		for _, name := range paper.Authors {
+			t := byVariant[name]
+			if t == nil {
+				t = &tally{papers: map[string]bool{}}
+				byVariant[name] = t
+				variants = append(variants, name)
+			}
+			t.papers[BaseID(paper.ID)] = true
+			if published := paper.PublishedTime(); published.After(t.latest) {
+				t.latest = published
+			}
+		}
+	}
+
+	canonical := names.Canonicalize(variants, rules)
+	byName := map[string]*tally{}
+	for _, variant := range variants {
+		name := canonical[variant]
+		t := byName[name]
+		if t == nil {
+			t = &tally{papers: map[string]bool{}, variants: map[string]bool{}}
+			byName[name] = t
+		}
+		for id := range byVariant[variant].papers {
+			t.papers[id] = true
+		}
+		if byVariant[variant].latest.After(t.latest) {
+			t.latest = byVariant[variant].latest
+		}
+		t.variants[variant] = true
+	}
+
+	counts := make([]AuthorCount, 0, len(byName))
+	latest := map[string]time.Time{}
+	for name, t := range byName {
+		count := AuthorCount{Name: name, Papers: len(t.papers), Variants: sortedKeys(t.variants)}
+		if !t.latest.IsZero() {
+			count.Latest = t.latest.Format("2006-01-02")
+		}
+		latest[name] = t.latest
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Papers != b.Papers {
+			return a.Papers > b.Papers
+		}
+		if !latest[a.Name].Equal(latest[b.Name]) {
+			return latest[a.Name].After(latest[b.Name])
+		}
+		if na, nb := names.Normalize(a.Name), names.Normalize(b.Name); na != nb {
+			return na < nb
+		}
+		return a.Name < b.Name
+	})
+	return counts
+}
+
+// Authors fetches the metadata of up to opts.Limit papers matching
+// opts.Query, without downloading anything, and prints their authors as
+// ranked by CountAuthors.
+func Authors(ctx context.Context, opts AuthorsOptions) error {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultAuthorsLimit
+	}
+
+	var papers []ArxivPaper
+	_, err := fetchPages(ctx, Options{Query: opts.Query, Limit: limit}, 0, func(page []ArxivPaper, next int) (bool, error) {
+		papers = append(papers, page...)
+		return len(papers) >= limit, nil
+	})
+	if err != nil {
+		return err
+	}
+	counts := CountAuthors(papers, opts.Merge)
+
+	out := Options{Out: opts.Out}.out()
+	if opts.JSON {
+		data, err := json.MarshalIndent(counts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal authors: %w", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "RANK\tAUTHOR\tPAPERS\tLATEST")
+	for i, count := range counts {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", i+1, count.Name, count.Papers, count.Latest)
+	}
+	return tw.Flush()
+}
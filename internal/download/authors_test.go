@@ -0,0 +1,97 @@
+package download
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+// authorsCorpus lists the same people under several spellings.
+var authorsCorpus = []ArxivPaper{
+	{ID: "http://arxiv.org/abs/2401.00001v1", Published: "2024-01-10T00:00:00Z", Authors: []string{"John Smith", "Ana Lopez"}},
+	{ID: "http://arxiv.org/abs/2401.00002v2", Published: "2024-03-01T00:00:00Z", Authors: []string{"J. Smith", "Bo Chen"}},
+	{ID: "http://arxiv.org/abs/2401.00003v1", Published: "2024-02-01T00:00:00Z", Authors: []string{"Smith, John", "Ana López"}},
+	{ID: "http://arxiv.org/abs/2401.00004v1", Published: "2024-02-15T00:00:00Z", Authors: []string{"Bo Chen", "Carla Diaz"}},
+	// The same paper listing one person twice counts once.
+	{ID: "http://arxiv.org/abs/2401.00005v1", Published: "2024-01-01T00:00:00Z", Authors: []string{"Carla Diaz", "C. Diaz"}},
+}
+
+func TestCountAuthors(t *testing.T) {
+	counts := CountAuthors(authorsCorpus, nil)
+	got := make([]string, len(counts))
+	for i, c := range counts {
+		got[i] = c.Name
+	}
+	// Without merging, "J. Smith" and "C. Diaz" are separate authors, but
+	// spellings that normalize identically are still one.
+	want := []string{"Bo Chen", "Carla Diaz", "Ana López", "Smith, John", "J. Smith", "C. Diaz"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("authors = %q, want %q", got, want)
+	}
+	if c := counts[3]; c.Papers != 2 || c.Latest != "2024-02-01" || !slices.Equal(c.Variants, []string{"John Smith", "Smith, John"}) {
+		t.Errorf("John Smith = %+v, want 2 papers, latest 2024-02-01 and both spellings", c)
+	}
+	if c := counts[4]; c.Papers != 1 || c.Latest != "2024-03-01" {
+		t.Errorf("J. Smith = %+v, want 1 paper, latest 2024-03-01", c)
+	}
+}
+
+func TestCountAuthorsMergesVariants(t *testing.T) {
+	counts := CountAuthors(authorsCorpus, &names.DefaultRules)
+	want := []AuthorCount{
+		{Name: "Smith, John", Papers: 3, Latest: "2024-03-01"},
+		{Name: "Bo Chen", Papers: 2, Latest: "2024-03-01"},
+		{Name: "Carla Diaz", Papers: 2, Latest: "2024-02-15"},
+		{Name: "Ana López", Papers: 2, Latest: "2024-02-01"},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("CountAuthors() = %+v, want %d authors", counts, len(want))
+	}
+	for i, w := range want {
+		got := counts[i]
+		if names.Normalize(got.Name) != names.Normalize(w.Name) || got.Papers != w.Papers || got.Latest != w.Latest {
+			t.Errorf("author %d = %s %d %s, want %s %d %s", i, got.Name, got.Papers, got.Latest, w.Name, w.Papers, w.Latest)
+		}
+	}
+	if !slices.Equal(counts[0].Variants, []string{"J. Smith", "John Smith", "Smith, John"}) {
+		t.Errorf("variants = %q", counts[0].Variants)
+	}
+}
+
+func TestCountAuthorsTiesAreDeterministic(t *testing.T) {
+	papers := []ArxivPaper{{ID: "2401.00001v1", Published: "2024-01-01T00:00:00Z", Authors: []string{"Zoe Young", "Adam Baker", "Mia Ng"}}}
+	for i := 0; i < 10; i++ {
+		counts := CountAuthors(papers, nil)
+		if counts[0].Name != "Adam Baker" || counts[1].Name != "Mia Ng" || counts[2].Name != "Zoe Young" {
+			t.Fatalf("tied authors = %+v, want them by name", counts)
+		}
+	}
+}
+
+func TestAuthorsJSON(t *testing.T) {
+	var query string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("search_query")
+		pagedFeedHandler(3)(w, r)
+	}))
+
+	var out strings.Builder
+	err := Authors(testingContext(t), AuthorsOptions{Query: "cat:cs.CR", Limit: 3, JSON: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Authors() error = %v", err)
+	}
+	if query != "cat:cs.CR" {
+		t.Errorf("search_query = %q", query)
+	}
+	var counts []AuthorCount
+	if err := json.Unmarshal([]byte(out.String()), &counts); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(counts) != 1 || counts[0].Name != "Jane Doe" || counts[0].Papers != 3 || counts[0].Latest != "2024-01-01" {
+		t.Errorf("counts = %+v, want Jane Doe with 3 papers", counts)
+	}
+}
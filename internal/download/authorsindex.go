@@ -0,0 +1,139 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+// AuthorsIndexFile is the authors index kept with Options.MergeAuthors. It
+// accumulates across runs.
+const AuthorsIndexFile = "authors.json"
+
+// AuthorsIndex lists the authors of a library, with the variants of each
+// name merged under one canonical name.
+type AuthorsIndex struct {
+	Authors []IndexedAuthor `json:"authors"`
+}
+
+// IndexedAuthor is one person in an AuthorsIndex. Variants maps each
+// spelling of the name to the base arXiv IDs of the papers listing it;
+// Papers is their union.
+type IndexedAuthor struct {
+	Name     string              `json:"name"`
+	Papers   []string            `json:"papers"`
+	Variants map[string][]string `json:"variants"`
+}
+
+// LoadAuthorsIndex reads the index at path; a missing file yields an empty
+// index.
+func LoadAuthorsIndex(path string) (*AuthorsIndex, error) {
+	index := &AuthorsIndex{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authors index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse authors index: %w", err)
+	}
+	return index, nil
+}
+
+// Save writes the index to path.
+func (idx *AuthorsIndex) Save(path string) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal authors index: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write authors index: %w", err)
+	}
+	return nil
+}
+
+// Add records the authors of papers and regroups every name variant in the
+// index under rules (see names.Canonicalize). Regrouping from scratch means
+// a variant that only became mergeable, or ambiguous, through the new
+// papers moves to its proper entry.
+func (idx *AuthorsIndex) Add(papers []ArxivPaper, rules names.Rules) {
+	variants := map[string]map[string]bool{}
+	record := func(name, id string) {
+		if variants[name] == nil {
+			variants[name] = map[string]bool{}
+		}
+		variants[name][id] = true
+	}
+	for _, author := range idx.Authors {
+		for name, ids := range author.Variants {
+			for _, id := range ids {
+				record(name, id)
+			}
+		}
+	}
+	for _, paper := range papers {
+		for _, name := range paper.Authors {
+			record(name, BaseID(paper.ID))
+		}
+	}
+
+	all := make([]string, 0, len(variants))
+	for name := range variants {
+		all = append(all, name)
+	}
+	canonical := names.Canonicalize(all, rules)
+
+	byName := map[string]*IndexedAuthor{}
+	for _, name := range all {
+		author := byName[canonical[name]]
+		if author == nil {
+			author = &IndexedAuthor{Name: canonical[name], Variants: map[string][]string{}}
+			byName[canonical[name]] = author
+		}
+		author.Variants[name] = sortedKeys(variants[name])
+	}
+
+	idx.Authors = idx.Authors[:0]
+	for _, author := range byName {
+		papers := map[string]bool{}
+		for _, ids := range author.Variants {
+			for _, id := range ids {
+				papers[id] = true
+			}
+		}
+		author.Papers = sortedKeys(papers)
+		idx.Authors = append(idx.Authors, *author)
+	}
+	sort.Slice(idx.Authors, func(i, j int) bool {
+		return names.Normalize(idx.Authors[i].Name) < names.Normalize(idx.Authors[j].Name)
+	})
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateAuthorsIndex adds papers to the index file of opts.
+func updateAuthorsIndex(opts Options, papers []ArxivPaper) error {
+	path := opts.path(AuthorsIndexFile)
+	index, err := LoadAuthorsIndex(path)
+	if err != nil {
+		return err
+	}
+	index.Add(papers, *opts.MergeAuthors)
+	if err := index.Save(path); err != nil {
+		return err
+	}
+	return opts.applyFileMode(path)
+}
@@ -0,0 +1,47 @@
+package download
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+func TestAuthorsIndexMergesAcrossRuns(t *testing.T) {
+	chdirTemp(t)
+	withAuthor := func(id, author string) string {
+		return strings.Replace(fakeEntry(id, "Paper "+id), "Jane Doe", author, 1)
+	}
+	feeds := []string{
+		fakeFeed(2, withAuthor("2401.00001v1", "J. Smith"), withAuthor("2401.00002v1", "Ann Lee")),
+		fakeFeed(1, withAuthor("2401.00003v2", "John Smith")),
+	}
+	run := 0
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(feeds[run]))
+	}))
+
+	rules := names.DefaultRules
+	for run = range feeds {
+		if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, MergeAuthors: &rules}); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	index, err := LoadAuthorsIndex(AuthorsIndexFile)
+	if err != nil {
+		t.Fatalf("LoadAuthorsIndex() error = %v", err)
+	}
+	want := []IndexedAuthor{
+		{Name: "Ann Lee", Papers: []string{"2401.00002"}, Variants: map[string][]string{"Ann Lee": {"2401.00002"}}},
+		{Name: "John Smith", Papers: []string{"2401.00001", "2401.00003"}, Variants: map[string][]string{
+			"J. Smith":   {"2401.00001"},
+			"John Smith": {"2401.00003"},
+		}},
+	}
+	if !reflect.DeepEqual(index.Authors, want) {
+		t.Errorf("authors index = %+v, want %+v", index.Authors, want)
+	}
+}
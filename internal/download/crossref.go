@@ -0,0 +1,83 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// crossrefAPIBase is the CrossRef works endpoint; tests point it at a local
+// server.
+var crossrefAPIBase = "https://api.crossref.org/works"
+
+// PublishedVersion describes the peer-reviewed publication of a preprint.
+type PublishedVersion struct {
+	DOI         string `json:"doi"`
+	JournalName string `json:"journal_name,omitempty"`
+	Year        int    `json:"year,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// crossrefResponse is the subset of a CrossRef works search we use.
+type crossrefResponse struct {
+	Message struct {
+		Items []struct {
+			DOI            string   `json:"DOI"`
+			Type           string   `json:"type"`
+			Title          []string `json:"title"`
+			ContainerTitle []string `json:"container-title"`
+			URL            string   `json:"URL"`
+			Issued         struct {
+				DateParts [][]int `json:"date-parts"`
+			} `json:"issued"`
+		} `json:"items"`
+	} `json:"message"`
+}
+
+// FindPublishedVersion searches CrossRef for a published version of paper by
+// title. A candidate only counts as a match when its title is within
+// DefaultTitleDistance of the paper's; nil is returned when none is. Records
+// of the preprint itself (arXiv DOIs and posted content) are ignored.
+func FindPublishedVersion(ctx context.Context, paper ArxivPaper) (*PublishedVersion, error) {
+	params := url.Values{}
+	params.Set("query.bibliographic", paper.Title)
+	params.Set("rows", "5")
+	params.Set("select", "DOI,type,title,container-title,URL,issued")
+
+	resp, err := httpGet(ctx, crossrefAPIBase+"?"+params.Encode(), http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CrossRef: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CrossRef API returned HTTP %d", resp.StatusCode)
+	}
+
+	var result crossrefResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse CrossRef response: %w", err)
+	}
+
+	want := paper.NormTitle()
+	for _, item := range result.Message.Items {
+		if len(item.Title) == 0 || item.Type == "posted-content" || strings.HasPrefix(strings.ToLower(item.DOI), "10.48550/") {
+			continue
+		}
+		if titleDistance(want, normTitle(item.Title[0])) > DefaultTitleDistance {
+			continue
+		}
+		version := &PublishedVersion{DOI: item.DOI, URL: item.URL}
+		if len(item.ContainerTitle) > 0 {
+			version.JournalName = item.ContainerTitle[0]
+		}
+		if parts := item.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 {
+			version.Year = parts[0][0]
+		}
+		return version, nil
+	}
+	return nil, nil
+}
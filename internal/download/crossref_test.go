@@ -0,0 +1,100 @@
+package download
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const crossrefFixture = `{"message": {"items": [
+  {"DOI": "10.48550/arXiv.1706.03762", "type": "posted-content", "title": ["Attention Is All You Need"]},
+  {"DOI": "10.5555/unrelated", "type": "journal-article", "title": ["Attention Mechanisms in Vision"], "container-title": ["Vision Journal"]},
+  {"DOI": "10.5555/3295222.3295349", "type": "proceedings-article", "title": ["Attention is all you need."],
+   "container-title": ["Advances in Neural Information Processing Systems"], "URL": "https://doi.org/10.5555/3295222.3295349",
+   "issued": {"date-parts": [[2017, 12]]}}
+]}}`
+
+func useFakeCrossRef(t *testing.T, body string) *[]string {
+	t.Helper()
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query.bibliographic"))
+		_, _ = w.Write([]byte(body))
+	}))
+	old := crossrefAPIBase
+	crossrefAPIBase = server.URL
+	t.Cleanup(func() {
+		crossrefAPIBase = old
+		server.Close()
+	})
+	return &queries
+}
+
+func TestFindPublishedVersion(t *testing.T) {
+	queries := useFakeCrossRef(t, crossrefFixture)
+
+	version, err := FindPublishedVersion(testingContext(t), ArxivPaper{Title: "Attention Is All You Need"})
+	if err != nil {
+		t.Fatalf("FindPublishedVersion() error = %v", err)
+	}
+	want := PublishedVersion{
+		DOI:         "10.5555/3295222.3295349",
+		JournalName: "Advances in Neural Information Processing Systems",
+		Year:        2017,
+		URL:         "https://doi.org/10.5555/3295222.3295349",
+	}
+	if version == nil || *version != want {
+		t.Errorf("FindPublishedVersion() = %+v, want %+v", version, want)
+	}
+	if len(*queries) != 1 || (*queries)[0] != "Attention Is All You Need" {
+		t.Errorf("CrossRef queries = %v", *queries)
+	}
+}
+
+func TestFindPublishedVersionNoMatch(t *testing.T) {
+	useFakeCrossRef(t, crossrefFixture)
+
+	version, err := FindPublishedVersion(testingContext(t), ArxivPaper{Title: "Attention Is Not All You Need"})
+	if err != nil {
+		t.Fatalf("FindPublishedVersion() error = %v", err)
+	}
+	if version != nil {
+		t.Errorf("FindPublishedVersion() = %+v, want no match", version)
+	}
+}
+
+func TestRunFindsPublishedVersion(t *testing.T) {
+	chdirTemp(t)
+	queries := useFakeCrossRef(t, crossrefFixture)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		published := strings.Replace(fakeEntry("1706.03762v1", "Attention Is All You Need"),
+			"</entry>", "<arxiv:journal_ref>NeurIPS 2017</arxiv:journal_ref></entry>", 1)
+		_, _ = w.Write([]byte(fakeFeed(2, fakeEntry("1706.03762v7", "Attention Is All You Need"), published)))
+	}))
+
+	err := Run(testingContext(t), Options{Query: "all:attention", Limit: 2, SaveMetadata: true, FindPublishedVersion: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d metadata lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[0], `"published_version":{"doi":"10.5555/3295222.3295349"`) {
+		t.Errorf("first paper lacks its published version: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "published_version") {
+		t.Errorf("paper with a journal reference was looked up: %s", lines[1])
+	}
+	if len(*queries) != 1 {
+		t.Errorf("made %d CrossRef queries, want 1", len(*queries))
+	}
+}
@@ -0,0 +1,206 @@
+package download
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultTitleDistance is the normalized Levenshtein distance under which two
+// titles are treated as the same paper.
+const DefaultTitleDistance = 0.1
+
+// DeduplicateByTitle drops papers whose normalized titles are within
+// maxDistance (normalized Levenshtein distance, 0 to 1) of an earlier paper.
+// Of each group of near-identical titles the paper with the higher version
+// number, or else the more recent Published date, is kept, at the position
+// of the group's first occurrence.
+func DeduplicateByTitle(papers []ArxivPaper, maxDistance float64) []ArxivPaper {
+	return deduplicateByTitle(papers, maxDistance, nil)
+}
+
+// deduplicateByTitle is DeduplicateByTitle calling onDrop, when set, with
+// each dropped paper and the one kept in its place.
+func deduplicateByTitle(papers []ArxivPaper, maxDistance float64, onDrop func(dropped, kept ArxivPaper)) []ArxivPaper {
+	kept := make([]ArxivPaper, 0, len(papers))
+	keys := make([]string, 0, len(papers))
+	// dropped holds the other papers of each kept one's group; which paper
+	// of a group is kept is only known once every paper has been seen.
+	dropped := make([][]ArxivPaper, 0, len(papers))
+
+	for _, paper := range papers {
+		key := paper.NormTitle()
+		duplicate := false
+		for i, keptKey := range keys {
+			if titleDistance(key, keptKey) <= maxDistance {
+				if preferPaper(paper, kept[i]) {
+					kept[i], paper = paper, kept[i]
+				}
+				dropped[i] = append(dropped[i], paper)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, paper)
+			keys = append(keys, key)
+			dropped = append(dropped, nil)
+		}
+	}
+
+	if onDrop != nil {
+		for i, group := range dropped {
+			for _, paper := range group {
+				onDrop(paper, kept[i])
+			}
+		}
+	}
+	return kept
+}
+
+// DeduplicatePapers returns papers without the repeats of an arXiv ID (the
+// full ID, version included), keeping the first occurrence of each in the
+// original order. papers is left unchanged.
+func DeduplicatePapers(papers []ArxivPaper) []ArxivPaper {
+	return deduplicatePapers(papers, nil)
+}
+
+// MergePaperLists concatenates lists, such as the results of separate
+// searches, in order and drops the repeats of an arXiv ID with
+// DeduplicatePapers. The lists are left unchanged.
+func MergePaperLists(lists ...[]ArxivPaper) []ArxivPaper {
+	var union []ArxivPaper
+	for _, list := range lists {
+		union = append(union, list...)
+	}
+	return DeduplicatePapers(union)
+}
+
+// deduplicatePapers is DeduplicatePapers calling onDrop, when set, with each
+// repeat and the first occurrence kept.
+func deduplicatePapers(papers []ArxivPaper, onDrop func(dropped, kept ArxivPaper)) []ArxivPaper {
+	seen := make(map[string]int, len(papers))
+	kept := make([]ArxivPaper, 0, len(papers))
+	for _, paper := range papers {
+		if i, ok := seen[paper.ID]; ok {
+			if onDrop != nil {
+				onDrop(paper, kept[i])
+			}
+			continue
+		}
+		seen[paper.ID] = len(kept)
+		kept = append(kept, paper)
+	}
+	return kept
+}
+
+// titleDeduper applies DeduplicateByTitle across successive pages, dropping
+// papers that match a title already handed on in an earlier page. onDrop,
+// when set, is called with each dropped paper and the one it matched.
+type titleDeduper struct {
+	maxDistance float64
+	onDrop      func(dropped, kept ArxivPaper)
+	seen        []ArxivPaper
+	seenKeys    []string
+}
+
+func (d *titleDeduper) filter(papers []ArxivPaper) []ArxivPaper {
+	var kept []ArxivPaper
+	for _, paper := range deduplicateByTitle(papers, d.maxDistance, d.onDrop) {
+		key := paper.NormTitle()
+		duplicate := false
+		for i, seen := range d.seenKeys {
+			if titleDistance(key, seen) <= d.maxDistance {
+				if d.onDrop != nil {
+					d.onDrop(paper, d.seen[i])
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			d.seen = append(d.seen, paper)
+			d.seenKeys = append(d.seenKeys, key)
+			kept = append(kept, paper)
+		}
+	}
+	return kept
+}
+
+// preferPaper reports whether a should be kept over its near-duplicate b.
+func preferPaper(a, b ArxivPaper) bool {
+	_, versionA := splitArxivID(a.ID)
+	_, versionB := splitArxivID(b.ID)
+	if versionA != versionB {
+		return versionA > versionB
+	}
+	return a.Published > b.Published
+}
+
+// latexCommandPattern matches a LaTeX command name, such as \textbf or
+// \mathcal, or an escaped character such as \&.
+var latexCommandPattern = regexp.MustCompile(`\\([A-Za-z]+\*?|.)`)
+
+// NormTitle returns the title of p in the form used to compare titles
+// across arXiv versions and sources: lowercased, without LaTeX commands,
+// braces or math delimiters, without punctuation other than hyphens, and
+// with whitespace collapsed. "\textbf{Deep} Learning: A $\mathcal{O}(n)$
+// Survey" becomes "deep learning a on survey".
+func (p ArxivPaper) NormTitle() string {
+	return normTitle(p.Title)
+}
+
+// normTitle is NormTitle for any title, e.g. one from CrossRef.
+func normTitle(title string) string {
+	title = latexCommandPattern.ReplaceAllStringFunc(title, func(command string) string {
+		if escaped := command[1:]; !strings.ContainsFunc(escaped, unicode.IsLetter) {
+			return escaped
+		}
+		return " "
+	})
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case r == '-':
+			return r
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, title)
+	return collapseWhitespace(title)
+}
+
+// titleDistance is the Levenshtein distance between a and b divided by the
+// length of the longer string.
+func titleDistance(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return float64(levenshtein(ra, rb)) / float64(longest)
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
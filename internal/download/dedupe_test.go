@@ -0,0 +1,120 @@
+package download
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"same", "same", 0},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDeduplicateByTitle(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00001v1", Title: "Graph Retrieval Augmented Generation", Published: "2024-01-01T00:00:00Z"},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "An Unrelated Paper", Published: "2024-01-02T00:00:00Z"},
+		{ID: "http://arxiv.org/abs/2401.00003v2", Title: "Graph Retrieval-Augmented Generation", Published: "2023-12-01T00:00:00Z"},
+		{ID: "http://arxiv.org/abs/2401.00004v1", Title: "an unrelated  paper", Published: "2024-02-01T00:00:00Z"},
+	}
+
+	got := DeduplicateByTitle(papers, DefaultTitleDistance)
+	if len(got) != 2 {
+		t.Fatalf("DeduplicateByTitle() kept %d papers, want 2", len(got))
+	}
+	if got[0].ID != "http://arxiv.org/abs/2401.00003v2" {
+		t.Errorf("got[0].ID = %q, want the higher version to win", got[0].ID)
+	}
+	if got[1].ID != "http://arxiv.org/abs/2401.00004v1" {
+		t.Errorf("got[1].ID = %q, want the more recent paper to win", got[1].ID)
+	}
+}
+
+func TestDeduplicatePapers(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "First"},
+		{ID: "http://arxiv.org/abs/2401.00001v1", Title: "Second"},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "Repeat"},
+		{ID: "http://arxiv.org/abs/2401.00001v2", Title: "New version"},
+	}
+
+	got := DeduplicatePapers(papers)
+	var titles []string
+	for _, paper := range got {
+		titles = append(titles, paper.Title)
+	}
+	if want := []string{"First", "Second", "New version"}; !slices.Equal(titles, want) {
+		t.Errorf("DeduplicatePapers() kept %q, want %q", titles, want)
+	}
+	if papers[2].Title != "Repeat" {
+		t.Error("DeduplicatePapers() modified its argument")
+	}
+	if got := DeduplicatePapers(nil); len(got) != 0 {
+		t.Errorf("DeduplicatePapers(nil) = %v, want none", got)
+	}
+}
+
+func TestMergePaperLists(t *testing.T) {
+	ml := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00001v1", Title: "Learning"},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "Both"},
+	}
+	gnn := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "Both again"},
+		{ID: "http://arxiv.org/abs/2401.00003v1", Title: "Graphs"},
+	}
+
+	var titles []string
+	for _, paper := range MergePaperLists(ml, nil, gnn) {
+		titles = append(titles, paper.Title)
+	}
+	if want := []string{"Learning", "Both", "Graphs"}; !slices.Equal(titles, want) {
+		t.Errorf("MergePaperLists() kept %q, want %q", titles, want)
+	}
+	if got := MergePaperLists(); len(got) != 0 {
+		t.Errorf("MergePaperLists() = %v, want none", got)
+	}
+}
+
+func TestTitleDeduperAcrossPages(t *testing.T) {
+	d := &titleDeduper{maxDistance: DefaultTitleDistance}
+
+	// An earlier page may already be saved, so its paper is kept even over
+	// a later version.
+	first := d.filter([]ArxivPaper{{ID: "a", Title: "Attention Is All You Need"}})
+	second := d.filter([]ArxivPaper{{ID: "bv2", Title: "Attention is all you need."}, {ID: "c", Title: "Something Else"}})
+
+	if len(first) != 1 || len(second) != 1 || second[0].ID != "c" {
+		t.Errorf("titleDeduper kept %v then %v, want [a] then [c]", first, second)
+	}
+}
+
+func TestNormTitle(t *testing.T) {
+	tests := map[string]string{
+		"Attention Is All You Need":                                "attention is all you need",
+		"  Attention\n  is all   you NEED.  ":                      "attention is all you need",
+		"Self-Supervised Learning: A Survey":                       "self-supervised learning a survey",
+		`\textbf{Deep} Learning: A $\mathcal{O}(n)$ Survey`:        "deep learning a on survey",
+		`Graphs \& Networks, {R}evisited!`:                         "graphs networks revisited",
+		`Don't Stop Pretraining: Adapt Language Models to Domains`: "dont stop pretraining adapt language models to domains",
+	}
+	for title, want := range tests {
+		if got := (ArxivPaper{Title: title}).NormTitle(); got != want {
+			t.Errorf("NormTitle(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
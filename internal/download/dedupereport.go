@@ -0,0 +1,60 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// Reasons a paper is dropped as a duplicate, as recorded in
+// DroppedDuplicate.Reason.
+const (
+	DuplicateID    = "duplicate_id" // same arXiv ID as a paper already kept
+	DuplicateTitle = "near_title"   // title within Options.TitleDistance
+)
+
+// DroppedDuplicate is a line of the Options.DedupeReport file: a paper
+// dropped as a duplicate, why, and the paper kept in its place.
+type DroppedDuplicate struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Reason       string `json:"reason"`
+	MatchedID    string `json:"matched_id"`
+	MatchedTitle string `json:"matched_title"`
+}
+
+// duplicate records that dropped was dropped as a duplicate of kept, when
+// the run keeps a report of duplicates.
+func (s *runStats) duplicate(dropped, kept ArxivPaper, reason string) {
+	if !s.reportDuplicates {
+		return
+	}
+	s.duplicates = append(s.duplicates, DroppedDuplicate{
+		ID:           dropped.ID,
+		Title:        dropped.Title,
+		Reason:       reason,
+		MatchedID:    kept.ID,
+		MatchedTitle: kept.Title,
+	})
+}
+
+// writeDedupeReport writes the dropped duplicates to opts.DedupeReport, one
+// JSON object per line; with none dropped the file is empty.
+func writeDedupeReport(opts Options, duplicates []DroppedDuplicate) error {
+	path := opts.path(opts.DedupeReport)
+	if err := opts.mkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create dedupe report directory: %w", err)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, duplicate := range duplicates {
+		if err := encoder.Encode(duplicate); err != nil {
+			return fmt.Errorf("failed to marshal dedupe report: %w", err)
+		}
+	}
+	if err := opts.writeFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write dedupe report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunDedupeReport(t *testing.T) {
+	results := map[string][]string{
+		"cat:cs.CL": {fakeEntry("2401.00001v1", "Graph Retrieval Augmented Generation"), fakeEntry("2401.00002v1", "Other")},
+		"cat:cs.LG": {
+			fakeEntry("2401.00002v2", "Other"),
+			fakeEntry("2401.00003v1", "Graph Retrieval-Augmented Generation"),
+			fakeEntry("2401.00004v1", "Repeated"),
+			fakeEntry("2401.00004v1", "Repeated"),
+		},
+	}
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := results[r.URL.Query().Get("search_query")]
+		_, _ = fmt.Fprint(w, fakeFeed(len(entries), entries...))
+	}))
+	chdirTemp(t)
+
+	report := func(opts Options) []DroppedDuplicate {
+		t.Helper()
+		opts.Queries = []string{"cat:cs.CL", "cat:cs.LG"}
+		opts.Limit = 10
+		opts.SaveMetadata = true
+		opts.DedupeReport = "reports/dedupe.jsonl"
+		opts.Out = &strings.Builder{}
+		if err := Run(testingContext(t), opts); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		content, err := os.ReadFile(opts.DedupeReport)
+		if err != nil {
+			t.Fatalf("Failed to read dedupe report: %v", err)
+		}
+		var records []DroppedDuplicate
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if line == "" {
+				continue
+			}
+			var record DroppedDuplicate
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("dedupe report line %q: %v", line, err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+	abs := func(id string) string { return "http://arxiv.org/abs/" + id }
+
+	got := report(Options{DeduplicateByTitle: true, TitleDistance: DefaultTitleDistance})
+	want := []DroppedDuplicate{
+		{ID: abs("2401.00002v2"), Title: "Other", Reason: DuplicateID, MatchedID: abs("2401.00002v1"), MatchedTitle: "Other"},
+		{ID: abs("2401.00004v1"), Title: "Repeated", Reason: DuplicateTitle, MatchedID: abs("2401.00004v1"), MatchedTitle: "Repeated"},
+		{ID: abs("2401.00003v1"), Title: "Graph Retrieval-Augmented Generation", Reason: DuplicateTitle,
+			MatchedID: abs("2401.00001v1"), MatchedTitle: "Graph Retrieval Augmented Generation"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupe report = %+v, want %+v", got, want)
+	}
+
+	// Without title deduplication the repeat is dropped by ID.
+	got = report(Options{})
+	want = []DroppedDuplicate{
+		{ID: abs("2401.00002v2"), Title: "Other", Reason: DuplicateID, MatchedID: abs("2401.00002v1"), MatchedTitle: "Other"},
+		{ID: abs("2401.00004v1"), Title: "Repeated", Reason: DuplicateID, MatchedID: abs("2401.00004v1"), MatchedTitle: "Repeated"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupe report without title deduplication = %+v, want %+v", got, want)
+	}
+
+	// A dry run writes nothing, the report included.
+	opts := Options{Queries: []string{"cat:cs.CL", "cat:cs.LG"}, Limit: 10, DryRun: true, DedupeReport: "dry/dedupe.jsonl", Out: &strings.Builder{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("dry Run() error = %v", err)
+	}
+	if _, err := os.Stat(opts.DedupeReport); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s (err = %v)", opts.DedupeReport, err)
+	}
+}
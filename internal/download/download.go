@@ -1,26 +1,42 @@
 package download
 
 import (
+	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+	"github.com/AstraBert/arxiv-cli/internal/text"
 )
 
 const (
-	JSONFile      = "metadata.jsonl"
+	JSONFile      = format.JSONLFile
 	PDFDirectory  = "pdfs/"
 	TextDirectory = "texts/"
 	arxivAPIBase  = "http://export.arxiv.org/api/query"
+	arxivAbsBase  = "http://arxiv.org/abs/"
+	arxivPDFBase  = "http://arxiv.org/pdf/" // as in the API's PDF links
 )
 
+// apiBaseURL is the endpoint queried by fetchArxivPapers; tests point it at a
+// local server.
+var apiBaseURL = arxivAPIBase
+
 type ArxivPaper struct {
 	ID              string   `json:"id"`
 	Updated         string   `json:"updated"`
@@ -32,13 +48,68 @@ type ArxivPaper struct {
 	Categories      []string `json:"categories"`
 	PDFURL          string   `json:"pdf_url"`
 	HTMLURL         string   `json:"html_url"`
+	ArxivID         string   `json:"arxiv_id"` // ID without the abs URL prefix and version, e.g. "2310.06825"
+	Version         int      `json:"version"`  // parsed from ID, 1 when it has none
 	Comment         *string  `json:"comment,omitempty"`
+	JournalRef      string   `json:"journal_ref,omitempty"`
+	DOI             string   `json:"doi,omitempty"`
+	PaperType       string   `json:"paper_type"`
+	ReadingLevel    float64  `json:"reading_level"`
+	WatchedAuthors  []string `json:"watched_authors,omitempty"`
+	Queries         []string `json:"queries,omitempty"`  // the searches that found the paper, see Options.Queries
+	Formulas        []string `json:"formulas,omitempty"` // LaTeX math in the summary, see Options.ExtractFormulas
+
+	Acronyms map[string]string `json:"acronyms,omitempty"` // acronyms defined in the summary, see Options.ExtractAcronyms
+
+	// Files lists the artifacts saved for the paper in the output directory,
+	// by kind (FilePDF, FileSummary and so on), as slash-separated paths
+	// relative to it, exactly as written.
+	Files map[string]string `json:"files,omitempty"`
+
+	PublishedVersion *PublishedVersion `json:"published_version,omitempty"`
+
+	// RequestedVersion is the version asked for with Options.PaperVersion.
+	RequestedVersion int `json:"requested_version,omitempty"`
+
+	// rawEntry is the source of the paper's Atom <entry>, see rawEntries.
+	rawEntry []byte
+
+	// notes are the user's notes on the paper, merged into the Markdown
+	// export with Options.IncludeNotes.
+	notes []Note
+}
+
+// setArxivID fills ArxivID and Version from ID. An ID without a version,
+// old-style like "cs/0112017" or new-style, is taken to be at version 1.
+func (p *ArxivPaper) setArxivID() {
+	p.ArxivID, p.Version = splitArxivID(p.ID)
+	if p.Version == 0 {
+		p.Version = 1
+	}
 }
 
 // Atom XML structures for parsing arXiv API response
 type Feed struct {
-	XMLName xml.Name `xml:"feed"`
-	Entries []Entry  `xml:"entry"`
+	XMLName      xml.Name `xml:"feed"`
+	TotalResults int      `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   *int     `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	ItemsPerPage int      `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage"`
+	Links        []Link   `xml:"link"`
+	Entries      []Entry  `xml:"entry"`
+}
+
+// searchPage is one page of results returned by the arXiv API.
+type searchPage struct {
+	Papers       []ArxivPaper
+	TotalResults int
+
+	// StartIndex is the offset of the first paper as reported by the feed,
+	// and NextStart the offset its rel="next" link points at; both are -1
+	// when the feed does not say. ItemsPerPage is the page size the server
+	// applied, or 0 when unknown.
+	StartIndex   int
+	NextStart    int
+	ItemsPerPage int
 }
 
 type Entry struct {
@@ -52,6 +123,8 @@ type Entry struct {
 	Links      []Link     `xml:"link"`
 	Categories []Category `xml:"category"`
 	Comment    Comment    `xml:"http://arxiv.org/schemas/atom comment"`
+	JournalRef string     `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	DOI        string     `xml:"http://arxiv.org/schemas/atom doi"`
 }
 
 type Comment struct {
@@ -74,6 +147,26 @@ type Category struct {
 	Term string `xml:"term,attr"`
 }
 
+// PublishedTime parses the Published timestamp, returning the zero time when
+// it is missing or malformed.
+func (p ArxivPaper) PublishedTime() time.Time {
+	return parseTimestamp(p.Published)
+}
+
+// UpdatedTime parses the Updated timestamp, returning the zero time when it
+// is missing or malformed.
+func (p ArxivPaper) UpdatedTime() time.Time {
+	return parseTimestamp(p.Updated)
+}
+
+func parseTimestamp(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func sanitizeFilename(name string) string {
 	invalidChars := []rune{'<', '>', ':', '"', '/', '\\', '|', '?', '*'}
 	sanitized := name
@@ -88,17 +181,14 @@ func sanitizeFilename(name string) string {
 	return sanitized
 }
 
-func (p *ArxivPaper) FetchPDF(ctx context.Context, outPath string) error {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+// pdfMagic starts every PDF file.
+var pdfMagic = []byte("%PDF-")
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.PDFURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := client.Do(req)
+// FetchPDF downloads the paper's PDF to outPath, adding the .pdf extension
+// if missing. A response that does not start like a PDF is rejected, and
+// nothing is left at outPath when the download fails.
+func (p *ArxivPaper) FetchPDF(ctx context.Context, outPath string) error {
+	resp, err := httpGet(ctx, p.PDFURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to fetch PDF: %w", err)
 	}
@@ -112,14 +202,26 @@ func (p *ArxivPaper) FetchPDF(ctx context.Context, outPath string) error {
 		outPath += ".pdf"
 	}
 
-	file, err := os.Create(outPath)
+	// arXiv occasionally answers 200 with an HTML error page; check the
+	// magic number before anything is written.
+	body := bufio.NewReader(resp.Body)
+	if magic, _ := body.Peek(len(pdfMagic)); !bytes.Equal(magic, pdfMagic) {
+		return fmt.Errorf("failed to fetch PDF: response is not a PDF (Content-Type %q, starts with %q)",
+			resp.Header.Get("Content-Type"), magic)
+	}
+
+	file, err := createFile(outPath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer func() { _ = file.Close() }()
 
-	_, err = io.Copy(file, resp.Body)
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		// Never leave a truncated PDF behind, e.g. after an interrupt.
+		_ = os.Remove(outPath)
 		return fmt.Errorf("failed to write PDF: %w", err)
 	}
 
@@ -130,33 +232,48 @@ func (p *ArxivPaper) WriteSummary(outPath string) error {
 	if !strings.HasSuffix(outPath, ".txt") {
 		outPath += ".txt"
 	}
+	if err := removeReadOnly(outPath); err != nil {
+		return err
+	}
 	return os.WriteFile(outPath, []byte(p.Summary), 0644)
 }
 
-func fetchArxivPapers(ctx context.Context, searchQuery string, numResults int) ([]ArxivPaper, error) {
-	baseURL, err := url.Parse(arxivAPIBase)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse base URL: %w", err)
-	}
+func fetchArxivPapers(ctx context.Context, searchQuery string, start, numResults int) (*searchPage, error) {
+	return fetchArxivPapersSorted(ctx, searchQuery, start, numResults, "submittedDate")
+}
 
+// fetchArxivPapersSorted is fetchArxivPapers with the results in descending
+// order of sortBy, one of the API's "relevance", "lastUpdatedDate" or
+// "submittedDate".
+func fetchArxivPapersSorted(ctx context.Context, searchQuery string, start, numResults int, sortBy string) (*searchPage, error) {
+	return fetchFeed(ctx, searchParams(searchQuery, start, numResults, sortBy), parseFeed)
+}
+
+// searchParams are the API parameters of a search for searchQuery.
+func searchParams(searchQuery string, start, numResults int, sortBy string) url.Values {
 	params := url.Values{}
 	params.Set("search_query", searchQuery)
-	params.Set("start", "0")
+	params.Set("start", fmt.Sprintf("%d", start))
 	params.Set("max_results", fmt.Sprintf("%d", numResults))
-	params.Set("sortBy", "submittedDate")
+	params.Set("sortBy", sortBy)
 	params.Set("sortOrder", "descending")
-	baseURL.RawQuery = params.Encode()
+	return params
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// fetchFeed requests a page of results from the API and decodes it with
+// parse.
+func fetchFeed(ctx context.Context, params url.Values, parse func(io.Reader) (*searchPage, error)) (*searchPage, error) {
+	baseURL, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
 	}
+	baseURL.RawQuery = params.Encode()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if err := apiLimiter.wait(ctx); err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := httpGet(ctx, baseURL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from arXiv API: %w", err)
 	}
@@ -166,105 +283,735 @@ func fetchArxivPapers(ctx context.Context, searchQuery string, numResults int) (
 		return nil, fmt.Errorf("arXiv API returned HTTP %d", resp.StatusCode)
 	}
 
+	page, err := parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	logger := loggerFrom(ctx)
+	for _, paper := range page.Papers {
+		logger.Debug("fetched paper", "id", paper.ID, "title", paper.Title)
+	}
+	return page, nil
+}
+
+// parseFeed decodes an arXiv Atom feed and maps its entries to papers.
+func parseFeed(r io.Reader) (*searchPage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
 	var feed Feed
-	decoder := xml.NewDecoder(resp.Body)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
 	if err := decoder.Decode(&feed); err != nil {
 		return nil, fmt.Errorf("failed to parse XML response: %w", err)
 	}
+	raw, err := rawEntries(data)
+	if err != nil || len(raw) != len(feed.Entries) {
+		// Only --store-raw-entry needs these; the papers are fine without.
+		raw = nil
+	}
 
 	papers := make([]ArxivPaper, 0, len(feed.Entries))
-	for _, entry := range feed.Entries {
+	for i, entry := range feed.Entries {
 		paper := ArxivPaper{
-			ID:              entry.ID,
-			Updated:         entry.Updated,
-			Published:       entry.Published,
-			Title:           strings.TrimSpace(entry.Title),
-			Summary:         strings.TrimSpace(entry.Summary),
+			ID:              cleanField(entry.ID),
+			Updated:         cleanField(entry.Updated),
+			Published:       cleanField(entry.Published),
+			Title:           collapseWhitespace(entry.Title),
+			Summary:         cleanField(entry.Summary),
 			Authors:         make([]string, 0, len(entry.Authors)),
 			PrimaryCategory: "",
 			Categories:      make([]string, 0, len(entry.Categories)),
 			Comment:         nil,
 		}
 
+		paper.setArxivID()
+
 		for _, author := range entry.Authors {
-			paper.Authors = append(paper.Authors, author.Name)
+			paper.Authors = append(paper.Authors, collapseWhitespace(author.Name))
 		}
 
 		for _, category := range entry.Categories {
-			paper.Categories = append(paper.Categories, category.Term)
+			term := cleanField(category.Term)
+			paper.Categories = append(paper.Categories, term)
 			if paper.PrimaryCategory == "" {
-				paper.PrimaryCategory = category.Term
+				paper.PrimaryCategory = term
 			}
 		}
 
 		for _, link := range entry.Links {
+			href := strings.ReplaceAll(cleanField(link.HRef), "httpss", "https")
 			if link.Rel == "alternate" && link.Type == "text/html" {
-				paper.HTMLURL = strings.ReplaceAll(link.HRef, "httpss", "https")
+				paper.HTMLURL = href
 			} else if link.Title == "pdf" {
-				paper.PDFURL = strings.ReplaceAll(link.HRef, "httpss", "https")
+				paper.PDFURL = href
 			} else if link.Type == "application/pdf" {
-				paper.PDFURL = strings.ReplaceAll(link.HRef, "httpss", "https")
+				paper.PDFURL = href
 			}
 		}
 
-		if entry.Comment.Value != "" {
-			comment := entry.Comment.Value
+		if comment := collapseWhitespace(entry.Comment.Value); comment != "" {
 			paper.Comment = &comment
 		}
+		paper.JournalRef = collapseWhitespace(entry.JournalRef)
+		paper.DOI = cleanField(entry.DOI)
+		paper.PaperType = InferPaperType(paper)
+		if raw != nil {
+			paper.rawEntry = raw[i]
+		}
+		paper.ReadingLevel = math.Round(text.FleschKincaidGrade(paper.Summary)*100) / 100
 
 		papers = append(papers, paper)
 	}
 
-	return papers, nil
+	return newSearchPage(papers, feed.TotalResults, feed.StartIndex, feed.ItemsPerPage, feed.Links), nil
+}
+
+// newSearchPage wraps the papers of a feed with the paging information of
+// its opensearch elements and links.
+func newSearchPage(papers []ArxivPaper, totalResults int, startIndex *int, itemsPerPage int, links []Link) *searchPage {
+	page := &searchPage{
+		Papers:       papers,
+		TotalResults: totalResults,
+		StartIndex:   -1,
+		NextStart:    -1,
+		ItemsPerPage: itemsPerPage,
+	}
+	for _, link := range links {
+		switch link.Rel {
+		case "self":
+			if page.StartIndex < 0 {
+				page.StartIndex = linkStart(link.HRef)
+			}
+		case "next":
+			page.NextStart = linkStart(link.HRef)
+		}
+	}
+	// The opensearch element is authoritative over the start parameter
+	// echoed in the self link.
+	if startIndex != nil {
+		page.StartIndex = *startIndex
+	}
+	return page
 }
 
-func DownloadArxivPapers(ctx context.Context, searchQuery string, numResults int, saveMetadata, savePDFs, saveSummaries bool) error {
-	papers, err := fetchArxivPapers(ctx, searchQuery, numResults)
+// linkStart returns the start query parameter of a feed link, or -1 when it
+// has none.
+func linkStart(href string) int {
+	u, err := url.Parse(href)
 	if err != nil {
-		return fmt.Errorf("failed to fetch papers: %w", err)
+		return -1
+	}
+	value := u.Query().Get("start")
+	if value == "" {
+		return -1
+	}
+	start, err := strconv.Atoi(value)
+	if err != nil || start < 0 {
+		return -1
 	}
+	return start
+}
 
-	var jsonlLines []string
+// normalizeNewlines folds \r\n sequences and lone \r characters into \n so
+// that no raw carriage return survives into the JSONL output.
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
 
-	for _, paper := range papers {
-		if saveMetadata {
-			paperCopy := paper
-			metadataJSON, err := json.Marshal(paperCopy)
-			if err != nil {
-				return fmt.Errorf("failed to marshal metadata: %w", err)
+// cleanField normalizes newlines and trims surrounding whitespace.
+func cleanField(s string) string {
+	return strings.TrimSpace(normalizeNewlines(s))
+}
+
+// collapseWhitespace folds every run of whitespace (including newlines) into
+// a single space, for fields that are meant to be single-line.
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(normalizeNewlines(s)), " ")
+}
+
+// DownloadArxivPapers saves the numResults most recent papers matching
+// searchQuery. The JSONL metadata goes to metadataOut, with progress
+// messages moved to stderr, or to JSONFile when metadataOut is nil. The run
+// logs through slog.Default.
+func DownloadArxivPapers(ctx context.Context, searchQuery string, numResults int, saveMetadata, savePDFs, saveSummaries bool, metadataOut io.Writer) error {
+	opts := Options{
+		Query:         searchQuery,
+		Limit:         numResults,
+		SaveMetadata:  saveMetadata,
+		SavePDFs:      savePDFs,
+		SaveSummaries: saveSummaries,
+		MetadataOut:   metadataOut,
+		Logger:        slog.Default(),
+	}
+	if metadataOut != nil {
+		opts.Out = os.Stderr
+	}
+	return Run(ctx, opts)
+}
+
+// Run fetches the papers matching opts.Query and saves the requested outputs.
+// Papers are written out page by page, so an interrupted run keeps everything
+// saved up to that point. With opts.Queries, every search is run before
+// anything is saved, so that the results can be merged; a failed search is
+// reported once the others' results are saved.
+func Run(ctx context.Context, opts Options) error {
+	if opts.Logger != nil {
+		ctx = withLogger(ctx, opts.Logger)
+	}
+	if len(opts.Queries) > 0 && opts.ResumePagination {
+		return fmt.Errorf("resuming pagination is not supported with multiple queries")
+	}
+	if opts.OAI != nil && (len(opts.Queries) > 0 || len(opts.IDs) > 0 || opts.All || opts.ResumePagination) {
+		return fmt.Errorf("an OAI-PMH harvest cannot be combined with searches, ID lists or pagination")
+	}
+	if opts.PerQueryLimit < 0 || opts.LimitTotal < 0 {
+		return fmt.Errorf("the per-query and total limits must not be negative")
+	}
+	if opts.PerQueryLimit > 0 {
+		opts.Limit = opts.PerQueryLimit
+	}
+	if opts.LimitTotal > 0 && len(opts.Queries) == 0 {
+		opts.Limit = min(opts.Limit, opts.LimitTotal)
+	}
+	if opts.PrintURLs && opts.PrintIDs {
+		return fmt.Errorf("printing PDF URLs and printing IDs cannot be combined")
+	}
+	if opts.IDQuery != "" && len(opts.IDs) == 0 {
+		return fmt.Errorf("an ID query only filters an ID list")
+	}
+	if len(opts.ExcludeFrom) > 0 {
+		var err error
+		if opts.reference, err = LoadReferenceCorpus(opts.ExcludeFrom...); err != nil {
+			return err
+		}
+	}
+	if opts.RawQuery {
+		if err := opts.validateRawQuery(); err != nil {
+			return err
+		}
+	}
+	if !opts.Month.IsZero() {
+		if err := opts.validateMonth(); err != nil {
+			return err
+		}
+		opts.All = true
+	}
+	if len(opts.IDs) > 0 && (opts.All || opts.ResumePagination) {
+		return fmt.Errorf("fetching by ID does not page through search results")
+	}
+	if opts.PaperVersion > 0 {
+		if len(opts.IDs) == 0 {
+			return fmt.Errorf("a paper version can only be selected when fetching by ID")
+		}
+		var err error
+		if opts.IDs, err = pinVersion(opts.IDs, opts.PaperVersion); err != nil {
+			return err
+		}
+	}
+	if opts.MetadataOut != nil && len(opts.formats()) > 1 {
+		return fmt.Errorf("metadata can only be streamed in one format")
+	}
+	if opts.Append {
+		if err := opts.validateAppend(); err != nil {
+			return err
+		}
+	}
+	if opts.CompareToPrevious && (len(opts.IDs) > 0 || opts.OAI != nil) {
+		return fmt.Errorf("comparing to the previous run needs a search, not an ID list or an OAI-PMH harvest")
+	}
+	if opts.IncludeNotes && !(opts.SaveMetadata && slices.Contains(opts.formats(), format.Markdown)) {
+		return fmt.Errorf("notes are only included in the Markdown metadata")
+	}
+	if opts.PDFToText && !opts.SavePDFs {
+		return fmt.Errorf("the full text is only extracted from saved PDFs")
+	}
+	if opts.SaveEPUB && !opts.SaveSummaries {
+		return fmt.Errorf("EPUB files are only made together with the summaries")
+	}
+	if opts.TimestampLayout != "" && opts.ResumePagination {
+		return fmt.Errorf("resuming pagination is not supported with a timestamped output directory")
+	}
+	if opts.writesFiles() {
+		if opts.TimestampLayout != "" {
+			opts.OutputDir = filepath.Join(opts.OutputDir, time.Now().UTC().Format(opts.TimestampLayout))
+		}
+		if opts.OutputDir != "" {
+			if err := opts.mkdirAll(opts.OutputDir); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
 			}
-			jsonlLines = append(jsonlLines, string(metadataJSON))
 		}
+		if opts.SaveMetadata && opts.MetadataFile != "" && opts.MetadataOut == nil {
+			if err := opts.mkdirAll(filepath.Dir(opts.path(opts.MetadataFile))); err != nil {
+				return fmt.Errorf("failed to create metadata directory: %w", err)
+			}
+		}
+		if opts.TimestampLayout != "" {
+			opts.printf("saving to %s\n", opts.OutputDir)
+		}
+	}
+	if opts.AbstractOnly {
+		if conflicts := opts.abstractOnlyConflicts(); len(conflicts) > 0 {
+			return fmt.Errorf("abstract-only mode does not parse the fields needed by %s", strings.Join(conflicts, ", "))
+		}
+	}
 
-		if savePDFs {
-			if err := os.MkdirAll(PDFDirectory, 0755); err != nil {
-				return fmt.Errorf("failed to create PDF directory: %w", err)
+	start := 0
+	if opts.All && opts.ResumePagination {
+		var err error
+		if start, err = loadPaginationState(opts.path(PaginationStateFile), opts.searchQuery()); err != nil {
+			return err
+		}
+	}
+
+	metadata := newMetadataExport(opts, start > 0 || opts.Append)
+	if opts.Append && opts.SaveMetadata {
+		var err error
+		if metadata.existing, err = ReadMetadataIDs(opts.path(opts.metadataName(format.JSONL))); err != nil {
+			return err
+		}
+	}
+	if opts.SaveMetadata && opts.writesFiles() && slices.Contains(opts.formats(), format.JSONL) {
+		var err error
+		if metadata.recorded, err = readRecordedFiles(opts.path(opts.metadataName(format.JSONL))); err != nil {
+			return err
+		}
+	}
+
+	stats := &runStats{reportDuplicates: opts.DedupeReport != ""}
+	var dedupe *titleDeduper
+	if opts.DeduplicateByTitle {
+		dedupe = &titleDeduper{maxDistance: opts.TitleDistance, onDrop: func(dropped, kept ArxivPaper) {
+			stats.duplicate(dropped, kept, DuplicateTitle)
+		}}
+	}
+
+	if opts.writesFiles() && len(opts.Queries) == 0 {
+		total := opts.Limit
+		if opts.All || opts.OAI != nil {
+			total = 0
+		} else if len(opts.IDs) > 0 {
+			total = len(opts.IDs)
+		}
+		opts.bar = newProgress(opts.Progress, total)
+	}
+
+	var table *paperTable
+	if opts.Table {
+		table = newPaperTable(opts)
+	}
+
+	var previous *previousRuns
+	var seenBefore map[string]bool
+	var current []seenPaper
+	unchanged := 0
+	if opts.CompareToPrevious {
+		var err error
+		if previous, err = loadPreviousRuns(opts.path(PreviousRunFile)); err != nil {
+			return err
+		}
+		seenBefore = previous.seen(opts.previousRunKey())
+	}
+
+	var notes *NoteStore
+	if opts.IncludeNotes {
+		var err error
+		if notes, err = LoadNotes(opts.path(NotesFile)); err != nil {
+			return err
+		}
+	}
+
+	var saved, emitted []ArxivPaper
+	var ids, abstracts []string
+	var written []artifact
+	opts.output = &sync.Mutex{}
+	pool := newSavePool(ctx, &opts, metadata, func(paper ArxivPaper, artifacts []artifact) {
+		opts.advance(paper.Title)
+		if opts.MergeAuthors != nil || opts.MarkdownIndex {
+			saved = append(saved, paper)
+		}
+		if opts.PrintTree {
+			for _, a := range artifacts {
+				if a.written {
+					written = append(written, a)
+				}
+			}
+		}
+	})
+	emit := func(paper ArxivPaper) error {
+		if !opts.RawDOIs {
+			paper.DOI = normalizeDOI(paper.DOI)
+		}
+		if previous != nil {
+			current = append(current, seenPaper{ID: BaseID(paper.ID), Title: paper.Title})
+			if seenBefore[BaseID(paper.ID)] {
+				unchanged++
+				return nil
+			}
+		}
+		if notes != nil {
+			paper.notes = notes.Notes(paper.ID)
+		}
+		if opts.ExtractFormulas {
+			paper.Formulas = ExtractFormulas(paper.Summary)
+		}
+		if opts.ExtractAcronyms {
+			paper.Acronyms = ExtractAcronyms(paper.Summary)
+		}
+		if opts.DetectDuplicateSubmissions || opts.TopJournals {
+			emitted = append(emitted, paper)
+		}
+		if opts.WordCloudData {
+			abstracts = append(abstracts, paper.Summary)
+		}
+		if opts.PrintURLs {
+			printURL(opts, paper)
+		} else if opts.PrintIDs {
+			opts.printf("%s\n", paper.ArxivID)
+		} else if opts.DryRun {
+			printDryRun(opts, paper)
+		} else if table != nil {
+			table.row(paper)
+		} else if opts.Cite != nil {
+			citation, err := opts.Cite(paper)
+			if err != nil {
+				return err
 			}
-			sanitizedTitle := sanitizeFilename(paper.Title)
-			path := filepath.Join(PDFDirectory, sanitizedTitle)
-			if err := paper.FetchPDF(ctx, path); err != nil {
-				return fmt.Errorf("failed to fetch PDF for %s: %w", paper.Title, err)
+			opts.printf("%s\n", citation)
+		} else {
+			if err := pool.submit(paper); err != nil {
+				return err
 			}
 		}
+		ids = append(ids, BaseID(paper.ID))
+		return nil
+	}
 
-		if saveSummaries {
-			if err := os.MkdirAll(TextDirectory, 0755); err != nil {
-				return fmt.Errorf("failed to create text directory: %w", err)
+	var err error
+	if len(opts.Queries) > 0 {
+		// Failed searches do not keep the others' results from being
+		// saved, but an interrupted run stops where it is.
+		var papers []ArxivPaper
+		papers, err = collectQueries(ctx, opts, stats, dedupe)
+		if opts.writesFiles() {
+			opts.bar = newProgress(opts.Progress, len(papers))
+		}
+		for _, paper := range papers {
+			if ctx.Err() != nil {
+				break
 			}
-			sanitizedTitle := sanitizeFilename(paper.Title)
-			path := filepath.Join(TextDirectory, sanitizedTitle+".txt")
-			if err := paper.WriteSummary(path); err != nil {
-				return fmt.Errorf("failed to write summary for %s: %w", paper.Title, err)
+			if emitErr := emit(paper); emitErr != nil {
+				err = errors.Join(emitErr, err)
+				break
 			}
 		}
+	} else if opts.OAI != nil {
+		err = harvestOAI(ctx, opts, func(papers []ArxivPaper) error {
+			for _, paper := range opts.filterPage(papers, stats, dedupe) {
+				if err := emit(paper); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else if len(opts.IDs) > 0 {
+		err = fetchIDs(ctx, opts, stats, func(papers []ArxivPaper) error {
+			for _, paper := range opts.filterPage(papers, stats, dedupe) {
+				if err := emit(paper); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else {
+		kept := 0
+		stats.totalResults, err = fetchPages(ctx, opts, start, func(papers []ArxivPaper, next int) (bool, error) {
+			for _, paper := range opts.filterPage(papers, stats, dedupe) {
+				if !opts.All && kept >= opts.Limit {
+					break
+				}
+				if err := emit(paper); err != nil {
+					return false, err
+				}
+				kept++
+			}
+			if opts.All && opts.writesFiles() {
+				// Only record the page once its metadata is on disk.
+				if err := pool.wait(); err != nil {
+					return false, err
+				}
+				if err := metadata.Sync(); err != nil {
+					return false, err
+				}
+				return false, savePaginationState(opts, next)
+			}
+			return kept >= opts.Limit, nil
+		})
 	}
 
-	if len(jsonlLines) > 0 {
-		content := strings.Join(jsonlLines, "\n") + "\n"
-		if err := os.WriteFile(JSONFile, []byte(content), 0644); err != nil {
-			return fmt.Errorf("failed to write metadata file: %w", err)
+	if poolErr := pool.wait(); poolErr != nil {
+		err = errors.Join(err, poolErr)
+	}
+	if table != nil {
+		if flushErr := table.flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to print table: %w", flushErr)
 		}
 	}
+	opts.bar.finish()
+	stats.report(opts)
+	stats.reportTotal(ctx, opts, len(ids))
+	if opts.DetectDuplicateSubmissions && err == nil {
+		reportDuplicateSubmissions(opts, emitted)
+	}
+	if opts.TopJournals && err == nil {
+		reportJournals(opts, emitted)
+	}
 
+	if metadata.skipped > 0 {
+		opts.printf("skipped %d paper(s) already in %s\n", metadata.skipped, opts.metadataName(format.JSONL))
+	}
+	if exportErr := metadata.Close(); exportErr != nil {
+		// Each format succeeds or fails on its own; any failure fails the run.
+		metadata.report(opts)
+		err = errors.Join(err, exportErr)
+	}
+	for _, path := range metadata.files() {
+		logFileWritten(ctx, "metadata", path)
+	}
+	if opts.MergeAuthors != nil && len(saved) > 0 {
+		// Index what was saved even when the run was interrupted.
+		if indexErr := updateAuthorsIndex(opts, saved); indexErr != nil && err == nil {
+			err = indexErr
+		} else if indexErr == nil {
+			logFileWritten(ctx, "authors index", opts.path(AuthorsIndexFile))
+		}
+	}
+	if opts.MarkdownIndex && len(saved) > 0 {
+		if indexErr := writeMarkdownIndex(opts, saved); indexErr != nil && err == nil {
+			err = indexErr
+		} else if indexErr == nil {
+			logFileWritten(ctx, "Markdown index", opts.path(IndexFile))
+		}
+	}
+	if opts.DedupeReport != "" && opts.writesFiles() {
+		if reportErr := writeDedupeReport(opts, stats.duplicates); reportErr != nil && err == nil {
+			err = reportErr
+		} else if reportErr == nil {
+			logFileWritten(ctx, "dedupe report", opts.path(opts.DedupeReport))
+		}
+	}
+	if opts.WordCloudData && opts.writesFiles() && len(abstracts) > 0 {
+		if cloudErr := writeWordCloud(opts, abstracts); cloudErr != nil && err == nil {
+			err = cloudErr
+		} else if cloudErr == nil {
+			logFileWritten(ctx, "word cloud data", opts.path(WordCloudJSONFile))
+			logFileWritten(ctx, "word cloud data", opts.path(WordCloudTSVFile))
+		}
+	}
+	if err == nil && opts.All && opts.writesFiles() && len(opts.Queries) == 0 {
+		err = clearPaginationState(opts.path(PaginationStateFile))
+	}
+	if previous != nil && err == nil && opts.writesFiles() {
+		// A failed or interrupted run keeps the last complete one to
+		// compare against, and a run that saves nothing marks nothing as
+		// seen.
+		opts.printf("%d new since the previous run, %d seen before\n", len(current)-unchanged, unchanged)
+		if opts.OutputDir != "" {
+			if mkdirErr := opts.mkdirAll(opts.OutputDir); mkdirErr != nil {
+				err = fmt.Errorf("failed to create output directory: %w", mkdirErr)
+			}
+		}
+		if err == nil {
+			err = previous.save(opts, opts.path(PreviousRunFile), opts.previousRunKey(), current)
+		}
+	}
+	if opts.PrintTree && opts.writesFiles() && err == nil {
+		err = printTree(opts, metadata.files(), written)
+	}
+	if opts.Webhook != "" {
+		notifyWebhook(ctx, opts, ids, stats, err)
+	}
+	return err
+}
+
+// prepareSave looks up the published version of paper when asked, then
+// writes its PDF, summary and other artifacts (see saveArtifacts). It may
+// run alongside other papers' (see savePool).
+func prepareSave(ctx context.Context, paper ArxivPaper, opts Options) (ArxivPaper, []artifact, error) {
+	if opts.FindPublishedVersion && paper.JournalRef == "" {
+		// A failed lookup only loses the enrichment, not the paper.
+		version, err := FindPublishedVersion(ctx, paper)
+		if err != nil {
+			opts.printf("could not look up a published version of %s: %v\n", paper.Title, err)
+		}
+		paper.PublishedVersion = version
+	}
+
+	artifacts, err := saveArtifacts(ctx, paper, opts)
+	return paper, artifacts, err
+}
+
+// recordSave writes the metadata line of paper listing the artifacts
+// prepareSave saved, where err is its error. The line is written even when
+// an artifact failed, so that the paper is not lost; the artifacts are
+// finalized once it is recorded.
+func recordSave(ctx context.Context, paper ArxivPaper, artifacts []artifact, err error, opts Options, metadata *metadataExport) error {
+	opts.recordFiles(&paper, artifacts, metadata.recorded[paper.ID])
+	if opts.SaveMetadata {
+		if metadataErr := metadata.Write(paper); metadataErr != nil {
+			return errors.Join(metadataErr, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, a := range artifacts {
+		if !a.written {
+			continue
+		}
+		if err := opts.finalize(a.path); err != nil {
+			return err
+		}
+		logFileWritten(ctx, a.kind, a.path)
+	}
 	return nil
 }
+
+// saveArtifacts writes the files requested for paper and returns them,
+// together with those SkipExisting found on disk. On error, the artifacts
+// saved so far are returned.
+func saveArtifacts(ctx context.Context, paper ArxivPaper, opts Options) ([]artifact, error) {
+	var artifacts []artifact
+
+	if opts.StoreRawEntry {
+		if err := opts.mkdirAll(opts.path(RawDirectory)); err != nil {
+			return artifacts, fmt.Errorf("failed to create raw entry directory: %w", err)
+		}
+		path := opts.rawEntryPath(paper)
+		if err := writeRawEntry(paper, path); err != nil {
+			return artifacts, fmt.Errorf("failed to write raw entry for %s: %w", paper.Title, err)
+		}
+		artifacts = append(artifacts, artifact{kind: FileRawEntry, path: path, written: true})
+	}
+
+	if opts.DublinCore {
+		if err := opts.mkdirAll(opts.path(DublinCoreDirectory)); err != nil {
+			return artifacts, fmt.Errorf("failed to create Dublin Core directory: %w", err)
+		}
+		path := opts.dublinCorePath(paper)
+		record, err := paper.ToDublinCore()
+		if err == nil {
+			err = opts.writeFile(path, []byte(record))
+		}
+		if err != nil {
+			return artifacts, fmt.Errorf("failed to write Dublin Core record for %s: %w", paper.Title, err)
+		}
+		artifacts = append(artifacts, artifact{kind: FileDublinCore, path: path, written: true})
+	}
+
+	if opts.SavePDFs {
+		if err := opts.mkdirAll(opts.pdfDir()); err != nil {
+			return artifacts, fmt.Errorf("failed to create PDF directory: %w", err)
+		}
+		path := filepath.Join(opts.pdfDir(), FormatFilename(opts.FilenameTemplate, paper)+".pdf")
+		if opts.SkipExisting && fileExists(path) {
+			opts.printf("skipping %s (already exists)\n", path)
+			artifacts = append(artifacts, artifact{kind: FilePDF, path: path})
+		} else if err := paper.FetchPDF(ctx, path); err != nil {
+			return artifacts, fmt.Errorf("failed to fetch PDF for %s: %w", paper.Title, err)
+		} else {
+			artifacts = append(artifacts, artifact{kind: FilePDF, path: path, written: true})
+		}
+
+		if opts.PDFToText {
+			textPath := opts.fullTextPath(paper)
+			if opts.SkipExisting && fileExists(textPath) {
+				opts.printf("skipping %s (already exists)\n", textPath)
+				artifacts = append(artifacts, artifact{kind: FileFullText, path: textPath})
+			} else if err := opts.writeFullText(path, textPath); err != nil {
+				return artifacts, fmt.Errorf("failed to extract the text of %s: %w", paper.Title, err)
+			} else {
+				artifacts = append(artifacts, artifact{kind: FileFullText, path: textPath, written: true})
+			}
+		}
+	}
+
+	if opts.SaveSources {
+		if err := opts.mkdirAll(opts.path(SourceDirectory)); err != nil {
+			return artifacts, fmt.Errorf("failed to create source directory: %w", err)
+		}
+		base := filepath.Join(opts.path(SourceDirectory), FormatFilename(opts.FilenameTemplate, paper))
+		if opts.SkipExisting && sourceExists(base) {
+			opts.printf("skipping source of %s (already exists)\n", paper.Title)
+			artifacts = append(artifacts, artifact{kind: FileSource, path: savedSource(base)})
+		} else if err := paper.FetchSource(ctx, base); err != nil {
+			return artifacts, fmt.Errorf("failed to fetch source for %s: %w", paper.Title, err)
+		} else if path := savedSource(base); path != "" {
+			artifacts = append(artifacts, artifact{kind: FileSource, path: path, written: true})
+		}
+	}
+
+	if opts.SaveSummaries {
+		if err := opts.mkdirAll(opts.textDir()); err != nil {
+			return artifacts, fmt.Errorf("failed to create text directory: %w", err)
+		}
+		path := filepath.Join(opts.textDir(), FormatFilename(opts.FilenameTemplate, paper)+".txt")
+		if opts.SkipExisting && fileExists(path) {
+			opts.printf("skipping %s (already exists)\n", path)
+			artifacts = append(artifacts, artifact{kind: FileSummary, path: path})
+		} else if err := paper.WriteSummary(path); err != nil {
+			return artifacts, fmt.Errorf("failed to write summary for %s: %w", paper.Title, err)
+		} else {
+			artifacts = append(artifacts, artifact{kind: FileSummary, path: path, written: true})
+		}
+	}
+
+	if opts.SaveEPUB {
+		if err := opts.mkdirAll(opts.path(EPUBDirectory)); err != nil {
+			return artifacts, fmt.Errorf("failed to create EPUB directory: %w", err)
+		}
+		path := filepath.Join(opts.path(EPUBDirectory), FormatFilename(opts.FilenameTemplate, paper)+".epub")
+		if opts.SkipExisting && fileExists(path) {
+			opts.printf("skipping %s (already exists)\n", path)
+			artifacts = append(artifacts, artifact{kind: FileEPUB, path: path})
+		} else if err := paper.WriteEPUB(path); err != nil {
+			return artifacts, fmt.Errorf("failed to write EPUB for %s: %w", paper.Title, err)
+		} else {
+			artifacts = append(artifacts, artifact{kind: FileEPUB, path: path, written: true})
+		}
+	}
+
+	if opts.Podcast != nil {
+		podcast, err := savePodcast(ctx, paper, opts)
+		artifacts = append(artifacts, podcast...)
+		if err != nil {
+			return artifacts, fmt.Errorf("failed to generate podcast for %s: %w", paper.Title, err)
+		}
+	}
+
+	return artifacts, nil
+}
+
+// printURL prints the PDF URL of paper, followed in Aria2 mode by the
+// aria2c input-file option naming the output file.
+func printURL(opts Options, paper ArxivPaper) {
+	if !opts.Aria2 {
+		opts.printf("%s\n", paper.PDFURL)
+		return
+	}
+	opts.printf("%s\n  out=%s.pdf\n", paper.PDFURL, FormatFilename(opts.FilenameTemplate, paper))
+}
+
+// printDryRun describes what a real run would download for paper.
+func printDryRun(opts Options, paper ArxivPaper) {
+	opts.printf("%s [%s] %s\n  pdf: %s\n", paper.ID, paper.PrimaryCategory, paper.Title, paper.PDFURL)
+}
+
+// fileExists reports whether path is a non-empty regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Size() > 0
+}
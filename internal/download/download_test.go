@@ -3,7 +3,12 @@ package download
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -111,7 +116,7 @@ func TestDownloadArxivPapersIntegration(t *testing.T) {
 	_ = os.RemoveAll(TextDirectory)
 
 	ctx := testingContext(t)
-	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, true, false, false)
+	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, true, false, false, nil)
 	if err != nil {
 		t.Fatalf("DownloadArxivPapers() error = %v", err)
 	}
@@ -147,7 +152,7 @@ func TestDownloadArxivPapersPDFs(t *testing.T) {
 	_ = os.RemoveAll(TextDirectory)
 
 	ctx := testingContext(t)
-	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, false, true, false)
+	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, false, true, false, nil)
 	if err != nil {
 		t.Fatalf("DownloadArxivPapers() error = %v", err)
 	}
@@ -191,7 +196,7 @@ func TestDownloadArxivPapersSummaries(t *testing.T) {
 	_ = os.RemoveAll(TextDirectory)
 
 	ctx := testingContext(t)
-	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, false, false, true)
+	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, false, false, true, nil)
 	if err != nil {
 		t.Fatalf("DownloadArxivPapers() error = %v", err)
 	}
@@ -235,7 +240,7 @@ func TestDownloadArxivPapersAll(t *testing.T) {
 	_ = os.RemoveAll(TextDirectory)
 
 	ctx := testingContext(t)
-	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, true, true, true)
+	err := DownloadArxivPapers(ctx, "cat:cs.CL", 2, true, true, true, nil)
 	if err != nil {
 		t.Fatalf("DownloadArxivPapers() error = %v", err)
 	}
@@ -278,9 +283,399 @@ func TestDownloadArxivPapersAll(t *testing.T) {
 	}
 }
 
+func TestParseFeedNormalizesCarriageReturns(t *testing.T) {
+	file, err := os.Open("testdata/crlf_feed.xml")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	page, err := parseFeed(file)
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	papers := page.Papers
+	if len(papers) != 2 {
+		t.Fatalf("parseFeed() returned %d papers, want 2", len(papers))
+	}
+
+	first := papers[0]
+	if first.ID != "http://arxiv.org/abs/2401.00001v1" {
+		t.Errorf("ID = %q, want trailing carriage return stripped", first.ID)
+	}
+	if first.Title != "A Title Written On Windows" {
+		t.Errorf("Title = %q, want whitespace collapsed", first.Title)
+	}
+	if first.Summary != "First line of the abstract.\nSecond line with a lone\ncarriage return." {
+		t.Errorf("Summary = %q, want carriage returns folded to newlines", first.Summary)
+	}
+	if first.Authors[0] != "Jane Doe" {
+		t.Errorf("Authors[0] = %q, want %q", first.Authors[0], "Jane Doe")
+	}
+	if first.Comment == nil || *first.Comment != "10 pages, 3 figures" {
+		t.Errorf("Comment = %v, want %q", first.Comment, "10 pages, 3 figures")
+	}
+
+	var lines []string
+	for _, paper := range papers {
+		line, err := marshalMetadata(paper, true)
+		if err != nil {
+			t.Fatalf("marshalMetadata() error = %v", err)
+		}
+		lines = append(lines, string(line))
+	}
+	assertValidJSONL(t, strings.Join(lines, "\n")+"\n", len(papers))
+}
+
+func TestParseFeedDOIAndJournalRef(t *testing.T) {
+	entry := strings.Replace(fakeEntry("2401.00001v1", "Published"), "</entry>",
+		"<arxiv:doi>\n      10.1000/xyz123\n    </arxiv:doi><arxiv:journal_ref>J. Test 12 (2024)\n  1-10</arxiv:journal_ref></entry>", 1)
+	page, err := parseFeed(strings.NewReader(fakeFeed(2, entry, fakeEntry("2401.00002v1", "Preprint"))))
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+
+	published, preprint := page.Papers[0], page.Papers[1]
+	if published.DOI != "10.1000/xyz123" {
+		t.Errorf("DOI = %q, want %q", published.DOI, "10.1000/xyz123")
+	}
+	if published.JournalRef != "J. Test 12 (2024) 1-10" {
+		t.Errorf("JournalRef = %q, want %q", published.JournalRef, "J. Test 12 (2024) 1-10")
+	}
+
+	line, err := marshalMetadata(published, false)
+	if err != nil {
+		t.Fatalf("marshalMetadata() error = %v", err)
+	}
+	if !strings.Contains(string(line), `"journal_ref":"J. Test 12 (2024) 1-10","doi":"10.1000/xyz123"`) {
+		t.Errorf("metadata = %s, want journal_ref and doi", line)
+	}
+
+	line, err = marshalMetadata(preprint, false)
+	if err != nil {
+		t.Fatalf("marshalMetadata() error = %v", err)
+	}
+	if strings.Contains(string(line), `"doi"`) || strings.Contains(string(line), `"journal_ref"`) {
+		t.Errorf("metadata = %s, want doi and journal_ref omitted", line)
+	}
+}
+
+func TestMarshalMetadataIncludeSummary(t *testing.T) {
+	paper := ArxivPaper{
+		ID:      "test-id",
+		Title:   "test_title",
+		Summary: "This is a test summary.",
+	}
+
+	without, err := marshalMetadata(paper, false)
+	if err != nil {
+		t.Fatalf("marshalMetadata() error = %v", err)
+	}
+	if strings.Contains(string(without), "summary") {
+		t.Error("default metadata should not include summary field")
+	}
+
+	with, err := marshalMetadata(paper, true)
+	if err != nil {
+		t.Fatalf("marshalMetadata() error = %v", err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(with, &record); err != nil {
+		t.Fatalf("Failed to unmarshal metadata: %v", err)
+	}
+	if record["summary"] != "This is a test summary." {
+		t.Errorf("summary = %v, want %q", record["summary"], "This is a test summary.")
+	}
+	if record["title"] != "test_title" {
+		t.Errorf("title = %v, want %q", record["title"], "test_title")
+	}
+}
+
+func TestRunSkipExisting(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	if err := os.MkdirAll(TextDirectory, 0755); err != nil {
+		t.Fatalf("Failed to create text directory: %v", err)
+	}
+	existing := TextDirectory + "Paper 0.txt"
+	if err := os.WriteFile(existing, []byte("kept"), 0644); err != nil {
+		t.Fatalf("Failed to write existing summary: %v", err)
+	}
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveSummaries: true, SkipExisting: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(existing)
+	if err != nil || string(content) != "kept" {
+		t.Errorf("existing summary = %q (%v), want it untouched", content, err)
+	}
+	if _, err := os.Stat(TextDirectory + "Paper 1.txt"); err != nil {
+		t.Errorf("missing summary was not written: %v", err)
+	}
+	if want := "skipping texts/Paper 0.txt (already exists)\nshowing 2 of 2 matching papers\n"; out.String() != want {
+		t.Errorf("output = %q, want a single skipping line and the total", out.String())
+	}
+}
+
+func TestRunPrintURLs(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 3, SaveMetadata: true, PrintURLs: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("printed %d lines, want one URL per paper: %q", len(lines), out.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "http://arxiv.org/pdf/") {
+			t.Errorf("line %q is not a PDF URL", line)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("--print-urls wrote %d files, want none", len(entries))
+	}
+
+	out.Reset()
+	err = Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, PrintURLs: true, Aria2: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out.String() != "http://arxiv.org/pdf/2401.00000v1\n  out=Paper 0.pdf\n" {
+		t.Errorf("aria2 output = %q", out.String())
+	}
+}
+
+func TestRunPrintIDs(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, PrintIDs: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out.String() != "2401.00000\n2401.00001\n" {
+		t.Errorf("output = %q, want one ID without version per line", out.String())
+	}
+	ids, err := ReadIDs(strings.NewReader(out.String()))
+	if err != nil || len(ids) != 2 {
+		t.Errorf("ReadIDs() of the output = %v, %v", ids, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("printing IDs wrote %d files, want none", len(entries))
+	}
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, PrintIDs: true, PrintURLs: true}); err == nil {
+		t.Error("Run() printing both IDs and URLs succeeded")
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:         "cat:cs.CL",
+		Limit:         2,
+		SaveMetadata:  true,
+		SavePDFs:      true,
+		SaveSummaries: true,
+		DryRun:        true,
+		Out:           &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, want := range []string{"http://arxiv.org/abs/2401.00001v1", "[cs.CL]", "Paper 1", "pdf: http://arxiv.org/pdf/2401.00001v1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry-run output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("--dry-run created %d files or directories, want none", len(entries))
+	}
+}
+
+func TestRunCite(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:        "cat:cs.CL",
+		Limit:        2,
+		SaveMetadata: true,
+		Cite: func(paper ArxivPaper) (string, error) {
+			return paper.Title + " (" + BaseID(paper.ID) + ")", nil
+		},
+		Out: &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := "Paper 0 (2401.00000)\nPaper 1 (2401.00001)\n"; out.String() != want {
+		t.Errorf("citations = %q, want %q", out.String(), want)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("citation mode created %d files or directories, want none", len(entries))
+	}
+}
+
+// assertValidJSONL checks that every line of content parses on its own as a
+// JSON object and carries no raw control characters outside of escapes.
+func assertValidJSONL(t *testing.T, content string, wantLines int) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) != wantLines {
+		t.Fatalf("got %d JSONL lines, want %d", len(lines), wantLines)
+	}
+	for i, line := range lines {
+		for _, r := range line {
+			if r < 0x20 {
+				t.Errorf("line %d contains raw control character %q", i+1, r)
+			}
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("line %d does not parse independently: %v", i+1, err)
+		}
+	}
+}
+
 // Helper function to create a context for testing
 func testingContext(t *testing.T) context.Context {
 	ctx := context.Background()
 	// Add timeout for tests if needed
 	return ctx
 }
+
+// fakeEntry renders a minimal Atom entry for a paper with the given short ID.
+func fakeEntry(id, title string) string {
+	return fmt.Sprintf(`<entry>
+    <id>http://arxiv.org/abs/%[1]s</id>
+    <updated>2024-01-02T00:00:00Z</updated>
+    <published>2024-01-01T00:00:00Z</published>
+    <title>%[2]s</title>
+    <summary>Summary of %[2]s.</summary>
+    <author><name>Jane Doe</name></author>
+    <link href="http://arxiv.org/abs/%[1]s" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/%[1]s" rel="related" type="application/pdf"/>
+    <category term="cs.CL" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>`, id, title)
+}
+
+// fakeFeed wraps entries in an Atom feed reporting total matching results.
+func fakeFeed(total int, entries ...string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <opensearch:totalResults>%d</opensearch:totalResults>
+  %s
+</feed>`, total, strings.Join(entries, "\n  "))
+}
+
+// pagedFeedHandler serves total fake papers, honoring start and max_results.
+func pagedFeedHandler(total int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		maxResults, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		var entries []string
+		for i := start; i < total && i < start+maxResults; i++ {
+			entries = append(entries, fakeEntry(fmt.Sprintf("2401.%05dv1", i), fmt.Sprintf("Paper %d", i)))
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(total, entries...))
+	}
+}
+
+// useFakeAPI points the package at a local server answering API queries with
+// handler and disables the delay between paged requests.
+func useFakeAPI(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	oldBase, oldDelay := apiBaseURL, pageDelay
+	apiBaseURL, pageDelay = server.URL, 0
+	t.Cleanup(func() {
+		apiBaseURL, pageDelay = oldBase, oldDelay
+		server.Close()
+	})
+	return server
+}
+
+// chdirTemp runs the rest of the test inside a fresh temporary directory.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return dir
+}
+
+func TestFetchPDFRejectsNonPDF(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pdf/ok" {
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.5\n..."))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html>Service unavailable</html>"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	paper := ArxivPaper{PDFURL: server.URL + "/pdf/error"}
+	err := paper.FetchPDF(testingContext(t), filepath.Join(dir, "error.pdf"))
+	if err == nil || !strings.Contains(err.Error(), "not a PDF") || !strings.Contains(err.Error(), "text/html") {
+		t.Errorf("FetchPDF() error = %v, want a not-a-PDF error", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "error.pdf")); !os.IsNotExist(statErr) {
+		t.Error("FetchPDF() left a file behind for an HTML response")
+	}
+
+	paper.PDFURL = server.URL + "/pdf/ok"
+	if err := paper.FetchPDF(testingContext(t), filepath.Join(dir, "ok")); err != nil {
+		t.Fatalf("FetchPDF() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "ok.pdf")); err != nil || string(data) != "%PDF-1.5\n..." {
+		t.Errorf("saved PDF = %q, %v", data, err)
+	}
+}
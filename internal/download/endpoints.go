@@ -0,0 +1,91 @@
+package download
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Endpoint is a service the tool may send requests to.
+type Endpoint struct {
+	Name    string `json:"name"`
+	Host    string `json:"host"`
+	URL     string `json:"url"` // without credentials or, for webhooks, path
+	UsedFor string `json:"used_for"`
+	Proxy   string `json:"proxy,omitempty"` // the proxy requests go through, if any
+}
+
+// integration is a service the package sends requests to, with the URL it
+// uses under opts; an empty URL means opts never contact it.
+type integration struct {
+	name, usedFor string
+	url           func(opts Options) string
+	secretPath    bool // the path carries a token, as in a Slack webhook URL
+}
+
+// integrations lists every service the package talks to. The URLs are read
+// from the same variables and options the requests are made with.
+var integrations = []integration{
+	{name: "arXiv API", usedFor: "searches and ID lookups", url: func(Options) string { return apiBaseURL }},
+	{name: "arXiv OAI-PMH", usedFor: "bulk harvests", url: func(Options) string { return oaiBaseURL }},
+	{name: "arXiv PDFs", usedFor: "PDF downloads", url: func(Options) string { return arxivPDFBase }},
+	{name: "arXiv e-prints", usedFor: "source downloads", url: func(Options) string { return eprintBaseURL }},
+	{name: "CrossRef", usedFor: "published version lookups", url: func(Options) string { return crossrefAPIBase }},
+	{name: "ORCID", usedFor: "author watches", url: func(Options) string { return orcidAPIBase }},
+	{name: "LLM API", usedFor: "podcast scripts", url: func(opts Options) string {
+		if opts.Podcast == nil {
+			return DefaultLLMAPIURL
+		}
+		return valueOr(opts.Podcast.APIURL, DefaultLLMAPIURL)
+	}},
+	{name: "TTS API", usedFor: "podcast audio", url: func(opts Options) string {
+		if opts.Podcast == nil {
+			return ""
+		}
+		return opts.Podcast.TTSAPIURL
+	}},
+	{name: "webhook", usedFor: "run summaries", url: func(opts Options) string { return opts.Webhook }, secretPath: true},
+}
+
+// Endpoints lists the services a run with opts could contact, with the
+// proxy each request would go through under the current SetProxy or
+// environment settings. Credentials are left out of the URLs, and so are
+// the paths of webhooks, which usually carry a token.
+func Endpoints(opts Options) []Endpoint {
+	var endpoints []Endpoint
+	for _, i := range integrations {
+		raw := i.url(opts)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			// Run rejects it before contacting anything.
+			continue
+		}
+		endpoint := Endpoint{Name: i.name, Host: u.Hostname(), UsedFor: i.usedFor}
+		if proxy := proxyFor(u); proxy != nil {
+			endpoint.Proxy = proxy.Redacted()
+		}
+		if i.secretPath {
+			u.Path, u.RawPath = redactedPath, ""
+		}
+		u.RawQuery = ""
+		endpoint.URL = u.Redacted()
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// proxyFor returns the proxy the shared transport sends requests for u
+// through, or nil.
+func proxyFor(u *url.URL) *url.URL {
+	transport, ok := httpTransport.next.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		return nil
+	}
+	proxy, err := transport.Proxy(&http.Request{URL: u, Header: http.Header{}})
+	if err != nil {
+		return nil
+	}
+	return proxy
+}
@@ -0,0 +1,93 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/epub"
+)
+
+// EPUBDirectory holds the e-reader books of Options.SaveEPUB.
+const EPUBDirectory = "epub/"
+
+// WriteEPUB writes p as an EPUB 3 book to outPath, adding ".epub" if it
+// has no such extension. The book holds the paper's metadata and abstract.
+func (p *ArxivPaper) WriteEPUB(outPath string) error {
+	if !strings.HasSuffix(outPath, ".epub") {
+		outPath += ".epub"
+	}
+	file, err := createFile(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	err = epub.Write(file, p.book())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(outPath)
+		return err
+	}
+	return nil
+}
+
+// book returns the e-reader book of p: one chapter with the title, authors,
+// publication details, abstract and links.
+func (p *ArxivPaper) book() epub.Book {
+	var body strings.Builder
+	fmt.Fprintf(&body, "<h1>%s</h1>\n", epub.Escape(p.Title))
+	if len(p.Authors) > 0 {
+		fmt.Fprintf(&body, "<p><em>%s</em></p>\n", epub.Escape(strings.Join(p.Authors, ", ")))
+	}
+
+	details := []string{"arXiv:" + versionedID(splitArxivID(p.ID))}
+	if published, err := time.Parse(time.RFC3339, p.Published); err == nil {
+		details = append(details, "published "+published.Format("2006-01-02"))
+	}
+	if len(p.Categories) > 0 {
+		details = append(details, strings.Join(p.Categories, ", "))
+	}
+	fmt.Fprintf(&body, "<p>%s</p>\n", epub.Escape(strings.Join(details, " · ")))
+	var comment string
+	if p.Comment != nil {
+		comment = *p.Comment
+	}
+	for _, field := range []struct{ label, value string }{
+		{"Journal reference", p.JournalRef},
+		{"DOI", p.DOI},
+		{"Comment", comment},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&body, "<p><strong>%s:</strong> %s</p>\n", field.label, epub.Escape(field.value))
+		}
+	}
+
+	body.WriteString("<h2>Abstract</h2>\n")
+	for _, paragraph := range strings.Split(normalizeNewlines(p.Summary), "\n\n") {
+		if paragraph = collapseWhitespace(paragraph); paragraph != "" {
+			fmt.Fprintf(&body, "<p>%s</p>\n", epub.Escape(paragraph))
+		}
+	}
+
+	var links []string
+	if p.PDFURL != "" {
+		links = append(links, fmt.Sprintf(`<a href="%s">PDF</a>`, epub.Escape(p.PDFURL)))
+	}
+	if p.HTMLURL != "" {
+		links = append(links, fmt.Sprintf(`<a href="%s">arXiv page</a>`, epub.Escape(p.HTMLURL)))
+	}
+	if len(links) > 0 {
+		fmt.Fprintf(&body, "<p>%s</p>\n", strings.Join(links, " · "))
+	}
+
+	modified, _ := time.Parse(time.RFC3339, p.Updated)
+	return epub.Book{
+		ID:       p.ID,
+		Title:    p.Title,
+		Authors:  p.Authors,
+		Modified: modified,
+		Chapters: []epub.Chapter{{Title: p.Title, Body: body.String()}},
+	}
+}
@@ -0,0 +1,51 @@
+package download
+
+import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSavesEPUB(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(1))
+	chdirTemp(t)
+
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, SaveSummaries: true, SaveEPUB: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	path := filepath.Join(EPUBDirectory, "Paper 0.epub")
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer func() { _ = zr.Close() }()
+	var chapter string
+	for _, f := range zr.File {
+		if f.Name == "OEBPS/chapter-1.xhtml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			chapter = string(data)
+		}
+	}
+	for _, want := range []string{"<h1>Paper 0</h1>", "<em>Jane Doe</em>", "arXiv:2401.00000v1 · published 2024-01-01 · cs.CL", "<p>Summary of Paper 0.</p>"} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("chapter lacks %q:\n%s", want, chapter)
+		}
+	}
+	if files := readFiles(t, JSONFile); files[0][FileEPUB] != "epub/Paper 0.epub" {
+		t.Errorf("files = %v, want the EPUB recorded", files[0])
+	}
+
+	err = Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveEPUB: true, Out: &strings.Builder{}})
+	if err == nil {
+		t.Error("Run() made EPUB files without summaries")
+	}
+}
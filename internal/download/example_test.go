@@ -0,0 +1,100 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exampleServer stands in for arXiv in the examples: it serves a feed of
+// two papers and their PDFs. The returned function restores the real API.
+func exampleServer() func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/pdf/") {
+			_, _ = w.Write([]byte("%PDF-1.5\n..."))
+			return
+		}
+		entries := []string{fakeEntry("2401.00001v1", "Attention Is All You Need"), fakeEntry("2401.00002v2", "Graph Retrieval-Augmented Generation")}
+		feed := strings.ReplaceAll(fakeFeed(2, entries...), "http://arxiv.org/pdf/", "http://"+r.Host+"/pdf/")
+		_, _ = fmt.Fprint(w, feed)
+	}))
+	oldBase, oldDelay := apiBaseURL, pageDelay
+	apiBaseURL, pageDelay = server.URL, 0
+	return func() {
+		apiBaseURL, pageDelay = oldBase, oldDelay
+		server.Close()
+	}
+}
+
+func ExampleSearch() {
+	defer exampleServer()()
+
+	papers, err := Search(context.Background(), "cat:cs.CL", 2)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, paper := range papers {
+		fmt.Printf("%s v%d: %s\n", BaseID(paper.ID), paper.Version, paper.Title)
+	}
+	// Output:
+	// 2401.00001 v1: Attention Is All You Need
+	// 2401.00002 v2: Graph Retrieval-Augmented Generation
+}
+
+func ExampleArxivPaper_FetchPDF() {
+	defer exampleServer()()
+
+	papers, err := Search(context.Background(), "cat:cs.CL", 1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	dir, err := os.MkdirTemp("", "papers")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	paper := papers[0]
+	path := filepath.Join(dir, FormatFilename("", paper)+".pdf")
+	if err := paper.FetchPDF(context.Background(), path); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("saved", filepath.Base(path))
+	// Output: saved Attention Is All You Need.pdf
+}
+
+func ExampleReadMetadata() {
+	defer exampleServer()()
+
+	dir, err := os.MkdirTemp("", "papers")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, OutputDir: dir, Out: &strings.Builder{}}
+	if err := Run(context.Background(), opts); err != nil {
+		fmt.Println(err)
+		return
+	}
+	papers, err := ReadMetadata(filepath.Join(dir, JSONFile))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, paper := range papers {
+		fmt.Println(paper.Title, paper.Categories)
+	}
+	// Output:
+	// Attention Is All You Need [cs.CL]
+	// Graph Retrieval-Augmented Generation [cs.CL]
+}
@@ -0,0 +1,210 @@
+package download
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// ReferenceMatch is how a paper matches a ReferenceCorpus.
+type ReferenceMatch string
+
+// Ways a paper can match a ReferenceCorpus, strongest first.
+const (
+	NoReferenceMatch ReferenceMatch = ""
+	ReferenceByID    ReferenceMatch = "ID"
+	ReferenceByDOI   ReferenceMatch = "DOI"
+	ReferenceByTitle ReferenceMatch = "title"
+)
+
+// ReferenceCorpus is a set of papers a run already knows about, such as an
+// earlier run's metadata or a bibliography, that it should not report
+// again (see Options.ExcludeFrom).
+type ReferenceCorpus struct {
+	ids    map[string]struct{} // arXiv IDs without version
+	dois   map[string]struct{} // see normalizeDOI
+	titles map[string]struct{} // see normTitle
+
+	// byLength holds the titles by length in runes, so that the fuzzy title
+	// match only compares titles long enough to be near.
+	byLength map[int][]string
+}
+
+// NewReferenceCorpus returns an empty corpus.
+func NewReferenceCorpus() *ReferenceCorpus {
+	return &ReferenceCorpus{
+		ids:      map[string]struct{}{},
+		dois:     map[string]struct{}{},
+		titles:   map[string]struct{}{},
+		byLength: map[int][]string{},
+	}
+}
+
+// LoadReferenceCorpus reads the papers of each source into one corpus. A
+// source is a JSONL metadata file (.jsonl), a BibTeX file (.bib), or a
+// workspace, i.e. the output directory of earlier runs, whose metadata.jsonl
+// and papers.bib are read, whichever exist.
+func LoadReferenceCorpus(sources ...string) (*ReferenceCorpus, error) {
+	corpus := NewReferenceCorpus()
+	for _, source := range sources {
+		if err := corpus.load(source); err != nil {
+			return nil, err
+		}
+	}
+	return corpus, nil
+}
+
+// load adds the papers of one source to c.
+func (c *ReferenceCorpus) load(source string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return fmt.Errorf("failed to read reference %s: %w", source, err)
+	}
+	if info.IsDir() {
+		found := false
+		for _, name := range []string{JSONFile, format.BibTeXFile} {
+			path := filepath.Join(source, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if err := c.load(path); err != nil {
+				return err
+			}
+			found = true
+		}
+		if !found {
+			return fmt.Errorf("reference workspace %s has neither %s nor %s", source, JSONFile, format.BibTeXFile)
+		}
+		return nil
+	}
+
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".jsonl":
+		papers, err := ReadMetadata(source)
+		if err != nil {
+			return err
+		}
+		for _, paper := range papers {
+			c.Add(paper)
+		}
+	case ".bib":
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read reference %s: %w", source, err)
+		}
+		for _, fields := range format.ParseBibTeX(string(data)) {
+			c.Add(bibTeXPaper(fields))
+		}
+	default:
+		return fmt.Errorf("unsupported reference %s (expected a .jsonl metadata file, a .bib file or a workspace directory)", source)
+	}
+	return nil
+}
+
+// bibTeXPaper returns the identifying fields of a BibTeX entry as a paper.
+// The arXiv ID comes from the eprint field, or else from an arXiv url.
+func bibTeXPaper(fields map[string]string) ArxivPaper {
+	paper := ArxivPaper{Title: fields["title"], DOI: fields["doi"]}
+	if eprint := fields["eprint"]; eprint != "" {
+		paper.ID = strings.TrimPrefix(eprint, "arXiv:")
+	} else if url := fields["url"]; strings.Contains(url, "arxiv.org/abs/") {
+		paper.ID = url
+	}
+	return paper
+}
+
+// Add records the arXiv ID, DOI and title of paper, whichever are set.
+func (c *ReferenceCorpus) Add(paper ArxivPaper) {
+	if id := BaseID(paper.ID); id != "" {
+		c.ids[id] = struct{}{}
+	}
+	if doi := normalizeDOI(paper.DOI); doi != "" {
+		c.dois[doi] = struct{}{}
+	}
+	if title := normTitle(paper.Title); title != "" {
+		if _, ok := c.titles[title]; !ok {
+			c.titles[title] = struct{}{}
+			n := utf8.RuneCountInString(title)
+			c.byLength[n] = append(c.byLength[n], title)
+		}
+	}
+}
+
+// Match reports how paper matches c: by arXiv ID, regardless of version;
+// by DOI; or by a title within DefaultTitleDistance of one in c (see
+// DeduplicateByTitle). It returns NoReferenceMatch when none of them does.
+func (c *ReferenceCorpus) Match(paper ArxivPaper) ReferenceMatch {
+	for _, tier := range c.tiers() {
+		if tier.match(paper) {
+			return tier.kind
+		}
+	}
+	return NoReferenceMatch
+}
+
+type referenceTier struct {
+	kind  ReferenceMatch
+	match func(ArxivPaper) bool
+}
+
+// tiers returns the ways of matching c, strongest first.
+func (c *ReferenceCorpus) tiers() []referenceTier {
+	return []referenceTier{
+		{ReferenceByID, c.matchID},
+		{ReferenceByDOI, c.matchDOI},
+		{ReferenceByTitle, c.matchTitle},
+	}
+}
+
+func (c *ReferenceCorpus) matchID(paper ArxivPaper) bool {
+	_, ok := c.ids[BaseID(paper.ID)]
+	return ok && paper.ID != ""
+}
+
+func (c *ReferenceCorpus) matchDOI(paper ArxivPaper) bool {
+	_, ok := c.dois[normalizeDOI(paper.DOI)]
+	return ok && paper.DOI != ""
+}
+
+// matchTitle looks the normalized title up, then compares it to the titles
+// whose lengths allow a distance within DefaultTitleDistance: the distance
+// is at least the difference in length over the longer length. The range
+// is widened by one on each side against rounding; titleDistance decides.
+func (c *ReferenceCorpus) matchTitle(paper ArxivPaper) bool {
+	title := normTitle(paper.Title)
+	if title == "" {
+		return false
+	}
+	if _, ok := c.titles[title]; ok {
+		return true
+	}
+	n := float64(utf8.RuneCountInString(title))
+	shortest := int(math.Ceil(n*(1-DefaultTitleDistance))) - 1
+	longest := int(math.Floor(n/(1-DefaultTitleDistance))) + 1
+	for length := shortest; length <= longest; length++ {
+		for _, known := range c.byLength[length] {
+			if titleDistance(title, known) <= DefaultTitleDistance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// referenceFilters returns a filter per kind of match with c, so that the
+// run reports how many papers each one suppressed. As each filter only sees
+// the papers the stronger ones kept, it only checks its own kind.
+func (c *ReferenceCorpus) referenceFilters() []paperFilter {
+	var filters []paperFilter
+	for _, tier := range c.tiers() {
+		filters = append(filters, paperFilter{"already in the reference corpus by " + string(tier.kind), func(paper ArxivPaper) bool {
+			return !tier.match(paper)
+		}})
+	}
+	return filters
+}
@@ -0,0 +1,146 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const referenceBib = `@misc{2401.00001,
+  title = {{Paper 1}},
+  eprint = {2401.00001},
+  url = {https://arxiv.org/abs/2401.00001v1},
+}
+
+@article{smith2020,
+  title = {Paper \textbf{2}},
+  doi = {10.1000/XYZ},
+}
+`
+
+func TestLoadReferenceCorpus(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	jsonl := writeFile("old.jsonl", `{"id":"http://arxiv.org/abs/2401.00000v3","title":"Paper 0","doi":"https://doi.org/10.1000/ABC"}`+"\n")
+	bib := writeFile("refs.bib", referenceBib)
+	writeFile("workspace/"+JSONFile, `{"id":"2402.00009v1","title":"A Workspace Paper"}`+"\n")
+
+	corpus, err := LoadReferenceCorpus(jsonl, bib, filepath.Join(dir, "workspace"))
+	if err != nil {
+		t.Fatalf("LoadReferenceCorpus() error = %v", err)
+	}
+	for _, tt := range []struct {
+		paper ArxivPaper
+		want  ReferenceMatch
+	}{
+		{ArxivPaper{ID: "2401.00000v1"}, ReferenceByID},
+		{ArxivPaper{ID: "http://arxiv.org/abs/2401.00001v2"}, ReferenceByID},
+		{ArxivPaper{ID: "2402.00009"}, ReferenceByID},
+		{ArxivPaper{DOI: "10.1000/abc"}, ReferenceByDOI},
+		{ArxivPaper{DOI: "doi:10.1000/xyz"}, ReferenceByDOI},
+		{ArxivPaper{Title: "paper 2"}, ReferenceByTitle},
+		{ArxivPaper{Title: "A workspace paper."}, ReferenceByTitle},
+		{ArxivPaper{ID: "2401.00002", Title: "Paper 3"}, NoReferenceMatch},
+	} {
+		if got := corpus.Match(tt.paper); got != tt.want {
+			t.Errorf("Match(%+v) = %q, want %q", tt.paper, got, tt.want)
+		}
+	}
+
+	for _, source := range []string{filepath.Join(dir, "missing.jsonl"), t.TempDir(), writeFile("refs.csv", "id\n")} {
+		if _, err := LoadReferenceCorpus(source); err == nil {
+			t.Errorf("LoadReferenceCorpus(%s) succeeded", source)
+		}
+	}
+}
+
+func TestReferenceCorpusMatchTiers(t *testing.T) {
+	corpus := NewReferenceCorpus()
+	corpus.Add(ArxivPaper{ID: "2401.00001v1", DOI: "10.1000/one", Title: "Attention Is All You Need"})
+	corpus.Add(ArxivPaper{DOI: "10.1000/two", Title: "Graph Neural Networks: A Review"})
+
+	tests := []struct {
+		name  string
+		paper ArxivPaper
+		want  ReferenceMatch
+	}{
+		{"ID wins over a different title", ArxivPaper{ID: "2401.00001v4", Title: "Renamed"}, ReferenceByID},
+		{"DOI wins over the title", ArxivPaper{ID: "2403.00001", DOI: "https://doi.org/10.1000/TWO", Title: "Graph Neural Networks: A Review"}, ReferenceByDOI},
+		{"exact normalized title", ArxivPaper{Title: "graph neural networks -- a review"}, ReferenceByTitle},
+		{"near title", ArxivPaper{Title: "Attention is all you needs"}, ReferenceByTitle},
+		{"near, shorter title", ArxivPaper{Title: "Attention Is All You Ne"}, ReferenceByTitle},
+		{"title too much shorter", ArxivPaper{Title: "Attention Is All You"}, NoReferenceMatch},
+		{"different DOI and title", ArxivPaper{DOI: "10.1000/three", Title: "Attention Is Not All You Need At All"}, NoReferenceMatch},
+		{"empty paper", ArxivPaper{}, NoReferenceMatch},
+	}
+	for _, tt := range tests {
+		if got := corpus.Match(tt.paper); got != tt.want {
+			t.Errorf("%s: Match() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Each filter drops the papers of its own tier only.
+	paper := ArxivPaper{ID: "2401.00001v4", DOI: "10.1000/one", Title: "Attention Is All You Need"}
+	for _, filter := range corpus.referenceFilters() {
+		if filter.keep(paper) {
+			t.Errorf("%s: kept a paper matching every tier", filter.reason)
+		}
+	}
+	filters := corpus.referenceFilters()
+	if !filters[0].keep(ArxivPaper{DOI: "10.1000/one"}) || !filters[1].keep(ArxivPaper{Title: "Attention Is All You Need"}) || !filters[2].keep(ArxivPaper{ID: "2401.00001"}) {
+		t.Error("a tier's filter dropped a paper matching another tier only")
+	}
+}
+
+func TestRunExcludeFrom(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(6))
+	if err := os.WriteFile("old.jsonl", []byte(`{"id":"2401.00000v2","title":"Something Else"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("refs.bib", []byte(referenceBib), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:        "cat:cs.CL",
+		Limit:        3,
+		SaveMetadata: true,
+		ExcludeFrom:  []string{"old.jsonl", "refs.bib"},
+		OutputDir:    "run",
+		Out:          &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	papers, err := ReadMetadata(filepath.Join(dir, "run", JSONFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, paper := range papers {
+		titles = append(titles, paper.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Paper 3, Paper 4, Paper 5" {
+		t.Errorf("saved %s, want Paper 3 to 5", got)
+	}
+	if want := "2 already in the reference corpus by ID, 1 already in the reference corpus by title"; !strings.Contains(out.String(), want) {
+		t.Errorf("output = %q, want it to report %q", out.String(), want)
+	}
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", ExcludeFrom: []string{"missing.bib"}}); err == nil {
+		t.Error("Run() with a missing reference succeeded")
+	}
+}
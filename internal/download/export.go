@@ -0,0 +1,69 @@
+package download
+
+import (
+	"strings"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// entry returns the bibliographic data of p.
+func (p ArxivPaper) entry() format.Entry {
+	return format.Entry{
+		ID:              BaseID(p.ID),
+		Title:           p.Title,
+		Authors:         p.Authors,
+		Published:       p.PublishedTime(),
+		Abstract:        p.Summary,
+		JournalRef:      p.JournalRef,
+		DOI:             p.DOI,
+		PrimaryCategory: p.PrimaryCategory,
+		Categories:      p.Categories,
+		PDFURL:          p.PDFURL,
+		Notes:           formatNotes(p.notes),
+	}
+}
+
+// ToBibTeX returns a BibTeX entry for the paper keyed by its arXiv ID: an
+// @article when it has a journal reference and a @misc otherwise.
+func (p ArxivPaper) ToBibTeX() string {
+	return p.entry().BibTeX()
+}
+
+// ToRIS returns a RIS record for the paper, for import into reference
+// managers such as Zotero, Mendeley and EndNote.
+func (p ArxivPaper) ToRIS() string {
+	return p.entry().RIS()
+}
+
+// ToMarkdown returns a Markdown section for the paper, headed by its title,
+// as written to format.MarkdownFile.
+func (p ArxivPaper) ToMarkdown() string {
+	return p.entry().Markdown()
+}
+
+// ToDublinCore returns a Dublin Core XML record for the paper, as saved
+// under DublinCoreDirectory with Options.DublinCore.
+func (p ArxivPaper) ToDublinCore() (string, error) {
+	return p.entry().DublinCore()
+}
+
+// ToCSVRow returns the paper's fields in the order of format.CSVHeader,
+// with authors and categories joined by semicolons.
+func (p ArxivPaper) ToCSVRow() []string {
+	comment := ""
+	if p.Comment != nil {
+		comment = *p.Comment
+	}
+	return []string{
+		p.ID,
+		p.Title,
+		strings.Join(p.Authors, "; "),
+		p.Published,
+		p.Updated,
+		p.PrimaryCategory,
+		strings.Join(p.Categories, "; "),
+		p.PDFURL,
+		p.HTMLURL,
+		comment,
+	}
+}
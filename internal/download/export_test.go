@@ -0,0 +1,167 @@
+package download
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+func TestToRIS(t *testing.T) {
+	paper := ArxivPaper{
+		ID:        "http://arxiv.org/abs/2401.00001v2",
+		Title:     "A Paper",
+		Summary:   "An abstract.",
+		Published: "2024-01-02T00:00:00Z",
+		Authors:   []string{"Jane Doe"},
+	}
+	want := "TY  - JOUR\nTI  - A Paper\nAU  - Jane Doe\nPY  - 2024\nUR  - https://arxiv.org/abs/2401.00001\nAB  - An abstract.\nER  - \n"
+	if got := paper.ToRIS(); got != want {
+		t.Errorf("ToRIS() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToCSVRow(t *testing.T) {
+	comment := "12 pages"
+	paper := ArxivPaper{
+		ID:         "http://arxiv.org/abs/2401.00001v2",
+		Title:      "A Paper",
+		Authors:    []string{"Jane Doe", "Richard Roe"},
+		Categories: []string{"cs.CL", "cs.AI"},
+		Comment:    &comment,
+	}
+	row := paper.ToCSVRow()
+	if len(row) != len(format.CSVHeader) {
+		t.Fatalf("ToCSVRow() has %d columns, want %d", len(row), len(format.CSVHeader))
+	}
+	if row[2] != "Jane Doe; Richard Roe" || row[6] != "cs.CL; cs.AI" || row[9] != "12 pages" {
+		t.Errorf("ToCSVRow() = %q", row)
+	}
+}
+
+func TestToMarkdown(t *testing.T) {
+	paper := ArxivPaper{
+		ID:         "http://arxiv.org/abs/2401.00001v2",
+		Title:      "A\n  Paper",
+		Summary:    "An abstract\nover two lines.",
+		Published:  "2024-01-02T00:00:00Z",
+		Authors:    []string{"Jane Doe", "Richard Roe"},
+		Categories: []string{"cs.CL", "cs.AI"},
+		PDFURL:     "http://arxiv.org/pdf/2401.00001v2",
+	}
+	want := `## A Paper
+
+*Jane Doe, Richard Roe*
+
+**Published:** 2024-01-02  
+**Categories:** cs.CL, cs.AI  
+**PDF:** [http://arxiv.org/pdf/2401.00001v2](http://arxiv.org/pdf/2401.00001v2)
+
+> An abstract
+> over two lines.
+`
+	if got := paper.ToMarkdown(); got != want {
+		t.Errorf("ToMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunMetadataFormats(t *testing.T) {
+	tests := []struct {
+		format format.Format
+		marker string
+	}{
+		{format.BibTeX, "@misc{2401.00001,"},
+		{format.RIS, "UR  - https://arxiv.org/abs/2401.00001\n"},
+		{format.CSV, "id,title,authors,published,updated,primary_category,categories,pdf_url,html_url,comment\n" +
+			"http://arxiv.org/abs/2401.00000v1,Paper 0,Jane Doe,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,cs.CL,cs.CL,"},
+		{format.Markdown, "> Summary of Paper 0.\n\n---\n\n## Paper 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			chdirTemp(t)
+			useFakeAPI(t, pagedFeedHandler(2))
+
+			if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, OutputFormats: []format.Format{tt.format}}); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			content, err := os.ReadFile(tt.format.Filename())
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", tt.format.Filename(), err)
+			}
+			if !strings.Contains(string(content), tt.marker) {
+				t.Errorf("%s lacks %q:\n%s", tt.format.Filename(), tt.marker, content)
+			}
+			if _, err := os.Stat(JSONFile); !os.IsNotExist(err) {
+				t.Errorf("%s was written in %s mode", JSONFile, tt.format)
+			}
+		})
+	}
+}
+
+func TestRunStreamsMetadata(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(4))
+
+	var records, messages strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:         "cat:cs.CL",
+		Limit:         2,
+		SaveMetadata:  true,
+		SaveSummaries: true,
+		TitleMatch:    regexp.MustCompile("Paper [13]"),
+		MetadataOut:   &records,
+		Out:           &messages,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	assertValidJSONL(t, records.String(), 2)
+	if !strings.Contains(messages.String(), "filtered out") || strings.Contains(records.String(), "filtered out") {
+		t.Errorf("messages went to the wrong stream:\nrecords: %s\nmessages: %s", records.String(), messages.String())
+	}
+	if _, err := os.Stat(JSONFile); !os.IsNotExist(err) {
+		t.Errorf("%s was written while streaming", JSONFile)
+	}
+	if entries, _ := os.ReadDir(TextDirectory); len(entries) != 2 {
+		t.Errorf("saved %d summaries, want 2", len(entries))
+	}
+}
+
+func TestDownloadArxivPapersWritesMetadataToWriter(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var records strings.Builder
+	if err := DownloadArxivPapers(testingContext(t), "cat:cs.CL", 2, true, false, false, &records); err != nil {
+		t.Fatalf("DownloadArxivPapers() error = %v", err)
+	}
+	assertValidJSONL(t, records.String(), 2)
+	if _, err := os.Stat(JSONFile); !os.IsNotExist(err) {
+		t.Errorf("%s was written although a writer was given", JSONFile)
+	}
+}
+
+func TestRunStreamsCSVWithHeader(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var records strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, OutputFormats: []format.Format{format.CSV}, MetadataOut: &records})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(records.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,title,") {
+		t.Errorf("streamed CSV = %q, want a header and 2 rows", lines)
+	}
+
+	err = Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, OutputFormats: []format.Format{format.CSV, format.JSONL}, MetadataOut: &records})
+	if err == nil {
+		t.Error("Run() streamed two formats at once")
+	}
+}
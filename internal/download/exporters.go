@@ -0,0 +1,135 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// metadataExport writes the metadata of a run in every requested format,
+// one metadataWriter per format. The exporters are isolated from each
+// other: one that fails is aborted, leaving its file as it was before the
+// run, while the others go on and are kept.
+//
+// With existing set, papers whose IDs are in it are skipped, and counted in
+// skipped, rather than written a second time. recorded holds the Files the
+// previous metadata listed for each paper (see Options.recordFiles).
+type metadataExport struct {
+	writers  []*metadataWriter
+	failures []error
+	existing map[string]struct{}
+	skipped  int
+	recorded map[string]map[string]string
+}
+
+func newMetadataExport(opts Options, appendMode bool) *metadataExport {
+	export := &metadataExport{}
+	for _, f := range opts.formats() {
+		w := newMetadataWriter(opts.path(opts.metadataName(f)), opts.IncludeSummary)
+		w.format = f
+		w.out = opts.MetadataOut
+		w.mode = opts.FileMode
+		w.appendMode = appendMode
+		// A harvest records its progress after each page (see
+		// savePaginationState), so its records must reach the real file as
+		// they are written for a killed run to be resumed.
+		w.atomic = !opts.All
+		export.writers = append(export.writers, w)
+	}
+	return export
+}
+
+// Write adds paper to every exporter that has not failed. It only reports
+// an error once no exporter is left.
+func (e *metadataExport) Write(paper ArxivPaper) error {
+	if e.existing != nil {
+		if _, ok := e.existing[paper.ID]; ok {
+			e.skipped++
+			return nil
+		}
+		e.existing[paper.ID] = struct{}{}
+	}
+	for i, w := range e.writers {
+		if w == nil {
+			continue
+		}
+		if err := w.Write(paper); err != nil {
+			w.Abort()
+			e.fail(w.format, err)
+			e.writers[i] = nil
+		}
+	}
+	if len(e.writers) > 0 && !slices.ContainsFunc(e.writers, func(w *metadataWriter) bool { return w != nil }) {
+		return errors.Join(e.failures...)
+	}
+	return nil
+}
+
+// Sync pushes the records written so far to disk, for every exporter that
+// has not failed.
+func (e *metadataExport) Sync() error {
+	for i, w := range e.writers {
+		if w == nil {
+			continue
+		}
+		if err := w.Sync(); err != nil {
+			w.Abort()
+			e.fail(w.format, err)
+			e.writers[i] = nil
+		}
+	}
+	if len(e.writers) > 0 && !slices.ContainsFunc(e.writers, func(w *metadataWriter) bool { return w != nil }) {
+		return errors.Join(e.failures...)
+	}
+	return nil
+}
+
+// Close closes every exporter that has not failed and returns the failures
+// of all of them.
+func (e *metadataExport) Close() error {
+	for i, w := range e.writers {
+		if w == nil {
+			continue
+		}
+		if err := w.Close(); err != nil {
+			e.fail(w.format, err)
+			e.writers[i] = nil
+		}
+	}
+	return errors.Join(e.failures...)
+}
+
+// files returns the paths of the metadata files that were written and kept.
+func (e *metadataExport) files() []string {
+	var paths []string
+	for _, w := range e.writers {
+		if w != nil && w.out == nil && w.hasRecords {
+			paths = append(paths, w.path)
+		}
+	}
+	return paths
+}
+
+func (e *metadataExport) fail(f format.Format, err error) {
+	e.failures = append(e.failures, fmt.Errorf("failed to export %s metadata: %w", f, err))
+}
+
+// report prints which formats were written when only some of them failed.
+func (e *metadataExport) report(opts Options) {
+	if len(e.failures) == 0 {
+		return
+	}
+	var written []string
+	for _, w := range e.writers {
+		if w != nil {
+			written = append(written, w.format.String())
+		}
+	}
+	if len(written) == 0 {
+		return
+	}
+	opts.printf("metadata export partly failed: wrote %s, %d format(s) failed\n", strings.Join(written, ", "), len(e.failures))
+}
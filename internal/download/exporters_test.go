@@ -0,0 +1,76 @@
+package download
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+func TestRunIsolatesFailingExporter(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	// The BibTeX exporter breaks on the second paper, after it has started
+	// its file.
+	recordEncoders[format.BibTeX] = func(paper ArxivPaper, _ bool) ([]byte, error) {
+		if paper.Title == "Paper 1" {
+			return nil, errors.New("bad data")
+		}
+		return []byte(paper.ToBibTeX()), nil
+	}
+	t.Cleanup(func() { delete(recordEncoders, format.BibTeX) })
+	if err := os.WriteFile(format.BibTeXFile, []byte("% previous run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:         "cat:cs.CL",
+		Limit:         3,
+		SaveMetadata:  true,
+		OutputFormats: []format.Format{format.JSONL, format.BibTeX, format.CSV},
+		Out:           &out,
+	})
+	if err == nil || !strings.Contains(err.Error(), "bibtex") || !strings.Contains(err.Error(), "bad data") {
+		t.Fatalf("Run() error = %v, want the BibTeX failure", err)
+	}
+
+	jsonl, _ := os.ReadFile(format.JSONLFile)
+	if n := strings.Count(string(jsonl), "\n"); n != 3 {
+		t.Errorf("%s has %d records, want 3", format.JSONLFile, n)
+	}
+	csv, _ := os.ReadFile(format.CSVFile)
+	if n := strings.Count(string(csv), "\n"); n != 4 {
+		t.Errorf("%s has %d lines, want a header and 3 rows", format.CSVFile, n)
+	}
+	bib, _ := os.ReadFile(format.BibTeXFile)
+	if string(bib) != "% previous run\n" {
+		t.Errorf("%s = %q, want the previous file left intact", format.BibTeXFile, bib)
+	}
+	if leftovers, _ := filepath.Glob("*.tmp"); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+	if !strings.Contains(out.String(), "wrote jsonl, csv") {
+		t.Errorf("report does not list the written formats:\n%s", out.String())
+	}
+}
+
+func TestRunFailsWhenEveryExporterFails(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	recordEncoders[format.RIS] = func(ArxivPaper, bool) ([]byte, error) { return nil, errors.New("broken") }
+	t.Cleanup(func() { delete(recordEncoders, format.RIS) })
+
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, SaveSummaries: true, OutputFormats: []format.Format{format.RIS}, Out: &strings.Builder{}})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("Run() error = %v, want the RIS failure", err)
+	}
+	if _, err := os.Stat(format.RISFile); !os.IsNotExist(err) {
+		t.Errorf("%s exists after its only exporter failed", format.RISFile)
+	}
+}
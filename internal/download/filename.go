@@ -0,0 +1,59 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultFilenameTemplate names saved files after the paper title.
+const DefaultFilenameTemplate = "{title}"
+
+// filenamePlaceholder matches a {name} placeholder in a filename template.
+var filenamePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// filenameFields are the placeholders a filename template may use.
+var filenameFields = map[string]func(ArxivPaper) string{
+	// The slash of an old-style ID such as hep-th/9901001 becomes an
+	// underscore, like any other path separator in a name.
+	"id":    func(p ArxivPaper) string { return versionedID(splitArxivID(p.ID)) },
+	"title": func(p ArxivPaper) string { return p.Title },
+	"year": func(p ArxivPaper) string {
+		if published := p.PublishedTime(); !published.IsZero() {
+			return strconv.Itoa(published.Year())
+		}
+		return ""
+	},
+	"primary_category": func(p ArxivPaper) string { return p.PrimaryCategory },
+}
+
+// ValidateFilenameTemplate checks that template only uses known
+// placeholders.
+func ValidateFilenameTemplate(template string) error {
+	for _, m := range filenamePlaceholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := filenameFields[m[1]]; !ok {
+			return fmt.Errorf("unknown filename placeholder %s (expected one of {id}, {title}, {year}, {primary_category})", m[0])
+		}
+	}
+	if !filenamePlaceholder.MatchString(template) {
+		return fmt.Errorf("filename template %q has no placeholders, so every paper would get the same name", template)
+	}
+	return nil
+}
+
+// FormatFilename expands the placeholders in template for paper and returns
+// the result run through sanitizeFilename, without an extension. An empty
+// template means DefaultFilenameTemplate.
+func FormatFilename(template string, paper ArxivPaper) string {
+	if template == "" {
+		template = DefaultFilenameTemplate
+	}
+	expanded := filenamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if field, ok := filenameFields[strings.Trim(placeholder, "{}")]; ok {
+			return field(paper)
+		}
+		return placeholder
+	})
+	return sanitizeFilename(expanded)
+}
@@ -0,0 +1,75 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatFilename(t *testing.T) {
+	paper := ArxivPaper{
+		ID:              "http://arxiv.org/abs/2401.00001v2",
+		Title:           "Attention: Is It All You Need?",
+		Published:       "2024-01-02T00:00:00Z",
+		PrimaryCategory: "cs.CL",
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "Attention_ Is It All You Need_"},
+		{"{title}", "Attention_ Is It All You Need_"},
+		{"{id}", "2401.00001v2"},
+		{"{year}-{primary_category}-{id}", "2024-cs.CL-2401.00001v2"},
+		{"{id} {title}", "2401.00001v2 Attention_ Is It All You Need_"},
+		{"{year}/{id}", "2024_2401.00001v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := FormatFilename(tt.template, paper); got != tt.want {
+				t.Errorf("FormatFilename(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilenameTemplate(t *testing.T) {
+	for _, template := range []string{"{title}", "{id}", "{year}_{primary_category}_{id}"} {
+		if err := ValidateFilenameTemplate(template); err != nil {
+			t.Errorf("ValidateFilenameTemplate(%q) error = %v", template, err)
+		}
+	}
+	for _, template := range []string{"{author}", "paper", ""} {
+		if err := ValidateFilenameTemplate(template); err == nil {
+			t.Errorf("ValidateFilenameTemplate(%q) succeeded, want error", template)
+		}
+	}
+}
+
+func TestRunFilenameTemplateAvoidsTitleCollisions(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeFeed(2,
+			fakeEntry("2401.00001v1", "Same Title"),
+			fakeEntry("2401.00002v1", "Same Title"),
+		)))
+	}))
+
+	err := Run(testingContext(t), Options{
+		Query:            "all:same",
+		Limit:            2,
+		SaveSummaries:    true,
+		FilenameTemplate: "{id}_{title}",
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, name := range []string{"2401.00001v1_Same Title.txt", "2401.00002v1_Same Title.txt"} {
+		if _, err := os.Stat(filepath.Join(TextDirectory, name)); err != nil {
+			t.Errorf("expected summary %s: %v", name, err)
+		}
+	}
+}
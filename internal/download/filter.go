@@ -0,0 +1,278 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FilterPapers returns the papers for which predicate reports true, in their
+// original order.
+func FilterPapers(papers []ArxivPaper, predicate func(ArxivPaper) bool) []ArxivPaper {
+	var filtered []ArxivPaper
+	for _, paper := range papers {
+		if predicate(paper) {
+			filtered = append(filtered, paper)
+		}
+	}
+	return filtered
+}
+
+// CombineFilters returns a predicate that holds when all of filters do. With
+// no filters it accepts every paper.
+func CombineFilters(filters ...func(ArxivPaper) bool) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		for _, filter := range filters {
+			if !filter(paper) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterByAuthor returns the papers with at least one author whose name
+// contains name, ignoring case.
+func FilterByAuthor(papers []ArxivPaper, name string) []ArxivPaper {
+	return FilterPapers(papers, byAuthor(name))
+}
+
+func byAuthor(name string) func(ArxivPaper) bool {
+	needle := strings.ToLower(name)
+	return func(paper ArxivPaper) bool {
+		for _, author := range paper.Authors {
+			if strings.Contains(strings.ToLower(author), needle) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterByCategory returns the papers listed under category, either as their
+// primary category or as a cross-list, ignoring case.
+func FilterByCategory(papers []ArxivPaper, category string) []ArxivPaper {
+	return FilterPapers(papers, byCategory(category))
+}
+
+func byCategory(category string) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		if strings.EqualFold(paper.PrimaryCategory, category) {
+			return true
+		}
+		for _, c := range paper.Categories {
+			if strings.EqualFold(c, category) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ExcludeCategories returns the papers whose primary category is none of
+// categories, ignoring case. Cross-lists do not count.
+func ExcludeCategories(papers []ArxivPaper, categories []string) []ArxivPaper {
+	return FilterPapers(papers, notInPrimaryCategories(categories))
+}
+
+func notInPrimaryCategories(categories []string) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		for _, category := range categories {
+			if strings.EqualFold(paper.PrimaryCategory, category) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterByDateRange returns the papers whose PublishedTime falls within
+// [from, to]. A zero from or to leaves that side of the range unbounded;
+// papers without a parseable publication date are dropped whenever a bound
+// is set.
+func FilterByDateRange(papers []ArxivPaper, from, to time.Time) []ArxivPaper {
+	return FilterPapers(papers, publishedIn(from, to))
+}
+
+func publishedIn(from, to time.Time) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		return inDateRange(paper.PublishedTime(), from, to)
+	}
+}
+
+func inDateRange(t, from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	if t.IsZero() {
+		return false
+	}
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+// FilterByUpdatedAfter returns the papers whose latest revision (the
+// Updated timestamp) is not before cutoff.
+func FilterByUpdatedAfter(papers []ArxivPaper, cutoff time.Time) []ArxivPaper {
+	return FilterPapers(papers, updatedAfter(cutoff))
+}
+
+func updatedAfter(cutoff time.Time) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		updated := paper.UpdatedTime()
+		return !updated.IsZero() && !updated.Before(cutoff)
+	}
+}
+
+// FilterByReadingLevel returns the papers whose ReadingLevel lies within
+// [minLevel, maxLevel]. A zero bound is open.
+func FilterByReadingLevel(papers []ArxivPaper, minLevel, maxLevel float64) []ArxivPaper {
+	return FilterPapers(papers, readingLevelIn(minLevel, maxLevel))
+}
+
+func readingLevelIn(minLevel, maxLevel float64) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool {
+		if minLevel != 0 && paper.ReadingLevel < minLevel {
+			return false
+		}
+		return maxLevel == 0 || paper.ReadingLevel <= maxLevel
+	}
+}
+
+// requiredFields tell, by metadata field name, whether a paper has the
+// field set.
+var requiredFields = map[string]func(ArxivPaper) bool{
+	"id":               func(p ArxivPaper) bool { return p.ID != "" },
+	"title":            func(p ArxivPaper) bool { return p.Title != "" },
+	"summary":          func(p ArxivPaper) bool { return p.Summary != "" },
+	"authors":          func(p ArxivPaper) bool { return len(p.Authors) > 0 },
+	"published":        func(p ArxivPaper) bool { return p.Published != "" },
+	"updated":          func(p ArxivPaper) bool { return p.Updated != "" },
+	"primary_category": func(p ArxivPaper) bool { return p.PrimaryCategory != "" },
+	"categories":       func(p ArxivPaper) bool { return len(p.Categories) > 0 },
+	"pdf_url":          func(p ArxivPaper) bool { return p.PDFURL != "" },
+	"html_url":         func(p ArxivPaper) bool { return p.HTMLURL != "" },
+	"comment":          func(p ArxivPaper) bool { return p.Comment != nil && *p.Comment != "" },
+	"journal_ref":      func(p ArxivPaper) bool { return p.JournalRef != "" },
+	"doi":              func(p ArxivPaper) bool { return p.DOI != "" },
+}
+
+// ValidateRequiredFields checks that every field can be required.
+func ValidateRequiredFields(fields []string) error {
+	for _, field := range fields {
+		if _, ok := requiredFields[field]; !ok {
+			names := make([]string, 0, len(requiredFields))
+			for name := range requiredFields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown field %q (expected one of %s)", field, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// FilterByRequiredFields returns the papers that have every one of fields
+// set, named as in the JSONL metadata (see ValidateRequiredFields).
+func FilterByRequiredFields(papers []ArxivPaper, fields []string) []ArxivPaper {
+	filters := make([]func(ArxivPaper) bool, len(fields))
+	for i, field := range fields {
+		filters[i] = requiredFields[field]
+	}
+	return FilterPapers(papers, CombineFilters(filters...))
+}
+
+// titleMatches and abstractMatches are the predicates of the --title-match
+// and --abstract-match filters.
+func titleMatches(re *regexp.Regexp) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool { return re.MatchString(paper.Title) }
+}
+
+func abstractMatches(re *regexp.Regexp) func(ArxivPaper) bool {
+	return func(paper ArxivPaper) bool { return re.MatchString(paper.Summary) }
+}
+
+// paperFilter is a client-side filter applied to every fetched page; reason
+// describes the dropped papers in the run report.
+type paperFilter struct {
+	reason string
+	keep   func(ArxivPaper) bool
+}
+
+// filters returns the client-side filters configured in o.
+func (o Options) filters() []paperFilter {
+	var filters []paperFilter
+	if !o.DateFrom.IsZero() || !o.DateTo.IsZero() {
+		filters = append(filters, paperFilter{"published outside the date range", publishedIn(o.DateFrom, o.DateTo)})
+	}
+	if !o.UpdatedAfter.IsZero() {
+		filters = append(filters, paperFilter{"updated before " + o.UpdatedAfter.Format("2006-01-02"), updatedAfter(o.UpdatedAfter)})
+	}
+	if o.Author != "" {
+		filters = append(filters, paperFilter{"not by " + o.Author, byAuthor(o.Author)})
+	}
+	if o.Category != "" {
+		filters = append(filters, paperFilter{"not in " + o.Category, byCategory(o.Category)})
+	}
+	if len(o.ExcludeCategories) > 0 {
+		filters = append(filters, paperFilter{"in excluded categories", notInPrimaryCategories(o.ExcludeCategories)})
+	}
+	if o.MinReadingLevel != 0 || o.MaxReadingLevel != 0 {
+		filters = append(filters, paperFilter{"outside the reading level range", readingLevelIn(o.MinReadingLevel, o.MaxReadingLevel)})
+	}
+	if o.TitleMatch != nil {
+		filters = append(filters, paperFilter{"with titles not matching " + o.TitleMatch.String(), titleMatches(o.TitleMatch)})
+	}
+	if o.AbstractMatch != nil {
+		filters = append(filters, paperFilter{"with abstracts not matching " + o.AbstractMatch.String(), abstractMatches(o.AbstractMatch)})
+	}
+	for _, field := range o.Require {
+		filters = append(filters, paperFilter{"missing " + field, requiredFields[field]})
+	}
+	if o.PaperType != "" {
+		filters = append(filters, paperFilter{"not of type " + o.PaperType, func(paper ArxivPaper) bool {
+			return paper.PaperType == o.PaperType
+		}})
+	}
+	if o.reference != nil {
+		filters = append(filters, o.reference.referenceFilters()...)
+	}
+	return filters
+}
+
+// hasFilters reports whether the options drop papers client-side, in which
+// case a run may need to fetch more than Limit results to fill its quota.
+func (o Options) hasFilters() bool {
+	return o.DeduplicateByTitle || len(o.filters()) > 0
+}
+
+// filterPage applies the configured filters and, when dedupe is set, title
+// deduplication to a fetched page.
+func (o Options) filterPage(papers []ArxivPaper, stats *runStats, dedupe *titleDeduper) []ArxivPaper {
+	stats.fetched += len(papers)
+	papers = o.applyFilters(papers, stats)
+	if dedupe != nil {
+		before := len(papers)
+		papers = dedupe.filter(papers)
+		stats.drop("duplicate titles", before-len(papers))
+	}
+	return papers
+}
+
+// applyFilters drops the papers rejected by the configured filters,
+// counting them in stats.
+func (o Options) applyFilters(papers []ArxivPaper, stats *runStats) []ArxivPaper {
+	for _, filter := range o.filters() {
+		before := len(papers)
+		papers = FilterPapers(papers, filter.keep)
+		stats.drop(filter.reason, before-len(papers))
+	}
+	return papers
+}
@@ -0,0 +1,402 @@
+package download
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterByDateRange(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "dec", Published: "2023-12-31T23:59:59Z"},
+		{ID: "jan", Published: "2024-01-01T00:00:00Z"},
+		{ID: "mar", Published: "2024-03-31T12:00:00Z"},
+		{ID: "apr", Published: "2024-04-01T00:00:00Z"},
+		{ID: "bad", Published: "not a date"},
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		expected string
+	}{
+		{"both bounds", from, to, "jan,mar"},
+		{"lower bound only", from, time.Time{}, "jan,mar,apr"},
+		{"upper bound only", time.Time{}, to, "dec,jan,mar"},
+		{"no bounds", time.Time{}, time.Time{}, "dec,jan,mar,apr,bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, paper := range FilterByDateRange(papers, tt.from, tt.to) {
+				ids = append(ids, paper.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.expected {
+				t.Errorf("FilterByDateRange() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterByAuthor(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "a", Authors: []string{"Jane Doe", "Richard Roe"}},
+		{ID: "b", Authors: []string{"John Smith"}},
+		{ID: "c", Authors: []string{"JANE DOE-SMITH"}},
+		{ID: "d"},
+	}
+
+	var ids []string
+	for _, paper := range FilterByAuthor(papers, "jane doe") {
+		ids = append(ids, paper.ID)
+	}
+	if got := strings.Join(ids, ","); got != "a,c" {
+		t.Errorf("FilterByAuthor() = %s, want a,c", got)
+	}
+}
+
+func TestFilterByRequiredFields(t *testing.T) {
+	comment := "12 pages"
+	empty := ""
+	papers := []ArxivPaper{
+		{ID: "a", DOI: "10.1000/a", PDFURL: "http://arxiv.org/pdf/a", Comment: &comment},
+		{ID: "b", PDFURL: "http://arxiv.org/pdf/b"},
+		{ID: "c", DOI: "10.1000/c"},
+		{ID: "d", DOI: "10.1000/d", PDFURL: "http://arxiv.org/pdf/d", Comment: &empty},
+	}
+
+	tests := []struct {
+		fields []string
+		want   string
+	}{
+		{nil, "a,b,c,d"},
+		{[]string{"doi"}, "a,c,d"},
+		{[]string{"pdf_url"}, "a,b,d"},
+		{[]string{"doi", "pdf_url"}, "a,d"},
+		{[]string{"doi", "pdf_url", "comment"}, "a"},
+		{[]string{"journal_ref"}, ""},
+	}
+	for _, tt := range tests {
+		var ids []string
+		for _, paper := range FilterByRequiredFields(papers, tt.fields) {
+			ids = append(ids, paper.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("FilterByRequiredFields(%q) = %s, want %s", tt.fields, got, tt.want)
+		}
+	}
+
+	if err := ValidateRequiredFields([]string{"doi", "pdf_url"}); err != nil {
+		t.Errorf("ValidateRequiredFields() error = %v", err)
+	}
+	if err := ValidateRequiredFields([]string{"isbn"}); err == nil {
+		t.Error("ValidateRequiredFields(isbn) succeeded")
+	}
+}
+
+func TestRunReportsMissingRequiredFields(t *testing.T) {
+	chdirTemp(t)
+
+	handler := pagedFeedHandler(3)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		// Only the first paper has a DOI.
+		body := strings.Replace(rec.Body.String(), "</title>", "</title>\n    <arxiv:doi>10.1000/xyz</arxiv:doi>", 1)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, Require: []string{"doi", "pdf_url"}, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := "(2 missing doi)"; !strings.Contains(out.String(), want) {
+		t.Errorf("report = %q, want it to contain %q", out.String(), want)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 1)
+	if !strings.Contains(string(content), `"doi":"10.1000/xyz"`) {
+		t.Errorf("kept the wrong paper:\n%s", content)
+	}
+}
+
+func TestFilterByCategory(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "primary", PrimaryCategory: "cs.LG", Categories: []string{"cs.LG"}},
+		{ID: "cross", PrimaryCategory: "stat.ML", Categories: []string{"stat.ML", "cs.LG"}},
+		{ID: "other", PrimaryCategory: "cs.CL", Categories: []string{"cs.CL", "cs.LGX"}},
+		{ID: "none"},
+	}
+
+	var ids []string
+	for _, paper := range FilterByCategory(papers, "CS.lg") {
+		ids = append(ids, paper.ID)
+	}
+	if got := strings.Join(ids, ","); got != "primary,cross" {
+		t.Errorf("FilterByCategory() = %s, want primary,cross", got)
+	}
+}
+
+func TestExcludeCategories(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "cl", PrimaryCategory: "cs.CL"},
+		{ID: "bio", PrimaryCategory: "q-bio.NC", Categories: []string{"q-bio.NC", "cs.CL"}},
+		{ID: "lg", PrimaryCategory: "cs.LG", Categories: []string{"cs.LG", "q-bio.NC"}},
+		{ID: "stat", PrimaryCategory: "stat.ML"},
+	}
+
+	var ids []string
+	for _, paper := range ExcludeCategories(papers, []string{"Q-BIO.NC", "stat.ML"}) {
+		ids = append(ids, paper.ID)
+	}
+	if got := strings.Join(ids, ","); got != "cl,lg" {
+		t.Errorf("ExcludeCategories() = %s, want cl,lg", got)
+	}
+}
+
+func TestRunExcludeCategoryFillsLimit(t *testing.T) {
+	chdirTemp(t)
+
+	handler := pagedFeedHandler(40)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		// Every even-numbered paper is primarily q-bio.
+		body := rec.Body.String()
+		var out strings.Builder
+		for i, part := range strings.Split(body, "<entry>") {
+			if i > 0 {
+				out.WriteString("<entry>")
+				if i%2 == 1 {
+					part = strings.Replace(part, `term="cs.CL"`, `term="q-bio.NC"`, 1)
+				}
+			}
+			out.WriteString(part)
+		}
+		_, _ = w.Write([]byte(out.String()))
+	}))
+
+	var report strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:             "cat:cs.CL",
+		Limit:             15,
+		SaveMetadata:      true,
+		ExcludeCategories: []string{"q-bio.NC"},
+		Out:               &report,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 15)
+	if strings.Contains(string(content), `"primary_category":"q-bio.NC"`) {
+		t.Errorf("excluded category was kept")
+	}
+	if want := "filtered out 15 of 30 fetched papers (15 in excluded categories)"; !strings.Contains(report.String(), want) {
+		t.Errorf("report = %q, want it to contain %q", report.String(), want)
+	}
+}
+
+func TestFilterByUpdatedAfter(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "old", Updated: "2023-12-31T23:59:59Z"},
+		{ID: "edge", Updated: "2024-01-01T00:00:00Z"},
+		{ID: "new", Updated: "2024-05-01T10:00:00Z"},
+		{ID: "missing"},
+	}
+
+	var ids []string
+	for _, paper := range FilterByUpdatedAfter(papers, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		ids = append(ids, paper.ID)
+	}
+	if got := strings.Join(ids, ","); got != "edge,new" {
+		t.Errorf("FilterByUpdatedAfter() = %s, want edge,new", got)
+	}
+}
+
+func TestFilterByReadingLevel(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "easy", ReadingLevel: 8.5},
+		{ID: "medium", ReadingLevel: 12},
+		{ID: "hard", ReadingLevel: 19.25},
+	}
+
+	tests := []struct {
+		name     string
+		min, max float64
+		want     string
+	}{
+		{"no bounds", 0, 0, "easy,medium,hard"},
+		{"max only", 0, 12, "easy,medium"},
+		{"min only", 12, 0, "medium,hard"},
+		{"both", 9, 15, "medium"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, paper := range FilterByReadingLevel(papers, tt.min, tt.max) {
+				ids = append(ids, paper.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("FilterByReadingLevel(%v, %v) = %s, want %s", tt.min, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsFilteredCount(t *testing.T) {
+	chdirTemp(t)
+
+	handler := pagedFeedHandler(4)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		// The first two papers were last revised in 2023.
+		body := strings.Replace(rec.Body.String(), "2024-01-02", "2023-01-02", 2)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:        "cat:cs.CL",
+		Limit:        2,
+		SaveMetadata: true,
+		UpdatedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Out:          &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := "filtered out 2 of 4 fetched papers (2 updated before 2024-01-01)"; !strings.Contains(out.String(), want) {
+		t.Errorf("report = %q, want it to contain %q", out.String(), want)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 2)
+}
+
+func TestRunOverfetchesToFillLimit(t *testing.T) {
+	chdirTemp(t)
+
+	// The first page of papers was published in 2023, so only the second
+	// page survives a 2024 lower bound.
+	handler := pagedFeedHandler(40)
+	var starts []string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := r.URL.Query().Get("start")
+		starts = append(starts, start)
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		body := rec.Body.String()
+		if start == "0" {
+			body = strings.ReplaceAll(body, "2024-01-01", "2023-06-01")
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+
+	err := Run(testingContext(t), Options{
+		Query:        "cat:cs.CL",
+		Limit:        3,
+		SaveMetadata: true,
+		DateFrom:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Out:          io.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 3)
+	if strings.Join(starts, ",") != "0,20" {
+		t.Errorf("requested start offsets %v, want [0 20]", starts)
+	}
+	if strings.Contains(string(content), "2023-06-01") {
+		t.Error("metadata should not contain papers outside the date range")
+	}
+}
+
+func TestRunTitleAndAbstractMatch(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(40))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:         "cat:cs.CL",
+		Limit:         3,
+		SaveMetadata:  true,
+		TitleMatch:    regexp.MustCompile(`(?i)^paper (0|25|31)$`),
+		AbstractMatch: regexp.MustCompile(`(?i)SUMMARY OF`),
+		Out:           &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 3)
+	// Papers 25 and 31 are only on the second page.
+	for _, title := range []string{`"Paper 0"`, `"Paper 25"`, `"Paper 31"`} {
+		if !strings.Contains(string(content), title) {
+			t.Errorf("metadata lacks %s:\n%s", title, content)
+		}
+	}
+	if !strings.Contains(out.String(), "with titles not matching") {
+		t.Errorf("report = %q, want the title filter counted", out.String())
+	}
+}
+
+func TestFilterPapersAndCombineFilters(t *testing.T) {
+	papers := []ArxivPaper{
+		{ID: "a", PrimaryCategory: "cs.CL", ReadingLevel: 10},
+		{ID: "b", PrimaryCategory: "cs.CL", ReadingLevel: 18},
+		{ID: "c", PrimaryCategory: "cs.LG", ReadingLevel: 9},
+	}
+	isCL := func(p ArxivPaper) bool { return p.PrimaryCategory == "cs.CL" }
+	readable := func(p ArxivPaper) bool { return p.ReadingLevel < 12 }
+
+	tests := []struct {
+		name      string
+		predicate func(ArxivPaper) bool
+		want      string
+	}{
+		{"single", isCL, "a,b"},
+		{"combined", CombineFilters(isCL, readable), "a"},
+		{"empty combination", CombineFilters(), "a,b,c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, paper := range FilterPapers(papers, tt.predicate) {
+				ids = append(ids, paper.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("FilterPapers() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultFindResults is how many papers Find shows by default.
+const DefaultFindResults = 10
+
+// findCandidates is how many search results Find ranks; the API orders
+// them by relevance, which only breaks ties between equally good matches.
+const findCandidates = 50
+
+// TitleMatchQuality grades how well a title matches a half-remembered one;
+// lower is better.
+type TitleMatchQuality int
+
+const (
+	MatchExact     TitleMatchQuality = iota // same title once normalized
+	MatchPrefix                             // the title starts with the query
+	MatchAllWords                           // every query word is in the title
+	MatchRelevance                          // found by the search only
+)
+
+// MatchTitle grades title against query. Both are compared as lowercase
+// words, ignoring punctuation; the last query word may be cut short for a
+// prefix match, as in "attention is al".
+func MatchTitle(query, title string) TitleMatchQuality {
+	q, t := titleWords(query), titleWords(title)
+	if len(q) == 0 {
+		return MatchRelevance
+	}
+	if strings.Join(q, " ") == strings.Join(t, " ") {
+		return MatchExact
+	}
+	if len(q) <= len(t) {
+		prefix := true
+		for i, word := range q {
+			if word != t[i] && (i < len(q)-1 || !strings.HasPrefix(t[i], word)) {
+				prefix = false
+				break
+			}
+		}
+		if prefix {
+			return MatchPrefix
+		}
+	}
+	present := map[string]bool{}
+	for _, word := range t {
+		present[word] = true
+	}
+	for _, word := range q {
+		if !present[word] {
+			return MatchRelevance
+		}
+	}
+	return MatchAllWords
+}
+
+// RankByTitle orders papers by how well their titles match query (see
+// MatchTitle), keeping the original order among equal matches.
+func RankByTitle(papers []ArxivPaper, query string) {
+	quality := make(map[string]TitleMatchQuality, len(papers))
+	for _, paper := range papers {
+		quality[paper.Title] = MatchTitle(query, paper.Title)
+	}
+	sort.SliceStable(papers, func(i, j int) bool {
+		return quality[papers[i].Title] < quality[papers[j].Title]
+	})
+}
+
+// titleWords splits s into lowercase words of letters and digits.
+func titleWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// titleQuery searches for titles containing every word of title.
+func titleQuery(title string) string {
+	words := titleWords(title)
+	for i, word := range words {
+		words[i] = "ti:" + word
+	}
+	return strings.Join(words, " AND ")
+}
+
+// FindOptions configures Find.
+type FindOptions struct {
+	Limit int       // papers to show; DefaultFindResults if zero
+	JSON  bool      // print a JSON array instead of a table
+	Out   io.Writer // defaults to os.Stdout
+}
+
+// Find searches arXiv for papers whose titles contain the words of title,
+// ranks them with RankByTitle and prints the best ones.
+func Find(ctx context.Context, title string, opts FindOptions) error {
+	if len(titleWords(title)) == 0 {
+		return fmt.Errorf("title has no words to search for")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultFindResults
+	}
+
+	page, err := fetchArxivPapersSorted(ctx, titleQuery(title), 0, max(limit, findCandidates), "relevance")
+	if err != nil {
+		return fmt.Errorf("failed to search titles: %w", err)
+	}
+	papers := page.Papers
+	RankByTitle(papers, title)
+	papers = papers[:min(limit, len(papers))]
+
+	out := Options{Out: opts.Out}.out()
+	if opts.JSON {
+		if papers == nil {
+			papers = []ArxivPaper{}
+		}
+		data, err := json.MarshalIndent(papers, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal papers: %w", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+
+	table := newPaperTable(Options{Out: out})
+	for _, paper := range papers {
+		table.row(paper)
+	}
+	return table.flush()
+}
+
+// Search returns the newest limit papers matching query, as a run would
+// fetch them, without saving anything.
+func Search(ctx context.Context, query string, limit int) ([]ArxivPaper, error) {
+	page, err := fetchArxivPapers(ctx, query, 0, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch papers: %w", err)
+	}
+	return page.Papers, nil
+}
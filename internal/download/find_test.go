@@ -0,0 +1,103 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchTitle(t *testing.T) {
+	tests := []struct {
+		query string
+		title string
+		want  TitleMatchQuality
+	}{
+		{"attention is all you need", "Attention Is All You Need", MatchExact},
+		{"Attention is all you need!", "Attention Is All You Need", MatchExact},
+		{"attention is all", "Attention Is All You Need", MatchPrefix},
+		{"attention is al", "Attention Is All You Need", MatchPrefix},
+		{"attention is all you need", "Attention Is All You Need In Speech Separation", MatchPrefix},
+		{"attention is all", "Is Attention All You Need?", MatchAllWords},
+		{"attention is all you need", "Attention Is Not All You Need", MatchAllWords},
+		{"attention is al", "Is Attention All You Need?", MatchRelevance},
+		{"attention is all you need", "Attention Is Not All You Need Is Wrong", MatchAllWords},
+		{"attention is all you need", "Self-Attention Does Not Need Everything", MatchRelevance},
+		{"...", "Attention Is All You Need", MatchRelevance},
+	}
+
+	for _, tt := range tests {
+		if got := MatchTitle(tt.query, tt.title); got != tt.want {
+			t.Errorf("MatchTitle(%q, %q) = %d, want %d", tt.query, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestRankByTitle(t *testing.T) {
+	titles := []string{
+		"Attention Mechanisms: A Survey",
+		"Is Attention All You Need?",
+		"Attention Is All You Need In Speech Separation",
+		"Attention is all you need.",
+		"Attention Is Not All You Need",
+		"Attention Is All You Need for Graphs",
+	}
+	papers := make([]ArxivPaper, len(titles))
+	for i, title := range titles {
+		papers[i] = ArxivPaper{Title: title}
+	}
+
+	RankByTitle(papers, "Attention Is All You Need")
+
+	want := []string{
+		"Attention is all you need.",
+		"Attention Is All You Need In Speech Separation",
+		"Attention Is All You Need for Graphs",
+		"Is Attention All You Need?",
+		"Attention Is Not All You Need",
+		"Attention Mechanisms: A Survey",
+	}
+	for i, paper := range papers {
+		if paper.Title != want[i] {
+			t.Errorf("rank %d = %q, want %q", i, paper.Title, want[i])
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	var query, sortBy string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, sortBy = r.URL.Query().Get("search_query"), r.URL.Query().Get("sortBy")
+		_, _ = fmt.Fprint(w, fakeFeed(3,
+			fakeEntry("2401.00001v1", "Attention Is Not All You Need"),
+			fakeEntry("2401.00002v1", "Attention Is All You Need In Speech Separation"),
+			fakeEntry("1706.03762v7", "Attention Is All You Need"),
+		))
+	}))
+
+	var out bytes.Buffer
+	if err := Find(testingContext(t), "attention is all", FindOptions{Limit: 2, Out: &out}); err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if query != "ti:attention AND ti:is AND ti:all" || sortBy != "relevance" {
+		t.Errorf("searched %q sorted by %q", query, sortBy)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "2401.00002v1") || !strings.HasPrefix(lines[2], "1706.03762v7") {
+		t.Errorf("Find() printed:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := Find(testingContext(t), "attention is all you need", FindOptions{JSON: true, Out: &out}); err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	var papers []ArxivPaper
+	if err := json.Unmarshal(out.Bytes(), &papers); err != nil {
+		t.Fatalf("Find() JSON: %v\n%s", err, out.String())
+	}
+	if len(papers) != 3 || papers[0].ID != "http://arxiv.org/abs/1706.03762v7" {
+		t.Errorf("Find() JSON = %+v", papers)
+	}
+}
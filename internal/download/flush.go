@@ -0,0 +1,60 @@
+package download
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlushPolicy decides when a metadata writer pushes buffered records to disk
+// and fsyncs the file. The zero policy writes every record straight through
+// to the operating system without fsyncing, which is what single runs do.
+// Whatever the policy, Sync and Close flush and fsync everything.
+type FlushPolicy struct {
+	Always   bool          // flush and fsync after every record
+	Papers   int           // flush and fsync once this many records are buffered
+	Interval time.Duration // flush and fsync on the first record this long after the last flush
+}
+
+// ParseFlushPolicy parses "always", a number of papers ("10" or
+// "10 papers") or a duration ("30s").
+func ParseFlushPolicy(value string) (FlushPolicy, error) {
+	value = strings.TrimSpace(value)
+	if value == "always" {
+		return FlushPolicy{Always: true}, nil
+	}
+	count := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(value, "papers"), "paper"))
+	if n, err := strconv.Atoi(count); err == nil {
+		if n <= 0 {
+			return FlushPolicy{}, fmt.Errorf("invalid flush policy %q: the number of papers must be positive", value)
+		}
+		return FlushPolicy{Papers: n}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return FlushPolicy{}, fmt.Errorf("invalid flush policy %q: the interval must be positive", value)
+		}
+		return FlushPolicy{Interval: d}, nil
+	}
+	return FlushPolicy{}, fmt.Errorf("invalid flush policy %q (expected always, a number of papers or a duration like 10s)", value)
+}
+
+// buffered reports whether records are held back between flushes.
+func (p FlushPolicy) buffered() bool {
+	return !p.Always && (p.Papers > 0 || p.Interval > 0)
+}
+
+// due reports whether pending records, the last flushed at lastFlush,
+// should be flushed now.
+func (p FlushPolicy) due(pending int, lastFlush, now time.Time) bool {
+	switch {
+	case p.Always:
+		return true
+	case p.Papers > 0:
+		return pending >= p.Papers
+	case p.Interval > 0:
+		return now.Sub(lastFlush) >= p.Interval
+	}
+	return false
+}
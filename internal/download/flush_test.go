@@ -0,0 +1,126 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlushPolicy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  FlushPolicy
+	}{
+		{"always", FlushPolicy{Always: true}},
+		{"25", FlushPolicy{Papers: 25}},
+		{"10 papers", FlushPolicy{Papers: 10}},
+		{"30s", FlushPolicy{Interval: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFlushPolicy(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFlushPolicy(%q) = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+	}
+	for _, input := range []string{"", "0", "-5s", "sometimes"} {
+		if _, err := ParseFlushPolicy(input); err == nil {
+			t.Errorf("ParseFlushPolicy(%q) succeeded", input)
+		}
+	}
+}
+
+func TestMetadataWriterFlushesEveryNPapers(t *testing.T) {
+	chdirTemp(t)
+	metadata := newMetadataWriter(JSONFile, false)
+	metadata.policy = FlushPolicy{Papers: 2}
+
+	lines := func() int {
+		content, _ := os.ReadFile(JSONFile)
+		return strings.Count(string(content), "\n")
+	}
+	for i, want := range []int{0, 2, 2} {
+		if err := metadata.Write(ArxivPaper{ID: "2401.0000" + string(rune('1'+i))}); err != nil {
+			t.Fatal(err)
+		}
+		if got := lines(); got != want {
+			t.Errorf("after %d writes %d lines are on disk, want %d", i+1, got, want)
+		}
+	}
+	if err := metadata.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := lines(); got != 3 {
+		t.Errorf("after Close %d lines are on disk, want 3", got)
+	}
+}
+
+// TestWatchAuthorsDurableOnShutdown stops a watch between writing a hit's
+// metadata and the policy's next flush, as SIGTERM would, and checks that
+// every paper the saved state reports as seen is in the metadata file.
+func TestWatchAuthorsDurableOnShutdown(t *testing.T) {
+	policies := map[string]FlushPolicy{
+		"write-through": {},
+		"always":        {Always: true},
+		"every 2":       {Papers: 2},
+		"every 10":      {Papers: 10},
+		"hourly":        {Interval: time.Hour},
+	}
+
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			chdirTemp(t)
+			useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(fakeFeed(3,
+					authorEntry("2401.00001v1", "First", "Jane Doe"),
+					authorEntry("2401.00002v1", "Second", "Jane Doe"),
+					authorEntry("2401.00003v1", "Third", "Jane Doe"),
+				)))
+			}))
+
+			ctx, cancel := context.WithCancel(testingContext(t))
+			defer cancel()
+			// Shut down as soon as the first hit is reported.
+			out := writerFunc(func(p []byte) (int, error) {
+				cancel()
+				return len(p), nil
+			})
+
+			err := WatchAuthors(ctx, AuthorWatchOptions{
+				Authors:   []WatchedAuthor{{Name: "Jane Doe"}},
+				Limit:     10,
+				StatePath: AuthorStateFile,
+				Flush:     policy,
+				Out:       out,
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("WatchAuthors() error = %v, want context.Canceled", err)
+			}
+
+			state, err := LoadAuthorWatchState(AuthorStateFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(JSONFile)
+			if err != nil {
+				t.Fatalf("Failed to read metadata: %v", err)
+			}
+			seen := state.Seen["Jane Doe"]
+			if len(seen) != 1 {
+				t.Errorf("state lists %v as seen, want only the reported hit", seen)
+			}
+			for _, id := range seen {
+				if !strings.Contains(string(content), "http://arxiv.org/abs/"+id) {
+					t.Errorf("%s is seen but missing from the metadata:\n%s", id, content)
+				}
+			}
+		})
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,27 @@
+package download
+
+import (
+	"regexp"
+	"strings"
+)
+
+// formulaPattern matches, leftmost first: an escaped dollar sign (so that
+// "\$5" opens no formula), $$display$$, \[display\], \(inline\) and
+// $inline$ math.
+var formulaPattern = regexp.MustCompile(`(?s)\\\$|\$\$(.+?)\$\$|\\\[(.+?)\\\]|\\\((.+?)\\\)|\$([^$]+?)\$`)
+
+// ExtractFormulas returns the LaTeX math in s, without delimiters and in
+// order of appearance: display math written as $$...$$ or \[...\] and inline
+// math written as $...$ or \(...\).
+func ExtractFormulas(s string) []string {
+	var formulas []string
+	for _, match := range formulaPattern.FindAllStringSubmatch(s, -1) {
+		for _, group := range match[1:] {
+			if formula := strings.TrimSpace(group); formula != "" {
+				formulas = append(formulas, formula)
+				break
+			}
+		}
+	}
+	return formulas
+}
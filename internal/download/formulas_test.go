@@ -0,0 +1,48 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractFormulas(t *testing.T) {
+	tests := []struct {
+		summary string
+		want    []string
+	}{
+		{"No math here.", nil},
+		{"We bound $O(n \\log n)$ and $k$-means.", []string{"O(n \\log n)", "k"}},
+		{"Display $$\\sum_i x_i = 1$$ then \\[ E = mc^2 \\] done.", []string{"\\sum_i x_i = 1", "E = mc^2"}},
+		{"Inline \\(\\alpha\\) and\n$\\beta\n+ 1$.", []string{"\\alpha", "\\beta\n+ 1"}},
+		{"It costs \\$5, or $x$.", []string{"x"}},
+		{"An unmatched $ sign.", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractFormulas(tt.summary); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractFormulas(%q) = %q, want %q", tt.summary, got, tt.want)
+		}
+	}
+}
+
+func TestRunExtractsFormulas(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := strings.Replace(fakeEntry("2401.00001v1", "Paper 1"), "Summary of Paper 1.", "We show $P \\neq NP$.", 1)
+		_, _ = w.Write([]byte(fakeFeed(1, entry)))
+	}))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CC", Limit: 1, SaveMetadata: true, ExtractFormulas: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if !strings.Contains(string(content), `"formulas":["P \\neq NP"]`) {
+		t.Errorf("metadata lacks the formula:\n%s", content)
+	}
+}
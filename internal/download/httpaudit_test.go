@@ -0,0 +1,162 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// forbiddenHTTPCalls build or send requests without going through httpDo
+// and so may not carry a cancellable context.
+var forbiddenHTTPCalls = map[string]bool{
+	"NewRequest":            true,
+	"NewRequestWithContext": true,
+	"Get":                   true,
+	"Head":                  true,
+	"Post":                  true,
+	"PostForm":              true,
+	"DefaultClient":         true,
+}
+
+func TestOutboundRequestsGoThroughHelper(t *testing.T) {
+	root := filepath.Join("..", "..")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+		httpName := ""
+		for _, imp := range file.Imports {
+			if imp.Path.Value == `"net/http"` {
+				httpName = "http"
+				if imp.Name != nil {
+					httpName = imp.Name.Name
+				}
+			}
+		}
+		if httpName == "" {
+			return nil
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := sel.X.(*ast.Ident)
+			if !ok || pkg.Name != httpName || !forbiddenHTTPCalls[sel.Sel.Name] {
+				return true
+			}
+			if filepath.Base(path) == "transport.go" && sel.Sel.Name == "NewRequestWithContext" {
+				return true
+			}
+			t.Errorf("%s uses http.%s; send requests with httpGet or httpDo so they carry the caller's context", path, sel.Sel.Name)
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan sources: %v", err)
+	}
+}
+
+// TestOutboundCallsHonorCancellation cancels the context while each kind of
+// external call is waiting on a server that never answers, and checks that
+// the call returns promptly without leaving goroutines behind.
+func TestOutboundCallsHonorCancellation(t *testing.T) {
+	calls := []struct {
+		name string
+		call func(ctx context.Context, serverURL string) error
+	}{
+		{"arXiv API", func(ctx context.Context, serverURL string) error {
+			old := apiBaseURL
+			apiBaseURL = serverURL
+			defer func() { apiBaseURL = old }()
+			_, err := fetchArxivPapers(ctx, "all:x", 0, 1)
+			return err
+		}},
+		{"PDF download", func(ctx context.Context, serverURL string) error {
+			paper := ArxivPaper{PDFURL: serverURL}
+			return paper.FetchPDF(ctx, filepath.Join(t.TempDir(), "paper.pdf"))
+		}},
+		{"e-print source", func(ctx context.Context, serverURL string) error {
+			old := eprintBaseURL
+			eprintBaseURL = serverURL + "/"
+			defer func() { eprintBaseURL = old }()
+			paper := ArxivPaper{ID: "2401.00001v1"}
+			return paper.FetchSource(ctx, filepath.Join(t.TempDir(), "source"))
+		}},
+		{"ORCID", func(ctx context.Context, serverURL string) error {
+			old := orcidAPIBase
+			orcidAPIBase = serverURL
+			defer func() { orcidAPIBase = old }()
+			_, err := AuthorFromORCID(ctx, "0000-0002-1825-0097")
+			return err
+		}},
+		{"CrossRef", func(ctx context.Context, serverURL string) error {
+			old := crossrefAPIBase
+			crossrefAPIBase = serverURL
+			defer func() { crossrefAPIBase = old }()
+			_, err := FindPublishedVersion(ctx, ArxivPaper{Title: "x"})
+			return err
+		}},
+	}
+
+	oldDelay := pageDelay
+	pageDelay = 0
+	defer func() { pageDelay = oldDelay }()
+
+	for _, tc := range calls {
+		t.Run(tc.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+			started := make(chan struct{}, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				started <- struct{}{}
+				<-r.Context().Done()
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- tc.call(ctx, server.URL) }()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request never reached the server")
+			}
+			cancel()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("error = %v, want context.Canceled", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("call did not return within 2s of cancellation")
+			}
+			server.Close()
+		})
+	}
+}
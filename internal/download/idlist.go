@@ -0,0 +1,106 @@
+package download
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// idListBatchSize is the number of IDs requested per id_list query, which
+// keeps request URLs short.
+const idListBatchSize = 100
+
+// ReadIDs reads one arXiv ID per line from r, skipping blank lines and lines
+// starting with #. Besides bare IDs, "arXiv:" prefixes and abs or pdf URLs
+// are accepted; the ID is returned as given, with its version if any.
+func ReadIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, err := ParseID(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// ParseID returns the arXiv ID in s, which may also be an abs or PDF URL or
+// carry an "arXiv:" prefix, with its version if it has one.
+func ParseID(s string) (string, error) {
+	id, version, err := parseArxivID(s)
+	if err != nil {
+		return "", err
+	}
+	return versionedID(id, version), nil
+}
+
+// fetchIDs fetches the papers of opts.IDs in batches of idListBatchSize and
+// hands each batch to handle. With opts.IDQuery each request also carries it
+// as search_query, so that the API only returns the IDs that match it. IDs
+// the API returns no paper for are reported once every batch is fetched;
+// with IDQuery they are recorded in stats as excluded.
+func fetchIDs(ctx context.Context, opts Options, stats *runStats, handle func(papers []ArxivPaper) error) error {
+	parse := parseFeed
+	if opts.AbstractOnly {
+		parse = parseAbstractFeed
+	}
+
+	found := map[string]bool{}
+	for start := 0; start < len(opts.IDs); start += idListBatchSize {
+		batch := opts.IDs[start:min(start+idListBatchSize, len(opts.IDs))]
+		params := url.Values{}
+		params.Set("id_list", strings.Join(batch, ","))
+		if opts.IDQuery != "" {
+			params.Set("search_query", opts.IDQuery)
+		}
+		params.Set("max_results", fmt.Sprintf("%d", len(batch)))
+		page, err := fetchFeed(ctx, params, parse)
+		if err != nil {
+			return fmt.Errorf("failed to fetch papers %s to %s: %w", batch[0], batch[len(batch)-1], err)
+		}
+		for i := range page.Papers {
+			found[BaseID(page.Papers[i].ID)] = true
+			if opts.PaperVersion > 0 {
+				if err := resolveVersion(&page.Papers[i], opts.PaperVersion); err != nil {
+					return err
+				}
+			}
+		}
+		if err := handle(page.Papers); err != nil {
+			return err
+		}
+	}
+
+	var missing []string
+	for _, id := range opts.IDs {
+		if !found[BaseID(id)] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 && opts.IDQuery != "" {
+		// An ID the query leaves out and one arXiv does not have are both
+		// simply absent from the results.
+		stats.excludedIDs = missing
+		opts.printf("%d of %d IDs not returned for %s: %s\n", len(missing), len(opts.IDs), opts.IDQuery, strings.Join(missing, ", "))
+		return nil
+	}
+	if len(missing) > 0 && opts.PaperVersion > 0 {
+		return fmt.Errorf("version %d does not exist for %s", opts.PaperVersion, strings.Join(missing, ", "))
+	}
+	if len(missing) > 0 {
+		opts.printf("no paper found for %s\n", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadIDs(t *testing.T) {
+	input := `# reading list
+2401.00001
+2401.00002v3
+
+arXiv:2401.00003
+https://arxiv.org/abs/2401.00004v2
+http://arxiv.org/pdf/2401.00005.pdf
+hep-th/9901001
+https://arxiv.org/abs/math.GT/0309136v1
+`
+	ids, err := ReadIDs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadIDs() error = %v", err)
+	}
+	want := []string{"2401.00001", "2401.00002v3", "2401.00003", "2401.00004v2", "2401.00005", "hep-th/9901001", "math.GT/0309136v1"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("ReadIDs() = %q, want %q", ids, want)
+	}
+
+	if _, err := ReadIDs(strings.NewReader("2401.00001\nnot an id\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadIDs() error = %v, want one naming line 2", err)
+	}
+}
+
+func TestRunFetchesIDsInBatches(t *testing.T) {
+	var requests []string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("search_query") != "" {
+			t.Errorf("id_list request also searched for %q", r.URL.Query().Get("search_query"))
+		}
+		list := r.URL.Query().Get("id_list")
+		requests = append(requests, list)
+		var entries []string
+		for _, id := range strings.Split(list, ",") {
+			if id != "2401.00007" {
+				entries = append(entries, fakeEntry(id+"v1", "Paper "+id))
+			}
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(len(entries), entries...))
+	}))
+	chdirTemp(t)
+
+	ids := make([]string, idListBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("2401.%05d", i)
+	}
+	var out strings.Builder
+	if err := Run(testingContext(t), Options{IDs: ids, SaveMetadata: true, Out: &out}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(requests) != 2 || !strings.HasPrefix(requests[1], ids[idListBatchSize]) {
+		t.Errorf("made %d requests, want 2 with the last ID in the second", len(requests))
+	}
+	data, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != len(ids)-1 {
+		t.Errorf("saved %d papers, want %d", lines, len(ids)-1)
+	}
+	if !strings.Contains(out.String(), "no paper found for 2401.00007") {
+		t.Errorf("output does not report the missing ID:\n%s", out.String())
+	}
+}
+
+func TestRunFiltersIDsByQuery(t *testing.T) {
+	var requests int
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.URL.Query().Get("search_query"); got != "cat:cs.LG" {
+			t.Errorf("search_query = %q, want cat:cs.LG", got)
+		}
+		// Only the even IDs are in cs.LG.
+		var entries []string
+		for _, id := range strings.Split(r.URL.Query().Get("id_list"), ",") {
+			if id[len(id)-1]%2 == 0 {
+				entries = append(entries, fakeEntry(id+"v1", "Paper "+id))
+			}
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(len(entries), entries...))
+	}))
+	received := make(chan RunSummary, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var summary RunSummary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		received <- summary
+	}))
+	t.Cleanup(webhook.Close)
+	chdirTemp(t)
+
+	var out strings.Builder
+	opts := Options{IDs: []string{"2401.00001", "2401.00002", "2401.00003", "2401.00004"}, IDQuery: "cat:cs.LG", SaveMetadata: true, Webhook: webhook.URL, Out: &out}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+	if want := "2 of 4 IDs not returned for cat:cs.LG: 2401.00001, 2401.00003"; !strings.Contains(out.String(), want) {
+		t.Errorf("output = %q, want it to contain %q", out.String(), want)
+	}
+	summary := <-received
+	if want := []string{"2401.00002", "2401.00004"}; !slices.Equal(summary.IDs, want) {
+		t.Errorf("summary IDs = %v, want %v", summary.IDs, want)
+	}
+	if want := []string{"2401.00001", "2401.00003"}; !slices.Equal(summary.ExcludedIDs, want) {
+		t.Errorf("summary excluded IDs = %v, want %v", summary.ExcludedIDs, want)
+	}
+
+	if err := Run(testingContext(t), Options{Query: "graphs", Limit: 1, IDQuery: "cat:cs.LG"}); err == nil {
+		t.Error("Run() with an ID query but no IDs succeeded")
+	}
+}
+
+func TestRunFetchesOldStyleIDsVerbatim(t *testing.T) {
+	var list string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list = r.URL.Query().Get("id_list")
+		_, _ = fmt.Fprint(w, fakeFeed(2, fakeEntry("hep-th/9901001v2", "Strings"), fakeEntry("math.GT/0309136v1", "Knots")))
+	}))
+	chdirTemp(t)
+
+	ids, err := ReadIDs(strings.NewReader("https://arxiv.org/abs/hep-th/9901001v2\nmath.GT/0309136\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := Run(testingContext(t), Options{IDs: ids, SaveMetadata: true, FilenameTemplate: "{id}", SaveSummaries: true, Out: &out}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if list != "hep-th/9901001v2,math.GT/0309136" {
+		t.Errorf("id_list = %q", list)
+	}
+	if strings.Contains(out.String(), "no paper found") {
+		t.Errorf("old-style IDs reported missing:\n%s", out.String())
+	}
+	for _, name := range []string{"hep-th_9901001v2.txt", "math.GT_0309136v1.txt"} {
+		if _, err := os.Stat(filepath.Join(TextDirectory, name)); err != nil {
+			t.Errorf("summary %s not saved: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionSuffix = regexp.MustCompile(`v(\d+)$`)
+
+// arxivIDPattern matches new-style identifiers (2401.00001, and 0704.0001
+// from before 2015) and old-style ones made of an archive, an optional
+// subject class and a number (hep-th/9901001, math.GT/0309136), optionally
+// versioned.
+var arxivIDPattern = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z-]+(\.[A-Z]{2})?/\d{7})(v\d+)?$`)
+
+// arxivDOIPrefix starts the DataCite DOIs arXiv assigns to every paper,
+// e.g. 10.48550/arXiv.2401.12345; DOI names are case-insensitive.
+const arxivDOIPrefix = "10.48550/arxiv."
+
+// doiPrefixes introduce a DOI name given as a URL or with a "doi:" prefix.
+var doiPrefixes = []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"}
+
+// parseArxivID extracts the identifier from an arXiv ID given by the user,
+// bare or with an "arXiv:" prefix, from an abs or PDF URL, or from an arXiv
+// DOI, bare or as a doi.org URL. Other DOIs are rejected. It returns
+// the ID without its version, which is 0 when none is given. The slash of
+// old-style IDs is kept: "https://arxiv.org/abs/hep-th/9901001v2" yields
+// ("hep-th/9901001", 2).
+func parseArxivID(s string) (string, int, error) {
+	id := strings.TrimSpace(s)
+	if doi, ok := doiName(id); ok {
+		if !hasPrefixFold(doi, arxivDOIPrefix) {
+			return "", 0, fmt.Errorf("%q is not an arXiv DOI (those start with 10.48550/arXiv.)", s)
+		}
+		id = doi[len(arxivDOIPrefix):]
+	}
+	for _, prefix := range []string{"/abs/", "/pdf/"} {
+		if i := strings.Index(id, prefix); i >= 0 {
+			id = id[i+len(prefix):]
+			break
+		}
+	}
+	if hasPrefixFold(id, "arxiv:") {
+		id = id[len("arxiv:"):]
+	}
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "/"), ".pdf")
+	if !arxivIDPattern.MatchString(id) {
+		return "", 0, fmt.Errorf("%q is not an arXiv ID or URL", s)
+	}
+	base, version := splitArxivID(id)
+	return base, version, nil
+}
+
+// doiName returns the DOI name in s, which is either one itself (starting
+// with the "10." directory indicator) or one behind a prefix in
+// doiPrefixes.
+func doiName(s string) (string, bool) {
+	for _, prefix := range doiPrefixes {
+		if hasPrefixFold(s, prefix) {
+			s = s[len(prefix):]
+			break
+		}
+	}
+	return s, strings.HasPrefix(s, "10.") && strings.Contains(s, "/")
+}
+
+// normalizeDOI reduces a DOI given as a doi.org URL or with a "doi:" prefix
+// to its name, lowercased as DOI names are case-insensitive, so that
+// reference managers match it: "https://doi.org/10.1103/PhysRevD.76.013009"
+// yields "10.1103/physrevd.76.013009".
+func normalizeDOI(doi string) string {
+	name, _ := doiName(strings.TrimSpace(doi))
+	return strings.ToLower(name)
+}
+
+// hasPrefixFold reports whether s begins with prefix, ignoring case.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// versionedID appends version to id, unless it is 0.
+func versionedID(id string, version int) string {
+	if version > 0 {
+		return id + "v" + strconv.Itoa(version)
+	}
+	return id
+}
+
+// splitArxivID strips the abs URL prefix from an entry ID and separates the
+// trailing version, e.g. "http://arxiv.org/abs/2310.06825v2" yields
+// ("2310.06825", 2). The version is 0 when the ID carries none.
+func splitArxivID(id string) (string, int) {
+	if i := strings.Index(id, "/abs/"); i >= 0 {
+		id = id[i+len("/abs/"):]
+	}
+	m := versionSuffix.FindStringSubmatch(id)
+	if m == nil {
+		return id, 0
+	}
+	version, err := strconv.Atoi(m[1])
+	if err != nil {
+		return id, 0
+	}
+	return strings.TrimSuffix(id, m[0]), version
+}
+
+// BaseID returns the arXiv identifier of an entry ID or abs URL without its
+// version, e.g. "2310.06825" for "http://arxiv.org/abs/2310.06825v2".
+func BaseID(id string) string {
+	base, _ := splitArxivID(id)
+	return base
+}
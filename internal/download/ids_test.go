@@ -0,0 +1,133 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSplitArxivID(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantID      string
+		wantVersion int
+	}{
+		{"http://arxiv.org/abs/2310.06825v2", "2310.06825", 2},
+		{"http://arxiv.org/abs/cs/0112017v1", "cs/0112017", 1},
+		{"2310.06825", "2310.06825", 0},
+	}
+
+	for _, tt := range tests {
+		id, version := splitArxivID(tt.input)
+		if id != tt.wantID || version != tt.wantVersion {
+			t.Errorf("splitArxivID(%q) = (%q, %d), want (%q, %d)", tt.input, id, version, tt.wantID, tt.wantVersion)
+		}
+	}
+}
+
+func TestParseArxivID(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantID      string
+		wantVersion int
+	}{
+		{"2401.00001", "2401.00001", 0},
+		{"2401.00001v3", "2401.00001", 3},
+		{"0704.0001", "0704.0001", 0},
+		{"arXiv:2401.00001v2", "2401.00001", 2},
+		{"https://arxiv.org/abs/2401.00001v2", "2401.00001", 2},
+		{"https://arxiv.org/pdf/2401.00001.pdf", "2401.00001", 0},
+		{"hep-th/9901001", "hep-th/9901001", 0},
+		{"hep-th/9901001v2", "hep-th/9901001", 2},
+		{"math.GT/0309136", "math.GT/0309136", 0},
+		{"http://arxiv.org/abs/math.GT/0309136v1", "math.GT/0309136", 1},
+		{"https://arxiv.org/pdf/cond-mat/0102536v1", "cond-mat/0102536", 1},
+		{" arxiv:hep-th/9901001 ", "hep-th/9901001", 0},
+		{"10.48550/arXiv.2401.12345", "2401.12345", 0},
+		{"10.48550/ARXIV.2401.12345v2", "2401.12345", 2},
+		{"https://doi.org/10.48550/arXiv.2401.12345", "2401.12345", 0},
+		{"doi:10.48550/arxiv.hep-th/9901001v3", "hep-th/9901001", 3},
+		{"10.48550/arXiv.math.GT/0309136", "math.GT/0309136", 0},
+	}
+	for _, tt := range tests {
+		id, version, err := parseArxivID(tt.input)
+		if err != nil || id != tt.wantID || version != tt.wantVersion {
+			t.Errorf("parseArxivID(%q) = (%q, %d, %v), want (%q, %d)", tt.input, id, version, err, tt.wantID, tt.wantVersion)
+		}
+	}
+
+	for _, bad := range []string{"", "2401.001", "hep-th/990100", "HEP-TH/9901001", "math.gt/0309136", "graphrag", "2401.00001v", "10.48550/arXiv.graphrag"} {
+		if id, _, err := parseArxivID(bad); err == nil {
+			t.Errorf("parseArxivID(%q) = %q, want an error", bad, id)
+		}
+	}
+
+	for _, doi := range []string{"10.1145/3292500.3330701", "https://doi.org/10.18653/v1/N19-1423", "doi:10.48550/zenodo.123"} {
+		if _, _, err := parseArxivID(doi); err == nil || !strings.Contains(err.Error(), "not an arXiv DOI") {
+			t.Errorf("parseArxivID(%q) error = %v, want one saying it is not an arXiv DOI", doi, err)
+		}
+	}
+}
+
+func TestOldStyleIDRoundTrip(t *testing.T) {
+	paper := ArxivPaper{ID: "http://arxiv.org/abs/hep-th/9901001v2", Title: "Old"}
+	if got := FormatFilename("{id}", paper); got != "hep-th_9901001v2" {
+		t.Errorf("FormatFilename({id}) = %q, want hep-th_9901001v2", got)
+	}
+	if got := paper.entry().PDF(); got != "https://arxiv.org/pdf/hep-th/9901001" {
+		t.Errorf("PDF URL = %q", got)
+	}
+	if got := versionedID(splitArxivID(paper.ID)); got != "hep-th/9901001v2" {
+		t.Errorf("versioned ID = %q", got)
+	}
+}
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"10.1103/PhysRevD.76.013009", "10.1103/physrevd.76.013009"},
+		{"https://doi.org/10.1103/PhysRevD.76.013009", "10.1103/physrevd.76.013009"},
+		{"http://dx.doi.org/10.1145/3292500.3330701", "10.1145/3292500.3330701"},
+		{"HTTPS://DOI.ORG/10.18653/v1/N19-1423", "10.18653/v1/n19-1423"},
+		{"doi:10.48550/arXiv.2401.12345", "10.48550/arxiv.2401.12345"},
+		{" 10.1000/ABC ", "10.1000/abc"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDOI(tt.input); got != tt.want {
+			t.Errorf("normalizeDOI(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRunNormalizesDOIs(t *testing.T) {
+	entry := strings.Replace(fakeEntry("2401.00001v1", "Paper 1"), "</entry>",
+		"<arxiv:doi>https://doi.org/10.1103/PhysRevD.76.013009</arxiv:doi>\n  </entry>", 1)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeFeed(1, entry)))
+	}))
+
+	for _, tt := range []struct {
+		raw  bool
+		want string
+	}{
+		{false, "10.1103/physrevd.76.013009"},
+		{true, "https://doi.org/10.1103/PhysRevD.76.013009"},
+	} {
+		chdirTemp(t)
+		err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, RawDOIs: tt.raw, Out: &strings.Builder{}})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		data, err := os.ReadFile(JSONFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `"doi":"` + tt.want + `"`; !strings.Contains(string(data), want) {
+			t.Errorf("RawDOIs=%v: metadata %s lacks %s", tt.raw, data, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package download
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IndexFile lists the papers saved by a run as a Markdown reading list (see
+// Options.MarkdownIndex).
+const IndexFile = "index.md"
+
+// markdownEscaper escapes the characters Markdown would read as formatting.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// escapeMarkdown flattens the whitespace of s and escapes it for Markdown.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
+
+// MarkdownIndex returns a Markdown list of papers, one bullet each: the
+// title linking to the abstract page, the authors on the next line and,
+// with abstracts, the abstract as a paragraph indented beneath.
+func MarkdownIndex(papers []ArxivPaper, abstracts bool) string {
+	var b strings.Builder
+	for _, paper := range papers {
+		link := paper.HTMLURL
+		if link == "" {
+			link = paper.ID
+		}
+		fmt.Fprintf(&b, "- [%s](%s)", escapeMarkdown(paper.Title), link)
+		if len(paper.Authors) > 0 {
+			authors := make([]string, len(paper.Authors))
+			for i, author := range paper.Authors {
+				authors[i] = escapeMarkdown(author)
+			}
+			// Two trailing spaces break the line within the bullet.
+			fmt.Fprintf(&b, "  \n  %s", strings.Join(authors, ", "))
+		}
+		b.WriteString("\n")
+		if abstract := escapeMarkdown(paper.Summary); abstracts && abstract != "" {
+			fmt.Fprintf(&b, "\n  %s\n\n", abstract)
+		}
+	}
+	return b.String()
+}
+
+// writeMarkdownIndex writes the MarkdownIndex of papers to IndexFile.
+func writeMarkdownIndex(opts Options, papers []ArxivPaper) error {
+	if err := opts.writeFile(opts.path(IndexFile), []byte(MarkdownIndex(papers, opts.IncludeSummary))); err != nil {
+		return fmt.Errorf("failed to write Markdown index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownIndex(t *testing.T) {
+	papers := []ArxivPaper{
+		{
+			ID:      "http://arxiv.org/abs/2401.00001v1",
+			Title:   "Learning *fast*\n  with [brackets] and_underscores",
+			Authors: []string{"Ada Lovelace", "Alan Turing"},
+			HTMLURL: "http://arxiv.org/abs/2401.00001v1",
+			Summary: "We study\n  x_1 < x_2.",
+		},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "No Authors"},
+	}
+
+	want := "- [Learning \\*fast\\* with \\[brackets\\] and\\_underscores](http://arxiv.org/abs/2401.00001v1)  \n" +
+		"  Ada Lovelace, Alan Turing\n" +
+		"\n  We study x\\_1 \\< x\\_2.\n\n" +
+		"- [No Authors](http://arxiv.org/abs/2401.00002v1)\n"
+	if got := MarkdownIndex(papers, true); got != want {
+		t.Errorf("MarkdownIndex() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := MarkdownIndex(papers, false); strings.Contains(got, "We study") {
+		t.Errorf("MarkdownIndex() without abstracts = %q", got)
+	}
+}
+
+func TestRunMarkdownIndex(t *testing.T) {
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, fakeFeed(2, fakeEntry("2401.00001v1", "First"), fakeEntry("2401.00002v1", "Second")))
+	}))
+	chdirTemp(t)
+
+	if err := Run(testingContext(t), Options{Query: "graphs", Limit: 2, SaveMetadata: true, MarkdownIndex: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	content, err := os.ReadFile(IndexFile)
+	if err != nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+	if got := strings.Count(string(content), "\n- ["); got != 1 || !strings.HasPrefix(string(content), "- [First](") {
+		t.Errorf("index = %q, want a bullet per paper", content)
+	}
+}
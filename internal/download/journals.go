@@ -0,0 +1,86 @@
+package download
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PreprintJournal is the group CountJournals puts papers without a journal
+// reference in.
+const PreprintJournal = "Preprint"
+
+// JournalCount is a venue and the number of papers that appeared in it.
+type JournalCount struct {
+	Journal string
+	Count   int
+}
+
+// journalDetailsPattern matches where the volume, issue, year or pages of a
+// journal reference start.
+var journalDetailsPattern = regexp.MustCompile(`(?i)\(|\bvol(ume)?\b|\bno\.|\bpp\.|\d`)
+
+// JournalName reduces a journal reference to the venue, dropping the volume,
+// year, pages and so on: "Phys. Rev. D 100, 123456 (2019)" becomes
+// "Phys. Rev. D" and "JHEP 05 (2020) 123" becomes "JHEP". A reference that
+// starts with a number is kept whole.
+func JournalName(ref string) string {
+	ref = collapseWhitespace(ref)
+	name := ref
+	if loc := journalDetailsPattern.FindStringIndex(ref); loc != nil {
+		name = ref[:loc[0]]
+	}
+	name = strings.TrimRight(name, " ,;:-")
+	if name == "" {
+		return ref
+	}
+	return name
+}
+
+// journalKey identifies a venue regardless of case and of the spaces and
+// dots in abbreviations, so "Phys.Rev.D" and "Phys. Rev. D" group together.
+func journalKey(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", ".", "").Replace(name))
+}
+
+// CountJournals groups papers by the JournalName of their journal reference,
+// papers without one under PreprintJournal, and returns the groups with the
+// most papers first, ties in alphabetical order. Each group is named as the
+// first of its papers spells it.
+func CountJournals(papers []ArxivPaper) []JournalCount {
+	var counts []JournalCount
+	index := map[string]int{}
+	for _, paper := range papers {
+		name := PreprintJournal
+		if paper.JournalRef != "" {
+			name = JournalName(paper.JournalRef)
+		}
+		key := journalKey(name)
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+			continue
+		}
+		index[key] = len(counts)
+		counts = append(counts, JournalCount{Journal: name, Count: 1})
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return strings.ToLower(counts[i].Journal) < strings.ToLower(counts[j].Journal)
+	})
+	return counts
+}
+
+// reportJournals prints the venues of papers, ranked by CountJournals.
+func reportJournals(opts Options, papers []ArxivPaper) {
+	counts := CountJournals(papers)
+	if len(counts) == 0 {
+		return
+	}
+	opts.printf("top journals:\n")
+	width := len(groupDigits(counts[0].Count))
+	for _, count := range counts {
+		opts.printf("  %*s  %s\n", width, groupDigits(count.Count), count.Journal)
+	}
+}
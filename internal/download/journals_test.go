@@ -0,0 +1,56 @@
+package download
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJournalName(t *testing.T) {
+	tests := []struct {
+		ref, want string
+	}{
+		{"Phys. Rev. D 100, 123456 (2019)", "Phys. Rev. D"},
+		{"Phys.Rev.D100:123456,2019", "Phys.Rev.D"},
+		{"JHEP 05 (2020) 123", "JHEP"},
+		{"Astrophys.J. 875 (2019) L1", "Astrophys.J."},
+		{"Nature  Physics, vol. 12, pp. 1-5", "Nature Physics"},
+		{"Proceedings of ICML 2021", "Proceedings of ICML"},
+		{"Transactions on Machine Learning Research (2023)", "Transactions on Machine Learning Research"},
+		{"2019 IEEE Conference", "2019 IEEE Conference"},
+	}
+	for _, tt := range tests {
+		if got := JournalName(tt.ref); got != tt.want {
+			t.Errorf("JournalName(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestCountJournals(t *testing.T) {
+	papers := []ArxivPaper{
+		{JournalRef: "Phys. Rev. D 100, 123456 (2019)"},
+		{},
+		{JournalRef: "Phys.Rev.D 101 (2020) 1"},
+		{JournalRef: "JHEP 05 (2020) 123"},
+		{JournalRef: "Astrophys.J. 875 (2019) L1"},
+		{},
+		{JournalRef: "phys. rev. d 99, 1 (2019)"},
+	}
+	got := CountJournals(papers)
+	want := []JournalCount{
+		{"Phys. Rev. D", 3},
+		{PreprintJournal, 2},
+		{"Astrophys.J.", 1},
+		{"JHEP", 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountJournals() = %v, want %v", got, want)
+	}
+
+	var out strings.Builder
+	reportJournals(Options{Out: &out}, papers)
+	wantOut := "top journals:\n  3  Phys. Rev. D\n  2  Preprint\n  1  Astrophys.J.\n  1  JHEP\n"
+	if out.String() != wantOut {
+		t.Errorf("reportJournals() printed %q, want %q", out.String(), wantOut)
+	}
+}
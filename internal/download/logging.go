@@ -0,0 +1,32 @@
+package download
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// discardLogger is the logger of a run without Options.Logger.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type loggerKey struct{}
+
+// withLogger returns ctx carrying logger, so that the HTTP helpers, which
+// see only the context, log through the logger of the run.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger carried by ctx, or one that discards
+// everything.
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return discardLogger
+}
+
+// logFileWritten records, at debug level, that the run wrote path.
+func logFileWritten(ctx context.Context, kind, path string) {
+	loggerFrom(ctx).Debug("wrote file", "kind", kind, "path", path)
+}
@@ -0,0 +1,45 @@
+package download
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRunLogs(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, SaveSummaries: true, Logger: logger, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`msg="sending request" method=GET url=`,
+		`msg="received response"`,
+		`msg="fetched paper" id=http://arxiv.org/abs/2401.00001v1 title="Paper 1"`,
+		`msg="wrote file" kind=summary path="texts/Paper 1.txt"`,
+		`msg="wrote file" kind=metadata path=` + JSONFile,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs lack %s:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestRunLogsNothingAboveDebugByDefault(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, Logger: logger, Out: &strings.Builder{}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if logs.Len() > 0 {
+		t.Errorf("a successful run logged at info level:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,288 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// marshalMetadata encodes a paper as a single JSONL record. The summary is
+// only part of the record when includeSummary is set.
+func marshalMetadata(p ArxivPaper, includeSummary bool) ([]byte, error) {
+	if !includeSummary {
+		return json.Marshal(p)
+	}
+	type paperAlias ArxivPaper
+	return json.Marshal(struct {
+		paperAlias
+		Summary string `json:"summary"`
+	}{paperAlias(p), p.Summary})
+}
+
+// ReadMetadata reads back the papers of the JSONL metadata file at path, as
+// written by Run, including the summaries of a run with IncludeSummary.
+// Blank lines are skipped; a line that is not a JSON record fails with its
+// line number.
+func ReadMetadata(path string) ([]ArxivPaper, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var papers []ArxivPaper
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		type paperAlias ArxivPaper
+		var record struct {
+			paperAlias
+			Summary string `json:"summary"`
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		paper := ArxivPaper(record.paperAlias)
+		paper.Summary = record.Summary
+		papers = append(papers, paper)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return papers, nil
+}
+
+// recordEncoders encode one metadata record per format, without the line
+// terminator. Formats missing here are rendered from the paper's
+// format.Entry.
+var recordEncoders = map[format.Format]func(paper ArxivPaper, includeSummary bool) ([]byte, error){
+	format.JSONL: func(paper ArxivPaper, includeSummary bool) ([]byte, error) {
+		line, err := marshalMetadata(paper, includeSummary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		return line, nil
+	},
+	format.CSV: func(paper ArxivPaper, _ bool) ([]byte, error) {
+		return csvLine(paper.ToCSVRow())
+	},
+}
+
+// metadataWriter streams metadata records to a file: JSONL lines, or the
+// records of a bibliography format such as format.BibTeX. The file is only
+// created (and truncated, unless appendMode is set) once the first record is
+// written, so a run that produces no metadata leaves any existing file
+// untouched. When the records reach the disk is up to policy.
+//
+// With atomic set (and neither appendMode nor a flush policy), the records
+// go to a temporary file that only replaces path on Close, so that an export
+// that fails part way (see Abort) never leaves a truncated file behind. A
+// flush policy asks for the records to be on disk as they are written, so it
+// writes to path directly. With out set, the
+// records go to out instead and no file is involved. A non-zero mode is set
+// on the file regardless of the umask.
+type metadataWriter struct {
+	path           string
+	out            io.Writer
+	format         format.Format
+	includeSummary bool
+	mode           os.FileMode
+	appendMode     bool
+	atomic         bool
+	policy         FlushPolicy
+	file           *os.File
+	buf            *bufio.Writer
+	pending        int  // records buffered or written since the last fsync
+	hasRecords     bool // whether the file holds a record to separate the next from
+	lastFlush      time.Time
+}
+
+func newMetadataWriter(path string, includeSummary bool) *metadataWriter {
+	return &metadataWriter{path: path, includeSummary: includeSummary}
+}
+
+// Write appends one record for paper.
+func (w *metadataWriter) Write(paper ArxivPaper) error {
+	var line []byte
+	if encode, ok := recordEncoders[w.format]; ok {
+		var err error
+		if line, err = encode(paper, w.includeSummary); err != nil {
+			return err
+		}
+	} else {
+		record, err := w.format.Render(paper.entry())
+		if err != nil {
+			return err
+		}
+		line = []byte(record)
+	}
+
+	if w.out == nil && w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if w.format == format.CSV && !w.hasRecords {
+		header, err := csvLine(format.CSVHeader)
+		if err != nil {
+			return err
+		}
+		line = append(append(header, '\n'), line...)
+	}
+	if w.format == format.Markdown && w.hasRecords {
+		line = append([]byte(format.MarkdownSeparator), line...)
+	}
+
+	if _, err := w.dest().Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	w.hasRecords = true
+	if w.out != nil {
+		return nil
+	}
+	w.pending++
+
+	if w.policy.due(w.pending, w.lastFlush, time.Now()) {
+		return w.Sync()
+	}
+	return nil
+}
+
+// open creates the metadata file, or opens it for appending. A file that
+// already has content is continued without a second CSV header.
+func (w *metadataWriter) open() error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if w.appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	mode := w.mode
+	if mode == 0 {
+		mode = defaultFileMode
+	}
+	file, err := os.OpenFile(w.filePath(), flags, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	if w.mode != 0 {
+		if err := file.Chmod(w.mode); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to set the mode of the metadata file: %w", err)
+		}
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	w.hasRecords = info.Size() > 0
+	w.file = file
+	w.lastFlush = time.Now()
+	if w.policy.buffered() {
+		w.buf = bufio.NewWriter(file)
+	}
+	return nil
+}
+
+// dest is where records are written: out, or else the metadata file,
+// through the buffer of a buffered flush policy.
+func (w *metadataWriter) dest() io.Writer {
+	switch {
+	case w.out != nil:
+		return w.out
+	case w.buf != nil:
+		return w.buf
+	}
+	return w.file
+}
+
+// csvLine encodes row as a CSV record without the line terminator.
+func csvLine(row []string) ([]byte, error) {
+	var b bytes.Buffer
+	cw := csv.NewWriter(&b)
+	if err := cw.Write(row); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV row: %w", err)
+	}
+	cw.Flush()
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), cw.Error()
+}
+
+// Sync flushes buffered records and fsyncs the file, if one was opened.
+func (w *metadataWriter) Sync() error {
+	if w.file == nil {
+		return nil
+	}
+	if w.buf != nil {
+		if err := w.buf.Flush(); err != nil {
+			return fmt.Errorf("failed to write metadata file: %w", err)
+		}
+	}
+	w.lastFlush = time.Now()
+	if w.pending == 0 {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync metadata file: %w", err)
+	}
+	w.pending = 0
+	return nil
+}
+
+// Close flushes and closes the underlying file, if one was opened; with a
+// flush policy set it fsyncs the file first. An atomic writer then moves its
+// temporary file into place, unless closing it failed.
+func (w *metadataWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	var err error
+	if w.buf != nil || w.policy != (FlushPolicy{}) {
+		err = w.Sync()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.buf = nil, nil
+	if w.filePath() == w.path {
+		return err
+	}
+	if err == nil {
+		err = os.Rename(w.filePath(), w.path)
+	}
+	if err != nil {
+		_ = os.Remove(w.filePath())
+	}
+	return err
+}
+
+// Abort closes the file without keeping what was written to it: an atomic
+// writer removes its temporary file and leaves path as it was.
+func (w *metadataWriter) Abort() {
+	if w.file == nil {
+		return
+	}
+	_ = w.file.Close()
+	w.file, w.buf = nil, nil
+	if w.filePath() != w.path {
+		_ = os.Remove(w.filePath())
+	}
+}
+
+// filePath is the file the records are written to: path itself, or the
+// temporary file of an atomic writer.
+func (w *metadataWriter) filePath() string {
+	if w.atomic && !w.appendMode && w.policy == (FlushPolicy{}) {
+		return w.path + ".tmp"
+	}
+	return w.path
+}
@@ -0,0 +1,70 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadMetadataRoundTrip(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(3))
+	chdirTemp(t)
+
+	comment := "10 pages"
+	written := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00001v2", Title: "Paper 1", Summary: "Abstract.", Authors: []string{"Jane Doe"}, Version: 2, Comment: &comment, Files: map[string]string{FilePDF: "pdfs/Paper 1.pdf"}},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "Paper 2", Summary: "Another abstract.", Categories: []string{"cs.CL"}, Version: 1},
+	}
+	var content strings.Builder
+	for _, paper := range written {
+		line, err := marshalMetadata(paper, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content.Write(line)
+		content.WriteString("\n\n")
+	}
+	if err := os.WriteFile(JSONFile, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	papers, err := ReadMetadata(JSONFile)
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if !reflect.DeepEqual(papers, written) {
+		t.Errorf("ReadMetadata() = %+v, want %+v", papers, written)
+	}
+
+	// What Run writes reads back too, without summaries unless included.
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 3, SaveMetadata: true, Out: &strings.Builder{}}); err != nil {
+		t.Fatal(err)
+	}
+	papers, err = ReadMetadata(JSONFile)
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if len(papers) != 3 || papers[2].Title != "Paper 2" || papers[2].Summary != "" || papers[2].PrimaryCategory != "cs.CL" {
+		t.Errorf("ReadMetadata() = %+v", papers)
+	}
+}
+
+func TestReadMetadataErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), JSONFile)
+	if err := os.WriteFile(path, []byte("{\"id\":\"a\"}\n\n{\"id\":\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadMetadata(path)
+	var syntaxErr *json.SyntaxError
+	if err == nil || !strings.Contains(err.Error(), "line 3") || !errors.As(err, &syntaxErr) {
+		t.Errorf("ReadMetadata() error = %v, want a wrapped syntax error on line 3", err)
+	}
+
+	if _, err := ReadMetadata(filepath.Join(t.TempDir(), "missing.jsonl")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadMetadata() of a missing file error = %v", err)
+	}
+}
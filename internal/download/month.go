@@ -0,0 +1,86 @@
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// monthLayout is the form of a --month value and of the suffix it adds to
+// the metadata file names.
+const monthLayout = "2006-01"
+
+// arxivCutoffHour is the hour, US Eastern time, of arXiv's daily submission
+// deadline: papers submitted later are announced a day later.
+const arxivCutoffHour = 14
+
+// arxivLocation is arXiv's timezone. Systems without timezone data fall
+// back to Eastern Standard Time, which puts the cutoff an hour late in
+// summer.
+var arxivLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		return loc
+	}
+	return time.FixedZone("EST", -5*60*60)
+}()
+
+// ParseMonth parses a --month value such as "2019-03" into the first day of
+// that month, in UTC.
+func ParseMonth(value string) (time.Time, error) {
+	t, err := time.Parse(monthLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q: expected YYYY-MM", value)
+	}
+	return t, nil
+}
+
+// monthWindow returns the submission times, in UTC, of the papers arXiv
+// announced in month: from the daily cutoff on the last day of the month
+// before through the cutoff on the month's last day, excluding to. Papers
+// submitted on the afternoon of February 28 are announced in March, and so
+// count for March. Weekends and holidays, which delay announcements by a day
+// or two, are not accounted for.
+func monthWindow(month time.Time) (from, to time.Time) {
+	first := time.Date(month.Year(), month.Month(), 1, arxivCutoffHour, 0, 0, 0, arxivLocation)
+	// Day 0 of a month is the last day of the month before it.
+	from = first.AddDate(0, 0, -1)
+	to = time.Date(month.Year(), month.Month()+1, 0, arxivCutoffHour, 0, 0, 0, arxivLocation)
+	return from.UTC(), to.UTC()
+}
+
+// monthClause returns the submittedDate range clause of monthWindow, e.g.
+// "submittedDate:[201902281900 TO 201903311759]" for March 2019.
+func monthClause(month time.Time) string {
+	from, to := monthWindow(month)
+	return fmt.Sprintf("submittedDate:[%s TO %s]", from.Format(submittedDateLayout), to.Add(-time.Minute).Format(submittedDateLayout))
+}
+
+// validateMonth rejects options that conflict with opts.Month, which
+// selects the search range itself.
+func (o Options) validateMonth() error {
+	if !o.SubmittedFrom.IsZero() || !o.SubmittedTo.IsZero() {
+		return fmt.Errorf("a month cannot be combined with a submission date range")
+	}
+	if len(o.IDs) > 0 || o.OAI != nil {
+		return fmt.Errorf("a month selects search results and cannot be combined with ID lists or an OAI-PMH harvest")
+	}
+	return nil
+}
+
+// metadataName is the name of the metadata file in format f: o.MetadataFile
+// for JSONL when set, or else f's file name, which with o.Month carries the
+// month: "metadata-2019-03.jsonl".
+func (o Options) metadataName(f format.Format) string {
+	if f == format.JSONL && o.MetadataFile != "" {
+		return o.MetadataFile
+	}
+	name := f.Filename()
+	if o.Month.IsZero() {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + o.Month.Format(monthLayout) + ext
+}
@@ -0,0 +1,95 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonthWindow(t *testing.T) {
+	if arxivLocation.String() != "America/New_York" {
+		t.Skip("no timezone data for America/New_York")
+	}
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		month    string
+		from, to time.Time
+	}{
+		{"2019-03", utc(2019, time.February, 28, 19), utc(2019, time.March, 31, 18)},
+		{"2019-02", utc(2019, time.January, 31, 19), utc(2019, time.February, 28, 19)},
+		{"2020-02", utc(2020, time.January, 31, 19), utc(2020, time.February, 29, 19)},
+		{"2020-03", utc(2020, time.February, 29, 19), utc(2020, time.March, 31, 18)},
+		{"2024-04", utc(2024, time.March, 31, 18), utc(2024, time.April, 30, 18)},
+		{"2023-12", utc(2023, time.November, 30, 19), utc(2023, time.December, 31, 19)},
+		{"2024-01", utc(2023, time.December, 31, 19), utc(2024, time.January, 31, 19)},
+	}
+	for _, tt := range tests {
+		month, err := ParseMonth(tt.month)
+		if err != nil {
+			t.Fatalf("ParseMonth(%q) error = %v", tt.month, err)
+		}
+		from, to := monthWindow(month)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("monthWindow(%s) = %s – %s, want %s – %s", tt.month, from, to, tt.from, tt.to)
+		}
+	}
+
+	month, _ := ParseMonth("2019-03")
+	if got, want := monthClause(month), "submittedDate:[201902281900 TO 201903311759]"; got != want {
+		t.Errorf("monthClause(2019-03) = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"", "2019-3", "2019-13", "March 2019", "2019-03-01"} {
+		if _, err := ParseMonth(bad); err == nil {
+			t.Errorf("ParseMonth(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestRunMonth(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	handler := pagedFeedHandler(5)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("search_query"))
+		mu.Unlock()
+		handler(w, r)
+	}))
+	chdirTemp(t)
+
+	month, _ := ParseMonth("2019-03")
+	err := Run(testingContext(t), Options{Query: "cat:cs.AI", Month: month, PageSize: 2, SaveMetadata: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(queries) != 3 {
+		t.Errorf("made %d requests, want 3 pages of 2", len(queries))
+	}
+	for _, query := range queries {
+		if !strings.HasPrefix(query, "(cat:cs.AI) AND submittedDate:[") {
+			t.Errorf("search_query = %q, want the month's range", query)
+		}
+	}
+	data, err := os.ReadFile("metadata-2019-03.jsonl")
+	if err != nil {
+		t.Fatalf("reading the month's metadata: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 5 {
+		t.Errorf("metadata-2019-03.jsonl has %d lines, want 5", lines)
+	}
+	if _, err := os.Stat(JSONFile); err == nil {
+		t.Errorf("%s was written as well", JSONFile)
+	}
+
+	err = Run(testingContext(t), Options{Query: "cat:cs.AI", Month: month, SubmittedFrom: month, Out: &strings.Builder{}})
+	if err == nil {
+		t.Error("Run() accepted a month together with a submission date range")
+	}
+}
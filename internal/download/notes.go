@@ -0,0 +1,164 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+// NotesFile keeps a workspace's notes on its papers. It is separate from
+// the metadata, so refreshing the metadata keeps the notes, and it is never
+// bundled or sent anywhere; only exports made with Options.IncludeNotes
+// show them.
+const NotesFile = "notes.json"
+
+// Note is a free-text note on a paper, timestamped when it was written.
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// NoteStore holds the notes of a workspace by arXiv ID without version, so
+// that the notes on a paper apply to all its versions.
+type NoteStore struct {
+	Papers map[string][]Note `json:"papers"`
+}
+
+// LoadNotes reads the notes file at path; a missing file holds no notes.
+func LoadNotes(path string) (*NoteStore, error) {
+	store := &NoteStore{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, store); err != nil {
+			return nil, fmt.Errorf("failed to parse notes: %w", err)
+		}
+	}
+	if store.Papers == nil {
+		store.Papers = map[string][]Note{}
+	}
+	return store, nil
+}
+
+// Save writes the notes to path through a temporary file and a rename, so
+// that an interrupted write never loses the notes already there.
+func (s *NoteStore) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notes: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return nil
+}
+
+// noteKey returns the key of the paper id (an ID, URL or entry ID, see
+// ParseID) in the store.
+func noteKey(id string) (string, error) {
+	parsed, err := ParseID(id)
+	if err != nil {
+		return "", err
+	}
+	return BaseID(parsed), nil
+}
+
+// Add appends a note written at t to the notes on the paper id.
+func (s *NoteStore) Add(id, text string, t time.Time) error {
+	key, err := noteKey(id)
+	if err != nil {
+		return err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return fmt.Errorf("a note needs some text")
+	}
+	s.Papers[key] = append(s.Papers[key], Note{Time: t.UTC(), Text: text})
+	return nil
+}
+
+// Notes returns the notes on the paper id, oldest first; an ID that does
+// not parse has none.
+func (s *NoteStore) Notes(id string) []Note {
+	key, err := noteKey(id)
+	if err != nil {
+		return nil
+	}
+	return s.Papers[key]
+}
+
+// IDs lists the papers with notes, in order.
+func (s *NoteStore) IDs() []string {
+	ids := make([]string, 0, len(s.Papers))
+	for id := range s.Papers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Edit replaces the text of the n-th note (from 1) on the paper id,
+// keeping its time.
+func (s *NoteStore) Edit(id string, n int, text string) error {
+	key, i, err := s.find(id, n)
+	if err != nil {
+		return err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return fmt.Errorf("a note needs some text")
+	}
+	s.Papers[key][i].Text = text
+	return nil
+}
+
+// Remove deletes the n-th note (from 1) on the paper id.
+func (s *NoteStore) Remove(id string, n int) error {
+	key, i, err := s.find(id, n)
+	if err != nil {
+		return err
+	}
+	notes := append(s.Papers[key][:i:i], s.Papers[key][i+1:]...)
+	if len(notes) == 0 {
+		delete(s.Papers, key)
+	} else {
+		s.Papers[key] = notes
+	}
+	return nil
+}
+
+// find returns the key of the paper id and the index of its n-th note.
+func (s *NoteStore) find(id string, n int) (string, int, error) {
+	key, err := noteKey(id)
+	if err != nil {
+		return "", 0, err
+	}
+	notes := s.Papers[key]
+	if n < 1 || n > len(notes) {
+		return "", 0, fmt.Errorf("%s has %d note(s), there is no note %d", key, len(notes), n)
+	}
+	return key, n - 1, nil
+}
+
+// formatNotes converts notes for the export of an entry.
+func formatNotes(notes []Note) []format.Note {
+	if len(notes) == 0 {
+		return nil
+	}
+	converted := make([]format.Note, len(notes))
+	for i, note := range notes {
+		converted[i] = format.Note{Time: note.Time, Text: note.Text}
+	}
+	return converted
+}
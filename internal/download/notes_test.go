@@ -0,0 +1,122 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+)
+
+func TestNoteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), NotesFile)
+	store, err := LoadNotes(path)
+	if err != nil {
+		t.Fatalf("LoadNotes() of a missing file error = %v", err)
+	}
+
+	first := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+	// Every form of the ID adds to the notes of the same paper.
+	for i, id := range []string{"2401.12345", "arXiv:2401.12345v2", "https://arxiv.org/abs/2401.12345v1"} {
+		if err := store.Add(id, "note "+string(rune('a'+i)), first.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Add(%q) error = %v", id, err)
+		}
+	}
+	if err := store.Add("hep-th/9901001", "  old-style  ", first); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := store.Add("not an id", "text", first); err == nil {
+		t.Error("Add() accepted an invalid ID")
+	}
+	if err := store.Add("2401.12345", " ", first); err == nil {
+		t.Error("Add() accepted an empty note")
+	}
+
+	if err := store.Edit("2401.12345", 2, "edited"); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if err := store.Remove("2401.12345", 1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := store.Remove("2401.12345", 3); err == nil {
+		t.Error("Remove() of a missing note succeeded")
+	}
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadNotes(path)
+	if err != nil {
+		t.Fatalf("LoadNotes() error = %v", err)
+	}
+	notes := loaded.Notes("http://arxiv.org/abs/2401.12345v3")
+	if len(notes) != 2 || notes[0].Text != "edited" || notes[1].Text != "note c" || !notes[0].Time.Equal(first.Add(time.Hour)) {
+		t.Errorf("Notes() = %+v, want the edited note and note c", notes)
+	}
+	if got := loaded.IDs(); strings.Join(got, ",") != "2401.12345,hep-th/9901001" {
+		t.Errorf("IDs() = %v", got)
+	}
+	if old := loaded.Notes("hep-th/9901001"); len(old) != 1 || old[0].Text != "old-style" {
+		t.Errorf("Notes(hep-th/9901001) = %+v", old)
+	}
+
+	if err := loaded.Remove("hep-th/9901001", 1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got := loaded.IDs(); len(got) != 1 {
+		t.Errorf("IDs() after removing the last note = %v, want the paper gone", got)
+	}
+}
+
+func TestRunIncludeNotes(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(2))
+	chdirTemp(t)
+
+	store, err := LoadNotes(NotesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("2401.00001", "great ablation section", time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(NotesFile); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, OutputFormats: []format.Format{format.JSONL, format.Markdown}, Out: &strings.Builder{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if markdown := read(format.MarkdownFile); strings.Contains(markdown, "ablation") {
+		t.Errorf("Markdown without IncludeNotes shows the notes:\n%s", markdown)
+	}
+
+	opts.IncludeNotes = true
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	markdown := read(format.MarkdownFile)
+	sections := strings.Split(markdown, format.MarkdownSeparator)
+	if len(sections) != 2 || !strings.Contains(sections[1], "**Notes:**\n\n- 2024-01-02 15:04: great ablation section\n") || strings.Contains(sections[0], "Notes") {
+		t.Errorf("Markdown with IncludeNotes =\n%s\nwant the note under paper 1 only", markdown)
+	}
+	if jsonl := read(JSONFile); strings.Contains(jsonl, "ablation") {
+		t.Errorf("JSONL metadata shows the notes: %s", jsonl)
+	}
+
+	opts.OutputFormats = nil
+	if err := Run(testingContext(t), opts); err == nil {
+		t.Error("Run() accepted IncludeNotes without the Markdown format")
+	}
+}
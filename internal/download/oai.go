@@ -0,0 +1,266 @@
+package download
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/text"
+)
+
+// oaiBaseURL is arXiv's OAI-PMH endpoint; tests point it at a local server.
+var oaiBaseURL = "https://oaipmh.arxiv.org/oai"
+
+// oaiMetadataPrefix selects the arXivRaw records, which unlike Dublin Core
+// carry the version history, categories, DOI and journal reference.
+const oaiMetadataPrefix = "arXivRaw"
+
+// oaiMaxRetries bounds how often a harvest waits out a 503 with Retry-After,
+// which arXiv sends to pace OAI-PMH clients.
+const oaiMaxRetries = 3
+
+// OAIHarvest selects the records harvested from arXiv's OAI-PMH interface
+// instead of searching (see Options.OAI).
+type OAIHarvest struct {
+	// Set is an OAI-PMH set such as "cs" or "physics:hep-th"; empty
+	// harvests every set.
+	Set string
+
+	// From and Until keep records created or changed within the range
+	// (whole UTC days); a zero value leaves that side open.
+	From  time.Time
+	Until time.Time
+}
+
+// OAI-PMH XML structures for ListRecords responses with arXivRaw metadata.
+type oaiResponse struct {
+	XMLName     xml.Name `xml:"OAI-PMH"`
+	Error       *oaiError
+	ListRecords struct {
+		Records         []oaiRecord `xml:"record"`
+		ResumptionToken string      `xml:"resumptionToken"`
+	} `xml:"ListRecords"`
+}
+
+type oaiError struct {
+	XMLName xml.Name `xml:"error"`
+	Code    string   `xml:"code,attr"`
+	Message string   `xml:",chardata"`
+}
+
+type oaiRecord struct {
+	Header struct {
+		Status string `xml:"status,attr"`
+	} `xml:"header"`
+	Raw oaiRaw `xml:"metadata>arXivRaw"`
+}
+
+type oaiRaw struct {
+	ID         string       `xml:"id"`
+	Versions   []oaiVersion `xml:"version"`
+	Title      string       `xml:"title"`
+	Authors    string       `xml:"authors"`
+	Categories string       `xml:"categories"`
+	Comments   string       `xml:"comments"`
+	JournalRef string       `xml:"journal-ref"`
+	DOI        string       `xml:"doi"`
+	Abstract   string       `xml:"abstract"`
+}
+
+type oaiVersion struct {
+	Version string `xml:"version,attr"`
+	Date    string `xml:"date"`
+}
+
+// harvestOAI fetches the records selected by opts.OAI with ListRecords and
+// hands them to handle one response at a time, following resumption tokens
+// until the list is complete. Deleted records are skipped.
+func harvestOAI(ctx context.Context, opts Options, handle func(papers []ArxivPaper) error) error {
+	params := url.Values{}
+	params.Set("verb", "ListRecords")
+	params.Set("metadataPrefix", oaiMetadataPrefix)
+	if opts.OAI.Set != "" {
+		params.Set("set", opts.OAI.Set)
+	}
+	if !opts.OAI.From.IsZero() {
+		params.Set("from", opts.OAI.From.UTC().Format("2006-01-02"))
+	}
+	if !opts.OAI.Until.IsZero() {
+		params.Set("until", opts.OAI.Until.UTC().Format("2006-01-02"))
+	}
+
+	for {
+		response, err := fetchOAI(ctx, params)
+		if err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if response.Error.Code == "noRecordsMatch" {
+				return nil
+			}
+			return fmt.Errorf("OAI-PMH error %s: %s", response.Error.Code, strings.TrimSpace(response.Error.Message))
+		}
+
+		papers := make([]ArxivPaper, 0, len(response.ListRecords.Records))
+		for _, record := range response.ListRecords.Records {
+			if record.Header.Status == "deleted" {
+				continue
+			}
+			papers = append(papers, record.Raw.paper())
+		}
+		if err := handle(papers); err != nil {
+			return err
+		}
+
+		token := strings.TrimSpace(response.ListRecords.ResumptionToken)
+		if token == "" {
+			return nil
+		}
+		// A resumption token stands for all the other arguments.
+		params = url.Values{}
+		params.Set("verb", "ListRecords")
+		params.Set("resumptionToken", token)
+	}
+}
+
+// fetchOAI sends one OAI-PMH request within the API rate limit, waiting out
+// up to oaiMaxRetries 503 responses that ask to retry later.
+func fetchOAI(ctx context.Context, params url.Values) (*oaiResponse, error) {
+	rawURL := oaiBaseURL + "?" + params.Encode()
+	for attempt := 0; ; attempt++ {
+		if err := apiLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := httpGet(ctx, rawURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch from arXiv OAI-PMH: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusServiceUnavailable && attempt < oaiMaxRetries {
+			wait, ok := retryAfter(resp.Header.Get("Retry-After"))
+			_ = resp.Body.Close()
+			if !ok {
+				return nil, fmt.Errorf("arXiv OAI-PMH returned HTTP %d", resp.StatusCode)
+			}
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		response, err := decodeOAI(resp)
+		_ = resp.Body.Close()
+		return response, err
+	}
+}
+
+func decodeOAI(resp *http.Response) (*oaiResponse, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arXiv OAI-PMH returned HTTP %d", resp.StatusCode)
+	}
+	var response oaiResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse OAI-PMH response: %w", err)
+	}
+	return &response, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// paper maps an arXivRaw record to the fields the search API provides, so
+// that harvested papers are saved like searched ones. The paper is at its
+// latest version, and dates are those of the first and latest versions.
+func (r oaiRaw) paper() ArxivPaper {
+	id := cleanField(r.ID)
+	versioned := id
+	if len(r.Versions) > 0 {
+		versioned += cleanField(r.Versions[len(r.Versions)-1].Version)
+	}
+	paper := ArxivPaper{
+		ID:         arxivAbsBase + versioned,
+		Title:      collapseWhitespace(r.Title),
+		Summary:    cleanField(r.Abstract),
+		Authors:    splitOAIAuthors(r.Authors),
+		Categories: strings.Fields(r.Categories),
+		PDFURL:     arxivPDFBase + versioned,
+		HTMLURL:    arxivAbsBase + versioned,
+		JournalRef: collapseWhitespace(r.JournalRef),
+		DOI:        cleanField(r.DOI),
+	}
+	paper.setArxivID()
+	if len(paper.Categories) > 0 {
+		paper.PrimaryCategory = paper.Categories[0]
+	}
+	if len(r.Versions) > 0 {
+		paper.Published = oaiDate(r.Versions[0].Date)
+		paper.Updated = oaiDate(r.Versions[len(r.Versions)-1].Date)
+	}
+	if comment := collapseWhitespace(r.Comments); comment != "" {
+		paper.Comment = &comment
+	}
+	paper.PaperType = InferPaperType(paper)
+	paper.ReadingLevel = math.Round(text.FleschKincaidGrade(paper.Summary)*100) / 100
+	return paper
+}
+
+// oaiDate converts a version date such as "Mon, 2 Apr 2007 19:18:42 GMT" to
+// the RFC 3339 form of the search API, keeping it as is if it does not
+// parse.
+func oaiDate(value string) string {
+	value = cleanField(value)
+	t, err := time.Parse("Mon, 2 Jan 2006 15:04:05 MST", value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// splitOAIAuthors splits the author list of an arXivRaw record, such as
+// "A. Smith (MIT, USA), B. Jones and C. Wu", into names, leaving commas
+// inside parenthesized affiliations alone and dropping the affiliations.
+func splitOAIAuthors(authors string) []string {
+	authors = collapseWhitespace(authors)
+	var names []string
+	var name strings.Builder
+	depth := 0
+	flush := func() {
+		for _, part := range strings.Split(name.String(), " and ") {
+			part = strings.TrimPrefix(strings.TrimSpace(part), "and ")
+			if part = strings.TrimSpace(part); part != "" {
+				names = append(names, part)
+			}
+		}
+		name.Reset()
+	}
+	for _, r := range authors {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth > 0:
+		case r == ',':
+			flush()
+		default:
+			name.WriteRune(r)
+		}
+	}
+	flush()
+	for i := range names {
+		names[i] = collapseWhitespace(names[i])
+	}
+	return names
+}
@@ -0,0 +1,154 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oaiRecordXML renders an arXivRaw record with the given versions.
+func oaiRecordXML(id, title string, versions ...string) string {
+	var history strings.Builder
+	for i, version := range versions {
+		fmt.Fprintf(&history, `<version version="%s"><date>Mon, %d Apr 2007 19:18:42 GMT</date></version>`, version, 2+i)
+	}
+	return fmt.Sprintf(`<record>
+  <header><identifier>oai:arXiv.org:%[1]s</identifier><datestamp>2024-01-02</datestamp><setSpec>cs</setSpec></header>
+  <metadata>
+    <arXivRaw xmlns="http://arxiv.org/OAI/arXivRaw/">
+      <id>%[1]s</id>%[3]s
+      <title>%[2]s</title>
+      <authors>A. Smith (MIT, USA), B. Jones and C. Wu</authors>
+      <categories>cs.CL cs.LG</categories>
+      <doi>10.1000/%[1]s</doi>
+      <abstract>  The abstract of %[2]s.  </abstract>
+    </arXivRaw>
+  </metadata>
+</record>`, id, title, history.String())
+}
+
+func oaiPage(token string, records ...string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">
+  <ListRecords>%s
+    <resumptionToken cursor="0" completeListSize="3">%s</resumptionToken>
+  </ListRecords>
+</OAI-PMH>`, strings.Join(records, "\n"), token)
+}
+
+// useFakeOAI points the harvester at handler, without the pause between
+// requests.
+func useFakeOAI(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	oldBase, oldDelay := oaiBaseURL, pageDelay
+	oaiBaseURL, pageDelay = server.URL, 0
+	t.Cleanup(func() {
+		oaiBaseURL, pageDelay = oldBase, oldDelay
+		server.Close()
+	})
+}
+
+func TestRunHarvestsOAIAcrossResumptionTokens(t *testing.T) {
+	var requests []url.Values
+	useFakeOAI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query())
+		switch r.URL.Query().Get("resumptionToken") {
+		case "":
+			_, _ = fmt.Fprint(w, oaiPage("page-2", oaiRecordXML("2401.00001", "First", "v1", "v2"), oaiRecordXML("2401.00002", "Second", "v1")))
+		case "page-2":
+			deleted := `<record><header status="deleted"><identifier>oai:arXiv.org:2401.00004</identifier></header></record>`
+			_, _ = fmt.Fprint(w, oaiPage("", oaiRecordXML("hep-th/9901001", "Third", "v1"), deleted))
+		default:
+			http.Error(w, "bad token", http.StatusBadRequest)
+		}
+	}))
+	chdirTemp(t)
+
+	harvest := &OAIHarvest{Set: "cs", From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+	err := Run(testingContext(t), Options{OAI: harvest, Limit: 1, SaveMetadata: true, IncludeSummary: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want 2", len(requests))
+	}
+	first := requests[0]
+	for name, want := range map[string]string{"verb": "ListRecords", "metadataPrefix": "arXivRaw", "set": "cs", "from": "2024-01-01", "until": "2024-01-31"} {
+		if got := first.Get(name); got != want {
+			t.Errorf("first request %s = %q, want %q", name, got, want)
+		}
+	}
+	if second := requests[1]; len(second) != 2 || second.Get("verb") != "ListRecords" {
+		t.Errorf("second request = %v, want only the verb and the resumption token", second)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertValidJSONL(t, string(content), 3)
+	for _, want := range []string{
+		`"id":"http://arxiv.org/abs/2401.00001v2"`,
+		`"published":"2007-04-02T19:18:42Z","title":"First"`,
+		`"authors":["A. Smith","B. Jones","C. Wu"],"primary_category":"cs.CL","categories":["cs.CL","cs.LG"]`,
+		`"pdf_url":"http://arxiv.org/pdf/2401.00001v2"`,
+		`"id":"http://arxiv.org/abs/hep-th/9901001v1"`,
+		`"summary":"The abstract of Third."`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("metadata lacks %s:\n%s", want, content)
+		}
+	}
+}
+
+func TestHarvestOAI(t *testing.T) {
+	tests := map[string]struct {
+		body    string
+		wantErr string
+	}{
+		"no records match": {body: `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><error code="noRecordsMatch">none</error></OAI-PMH>`},
+		"other error":      {body: `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><error code="badArgument">bad from</error></OAI-PMH>`, wantErr: "OAI-PMH error badArgument: bad from"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			useFakeOAI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			err := harvestOAI(testingContext(t), Options{OAI: &OAIHarvest{}}, func(papers []ArxivPaper) error {
+				t.Errorf("handed on %d papers", len(papers))
+				return nil
+			})
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("harvestOAI() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHarvestOAIWaitsOutRetryAfter(t *testing.T) {
+	calls := 0
+	useFakeOAI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, oaiPage("", oaiRecordXML("2401.00001", "First", "v1")))
+	}))
+	var got int
+	err := harvestOAI(testingContext(t), Options{OAI: &OAIHarvest{}}, func(papers []ArxivPaper) error {
+		got += len(papers)
+		return nil
+	})
+	if err != nil || got != 1 || calls != 2 {
+		t.Errorf("harvestOAI() = %d papers in %d calls, error %v; want 1 paper in 2 calls", got, calls, err)
+	}
+}
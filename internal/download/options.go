@@ -0,0 +1,410 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/format"
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+// Options configures a single run of Run.
+type Options struct {
+	Query          string
+	Limit          int
+	SaveMetadata   bool
+	SavePDFs       bool
+	SaveSummaries  bool
+	SaveSources    bool            // save the e-print source under SourceDirectory
+	IncludeSummary bool            // inline the abstract in the JSONL metadata
+	OutputFormats  []format.Format // metadata formats, format.JSONL if empty
+	All            bool            // page through every result, ignoring Limit
+	PageSize       int             // results per API request in All mode or above Limit
+
+	// RawQuery sends Query to the API as search_query verbatim, only
+	// URL-encoded, without adding the date range clauses of SubmittedFrom,
+	// SubmittedTo or Month, which Run then rejects.
+	RawQuery bool
+
+	// Phrase searches each multi-word query as a phrase rather than as
+	// independent words (see PhraseQuery).
+	Phrase bool
+
+	// FailOnEmpty fails the run with ErrNoResults when a search finds no
+	// papers; either way a warning is logged.
+	FailOnEmpty bool
+
+	// CompareToPrevious keeps only the papers the previous run of the same
+	// search did not return, as recorded in PreviousRunFile, and, when the
+	// run saves files (not with DryRun, PrintURLs and the like), records
+	// this run's papers there for the next one. It needs a search; Run
+	// rejects it with IDs or OAI.
+	CompareToPrevious bool
+
+	// Queries runs several searches in one run, in turn and within the API
+	// rate limit; when set, Query is ignored. Limit applies to each search.
+	// The results are merged by arXiv ID, and each paper records the
+	// searches that found it in its Queries field.
+	Queries []string
+
+	// PerQueryLimit, when set, replaces Limit as the number of papers kept
+	// from each search, and LimitTotal, when set, caps the papers kept from
+	// all of Queries together: the searches run in order until it is
+	// reached. Like Limit, both are ignored with All.
+	PerQueryLimit int
+	LimitTotal    int
+
+	// IDs fetches these arXiv IDs (see ReadIDs) instead of searching; when
+	// set, Query, Queries and Limit are ignored.
+	IDs []string
+
+	// IDQuery, when set, is sent as search_query along with each request
+	// for IDs, such as "cat:cs.LG", so that only the IDs matching it are
+	// returned. The IDs it leaves out are reported at the end of the run and
+	// listed in RunSummary.ExcludedIDs. It requires IDs.
+	IDQuery string
+
+	// OAI, when set, harvests the records it selects from arXiv's OAI-PMH
+	// interface instead of searching (see OAIHarvest); Query, Queries and
+	// Limit are then ignored.
+	OAI *OAIHarvest
+
+	// PaperVersion, when set, fetches this version of each of IDs instead
+	// of the latest. A version arXiv does not have fails the run; the PDF
+	// and abstract URLs of the papers point at the version, which is also
+	// recorded as RequestedVersion.
+	PaperVersion int
+
+	// ResumePagination continues an interrupted All run from the offset
+	// saved in PaginationStateFile, appending to the existing metadata.
+	ResumePagination bool
+
+	// Append adds the run's metadata to the existing files instead of
+	// replacing them, skipping papers whose arXiv ID JSONFile already lists
+	// (see ReadMetadataIDs).
+	Append bool
+
+	// UpdatedAfter keeps only papers whose latest revision is not older
+	// than it; zero disables the filter.
+	UpdatedAfter time.Time
+
+	// AbstractOnly parses only the ID, title, authors and summary of each
+	// entry, which is faster on large feeds (see parseAbstractFeed). Options
+	// that need the other fields are rejected by Run.
+	AbstractOnly bool
+
+	// ExtractFormulas records the LaTeX math of each summary in the
+	// paper's Formulas (see ExtractFormulas).
+	ExtractFormulas bool
+
+	// ExtractAcronyms records the acronyms each summary defines, with their
+	// expansions, in the paper's Acronyms (see ExtractAcronyms).
+	ExtractAcronyms bool
+
+	// RawDOIs keeps each paper's DOI as arXiv gives it. By default the
+	// DOI is normalized to its bare, lowercase name (see normalizeDOI).
+	RawDOIs bool
+
+	// IncludeNotes merges the notes of NotesFile in OutputDir into the
+	// Markdown metadata, under each paper; it requires the Markdown format.
+	// Without it notes stay out of every output.
+	IncludeNotes bool
+
+	// MarkdownIndex writes the papers saved by the run to IndexFile as a
+	// Markdown reading list (see MarkdownIndex), with their abstracts when
+	// IncludeSummary is set.
+	MarkdownIndex bool
+
+	// WordCloudData writes the term frequencies of the run's abstracts to
+	// WordCloudJSONFile and WordCloudTSVFile.
+	WordCloudData bool
+
+	// PDFToText extracts the text of each saved PDF (see ExtractPDFText)
+	// into the text directory, named like the summary with a "-fulltext"
+	// suffix; it requires SavePDFs.
+	PDFToText bool
+
+	// SaveEPUB writes each paper's metadata and abstract as an EPUB 3 book
+	// under EPUBDirectory, for e-readers; it requires SaveSummaries.
+	SaveEPUB bool
+
+	// StoreRawEntry saves each paper's original Atom <entry> under
+	// RawDirectory, named after its arXiv ID.
+	StoreRawEntry bool
+
+	// DublinCore saves a Dublin Core record of each paper (see
+	// format.Entry.DublinCore) under DublinCoreDirectory, named after its
+	// arXiv ID, for library catalogs.
+	DublinCore bool
+
+	// Podcast, when set, generates a podcast script (and optionally audio)
+	// for each paper under PodcastDirectory.
+	Podcast *PodcastOptions
+
+	// MergeAuthors, when set, adds the authors of saved papers to
+	// AuthorsIndexFile, merging name variants under these rules.
+	MergeAuthors *names.Rules
+
+	// Concurrency is the number of papers whose files are downloaded at
+	// once; 0 or 1 downloads them one at a time. Requests to each host are
+	// further capped by SetHostLimits.
+	Concurrency int
+
+	// FindPublishedVersion looks up papers without a journal reference on
+	// CrossRef and records the published version, if any, in the metadata.
+	FindPublishedVersion bool
+
+	// SubmittedFrom and SubmittedTo restrict the search itself to papers
+	// submitted within the range (whole UTC days) by ANDing a submittedDate
+	// clause onto Query; a zero value leaves that side open.
+	SubmittedFrom time.Time
+	SubmittedTo   time.Time
+
+	// Month, the first day of a month, restricts the search to the papers
+	// announced in that month (see monthWindow) and pages through all of
+	// them, as with All. The metadata files are named after it. It cannot
+	// be combined with SubmittedFrom and SubmittedTo.
+	Month time.Time
+
+	// DateFrom and DateTo keep only papers published within the range; a
+	// zero value leaves that side of the range open.
+	DateFrom time.Time
+	DateTo   time.Time
+
+	// Author keeps only papers with an author whose name contains it,
+	// ignoring case (see FilterByAuthor).
+	Author string
+
+	// Category keeps only papers listed under it, as primary category or
+	// cross-list, ignoring case (see FilterByCategory).
+	Category string
+
+	// ExcludeCategories drops papers whose primary category is one of
+	// them, ignoring case (see ExcludeCategories).
+	ExcludeCategories []string
+
+	// TitleMatch and AbstractMatch keep only papers whose title or abstract
+	// matches them; nil disables either.
+	TitleMatch    *regexp.Regexp
+	AbstractMatch *regexp.Regexp
+
+	// Require keeps only papers that have all of these metadata fields set,
+	// such as "doi" or "pdf_url" (see FilterByRequiredFields).
+	Require []string
+
+	// PaperType keeps only papers whose inferred type (see InferPaperType)
+	// matches; empty keeps everything.
+	PaperType string
+
+	// MinReadingLevel and MaxReadingLevel keep only papers whose abstract's
+	// Flesch-Kincaid grade (ReadingLevel) falls within them; zero disables
+	// either bound.
+	MinReadingLevel float64
+	MaxReadingLevel float64
+
+	// ExcludeFrom drops papers already in these references: JSONL metadata
+	// files, BibTeX files or workspaces, matched by arXiv ID, DOI or title
+	// (see LoadReferenceCorpus).
+	ExcludeFrom []string
+
+	// DeduplicateByTitle drops papers whose titles are within TitleDistance
+	// (see DeduplicateByTitle) of another paper in the run. Within a page,
+	// the preferred version is kept; across pages, which are saved as they
+	// arrive, the first seen is.
+	DeduplicateByTitle bool
+	TitleDistance      float64
+
+	// DedupeReport, when set, is the path, relative to OutputDir unless
+	// absolute, of a JSONL file listing each paper dropped as a duplicate
+	// (see DroppedDuplicate): repeats of an ID across Queries, and near
+	// titles with DeduplicateByTitle. Like every file, it is only written
+	// by runs that save files (see DryRun).
+	DedupeReport string
+
+	// DetectDuplicateSubmissions compares the abstracts of the run's papers
+	// once they are fetched and reports pairs more similar than
+	// DuplicateThreshold (see DetectDuplicateSubmissions).
+	DetectDuplicateSubmissions bool
+	DuplicateThreshold         float64
+
+	// TopJournals prints, once the papers are fetched, the venues they
+	// appeared in, ranked by number of papers (see CountJournals).
+	TopJournals bool
+
+	// PrintTree prints, once the run succeeds, the metadata files and the
+	// PDFs and summaries the run wrote as trees with the size of each file
+	// and their total (see term.PrintTree).
+	PrintTree bool
+
+	// SkipExisting leaves PDFs and summaries that are already on disk (and
+	// non-empty) alone instead of downloading them again.
+	SkipExisting bool
+
+	// PrintURLs prints one PDF URL per paper instead of saving anything;
+	// with Aria2 each URL is followed by an "out=" line naming the file, as
+	// expected by aria2c -i.
+	PrintURLs bool
+	Aria2     bool
+
+	// PrintIDs prints the arXiv ID, without version, of each paper one per
+	// line instead of saving anything, for piping into another run's IDs
+	// (see ReadIDs). It cannot be combined with PrintURLs.
+	PrintIDs bool
+
+	// DryRun fetches and parses the results but only prints, for each
+	// paper, what would be downloaded; nothing is written to disk.
+	DryRun bool
+
+	// Table prints the papers as an aligned table instead of saving
+	// anything. AbstractWidth adds an abstract column that many characters
+	// wide, truncated unless WrapAbstract is set.
+	Table         bool
+	AbstractWidth int
+	WrapAbstract  bool
+
+	// Cite, when set, formats a citation that is printed for each paper
+	// instead of saving anything.
+	Cite func(ArxivPaper) (string, error)
+
+	// Webhook, when set, receives a JSON RunSummary once the run ends,
+	// whether or not it succeeded.
+	Webhook string
+
+	// OutputDir is the directory all outputs are written under; empty means
+	// the current directory.
+	OutputDir string
+
+	// MetadataFile, PDFDir and TextDir replace JSONFile, PDFDirectory and
+	// TextDirectory when set. Relative paths are under OutputDir.
+	MetadataFile string
+	PDFDir       string
+	TextDir      string
+
+	// TimestampLayout, when set, nests the outputs of the run one level
+	// below OutputDir, in a directory named after the time the run started
+	// formatted with this time layout (e.g. "2006-01-02_15-04-05"), so
+	// repeated runs never overwrite each other.
+	TimestampLayout string
+
+	// FileMode and DirMode, when set, are the modes of the files and
+	// directories the run creates, set regardless of the umask; unset, they
+	// are created 0644 and 0755 less the umask.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+
+	// FinalizeReadOnly removes the write bits from each PDF, source,
+	// summary, raw entry, Dublin Core record and podcast file once the
+	// paper's metadata has been recorded. A later run that saves the file
+	// again replaces it.
+	FinalizeReadOnly bool
+
+	// FilenameTemplate names saved PDFs and summaries (see FormatFilename);
+	// empty means DefaultFilenameTemplate.
+	FilenameTemplate string
+
+	// MetadataOut, when set, receives the metadata records instead of the
+	// metadata file, in the one format of OutputFormats. Out should then be
+	// another stream, such as os.Stderr, so that messages do not end up
+	// among the records.
+	MetadataOut io.Writer
+
+	// Logger, when set, receives debug records of each request, each paper
+	// fetched and each file written, and warnings about failed requests.
+	Logger *slog.Logger
+
+	// Out receives progress messages; it defaults to os.Stdout.
+	Out io.Writer
+
+	// Progress, when set, receives a progress bar redrawn as each paper is
+	// saved. It should be a terminal.
+	Progress io.Writer
+
+	// bar is the progress bar of the current run, cleared before messages.
+	bar *progress
+
+	// output serializes messages and progress bar updates from papers
+	// saved concurrently.
+	output *sync.Mutex
+
+	// reference is the corpus loaded from ExcludeFrom.
+	reference *ReferenceCorpus
+}
+
+// printf writes a progress message to o.Out.
+func (o Options) printf(format string, args ...any) {
+	if o.output != nil {
+		o.output.Lock()
+		defer o.output.Unlock()
+	}
+	o.bar.clear()
+	_, _ = fmt.Fprintf(o.out(), format, args...)
+}
+
+// advance moves the progress bar on by a saved paper.
+func (o Options) advance(title string) {
+	if o.output != nil {
+		o.output.Lock()
+		defer o.output.Unlock()
+	}
+	o.bar.advance(title)
+}
+
+// out returns o.Out, defaulting to os.Stdout.
+func (o Options) out() io.Writer {
+	if o.Out == nil {
+		return os.Stdout
+	}
+	return o.Out
+}
+
+// writesFiles reports whether the run saves anything to disk.
+func (o Options) writesFiles() bool {
+	return !o.PrintURLs && !o.PrintIDs && !o.DryRun && !o.Table && o.Cite == nil
+}
+
+// formats returns o.OutputFormats without repeats, defaulting to JSONL.
+func (o Options) formats() []format.Format {
+	if len(o.OutputFormats) == 0 {
+		return []format.Format{format.JSONL}
+	}
+	var formats []format.Format
+	for _, f := range o.OutputFormats {
+		if !slices.Contains(formats, f) {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// path resolves an output file or directory name against o.OutputDir.
+// Absolute names are kept as they are.
+func (o Options) path(name string) string {
+	if o.OutputDir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(o.OutputDir, name)
+}
+
+// pdfDir is the directory PDFs are saved to: o.PDFDir or PDFDirectory.
+func (o Options) pdfDir() string {
+	if o.PDFDir != "" {
+		return o.path(o.PDFDir)
+	}
+	return o.path(PDFDirectory)
+}
+
+// textDir is the directory summaries are saved to: o.TextDir or
+// TextDirectory.
+func (o Options) textDir() string {
+	if o.TextDir != "" {
+		return o.path(o.TextDir)
+	}
+	return o.path(TextDirectory)
+}
@@ -0,0 +1,51 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunTimestampedOutputDir(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(1))
+
+	opts := Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, SaveSummaries: true, OutputDir: "runs", TimestampLayout: time.RFC3339, Out: &strings.Builder{}}
+	before := time.Now().UTC().Truncate(time.Second)
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	entries, err := os.ReadDir("runs")
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		t.Fatalf("runs/ holds %v (%v), want one directory", entries, err)
+	}
+	stamp, err := time.Parse(time.RFC3339, entries[0].Name())
+	if err != nil {
+		t.Fatalf("directory %q is not an RFC 3339 timestamp: %v", entries[0].Name(), err)
+	}
+	if stamp.Before(before) || stamp.After(time.Now()) {
+		t.Errorf("timestamp %v is not the time of the run", stamp)
+	}
+	for _, name := range []string{JSONFile, TextDirectory} {
+		if _, err := os.Stat(filepath.Join("runs", entries[0].Name(), name)); err != nil {
+			t.Errorf("%s is not in the timestamped directory: %v", name, err)
+		}
+	}
+}
+
+func TestRunTimestampedOutputDirCustomLayout(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(1))
+
+	opts := Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, TimestampLayout: "20060102-150405", Out: &strings.Builder{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join("[0-9]*-[0-9]*", JSONFile))
+	if len(matches) != 1 || len(filepath.Dir(matches[0])) != len("20060102-150405") {
+		t.Errorf("metadata written to %v, want one directory named like 20060102-150405", matches)
+	}
+}
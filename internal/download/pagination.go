@@ -0,0 +1,234 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// PaginationStateFile records how far an --all run got, so that an
+// interrupted harvest can be resumed.
+const PaginationStateFile = ".arxiv-cli-pagination.json"
+
+// defaultPageSize is the number of results requested per API call when
+// paging through a whole result set.
+const defaultPageSize = 200
+
+// maxOverfetchFactor bounds how many results a filtered run may fetch, as a
+// multiple of the requested limit, while trying to fill its quota.
+const maxOverfetchFactor = 10
+
+// ErrNoResults is returned, with Options.FailOnEmpty, by a run whose search
+// found no papers, which usually means a typo in the query.
+var ErrNoResults = errors.New("the search returned no papers")
+
+// pageDelay is the minimum pause between consecutive API calls, following
+// arXiv's guidance of no more than one request every three seconds.
+var pageDelay = 3 * time.Second
+
+// apiLimiter spaces out every call to the arXiv API made by this process,
+// whether it comes from paging, multiple queries or scheduled runs.
+var apiLimiter = &rateLimiter{}
+
+// rateLimiter lets one caller through per pageDelay.
+type rateLimiter struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// wait blocks until pageDelay has passed since the previous call was let
+// through, or until ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		if err := sleepContext(ctx, time.Until(l.last.Add(pageDelay))); err != nil {
+			return err
+		}
+	}
+	l.last = time.Now()
+	return nil
+}
+
+// fetchPages fetches the results for opts.Query and hands them to handle one
+// page at a time, together with the offset the next page starts at; handle
+// reports whether the run has all the papers it wants. It returns the
+// number of matching papers arXiv reported (opensearch:totalResults).
+//
+// Without opts.All and without client-side filters a single request for
+// opts.Limit papers is made, or, when the limit is above the page size,
+// one request per page until the limit is reached, each page printed as it
+// arrives. When filters may drop papers, further pages are requested until
+// handle is satisfied or maxOverfetchFactor times the limit has been
+// fetched. With opts.All, pages are requested from offset first until
+// totalResults is reached or an empty page comes back.
+//
+// Each page is handed on before the next is requested, so a failure part
+// way through keeps the papers of the pages already handled.
+func fetchPages(ctx context.Context, opts Options, first int, handle func(papers []ArxivPaper, next int) (bool, error)) (int, error) {
+	fetch := fetchArxivPapers
+	if opts.AbstractOnly {
+		fetch = fetchAbstracts
+	}
+	loggerFrom(ctx).Debug("searching", "search_query", opts.searchQuery())
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	chunked := !opts.All && !opts.hasFilters()
+	if chunked && opts.Limit <= pageSize {
+		page, err := fetch(ctx, opts.searchQuery(), 0, opts.Limit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch papers: %w", err)
+		}
+		if len(page.Papers) == 0 {
+			if err := noResults(ctx, opts, page.TotalResults); err != nil {
+				return 0, err
+			}
+		}
+		_, err = handle(page.Papers, len(page.Papers))
+		return page.TotalResults, err
+	}
+
+	fetchCap := 0
+	if chunked {
+		fetchCap = opts.Limit
+	} else if !opts.All {
+		fetchCap = opts.Limit * maxOverfetchFactor
+		pageSize = min(pageSize, max(opts.Limit*2, 20))
+	}
+
+	total := 0
+	for start := first; ; {
+		size := pageSize
+		if chunked {
+			size = min(pageSize, opts.Limit-start)
+		}
+		page, err := fetch(ctx, opts.searchQuery(), start, size)
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch papers starting at %d: %w", start, err)
+		}
+		total = page.TotalResults
+		if len(page.Papers) == 0 {
+			if start == 0 {
+				return total, noResults(ctx, opts, page.TotalResults)
+			}
+			return total, nil
+		}
+		start = page.next(start)
+		if page.ItemsPerPage > 0 && page.ItemsPerPage < size {
+			pageSize = page.ItemsPerPage
+		}
+		if chunked && opts.writesFiles() {
+			opts.printf("fetched %d of %d papers\n", min(start, opts.Limit), opts.Limit)
+		}
+		done, err := handle(page.Papers, start)
+		if err != nil || done {
+			return total, err
+		}
+
+		if page.TotalResults > 0 && start >= page.TotalResults {
+			return total, nil
+		}
+		if fetchCap > 0 && start >= fetchCap {
+			return total, nil
+		}
+	}
+}
+
+// noResults warns that the search of opts found no papers, giving the
+// search_query sent and the totalResults arXiv reported, and returns
+// ErrNoResults with opts.FailOnEmpty.
+func noResults(ctx context.Context, opts Options, totalResults int) error {
+	query := opts.searchQuery()
+	loggerFrom(ctx).Warn("search returned no papers", "search_query", query, "total_results", totalResults)
+	if opts.FailOnEmpty {
+		return fmt.Errorf("%w: search_query %q (totalResults %d)", ErrNoResults, query, totalResults)
+	}
+	return nil
+}
+
+// next returns the offset of the page following p, which was requested at
+// offset requested. A rel="next" link past the start of p wins; otherwise
+// the count of papers actually returned is added to the start index the
+// feed reports, since the API may return fewer results than were asked for.
+func (p *searchPage) next(requested int) int {
+	base := requested
+	if p.StartIndex >= 0 {
+		base = p.StartIndex
+	}
+	following := base + len(p.Papers)
+	if p.NextStart > base {
+		following = p.NextStart
+	}
+	// Never go backwards, which would loop on the same page.
+	return max(following, requested+1)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// paginationState is the content of PaginationStateFile.
+type paginationState struct {
+	Query string `json:"query"`
+	Start int    `json:"start"`
+}
+
+// loadPaginationState returns the offset saved for query, or 0 when there is
+// no state file or it belongs to a different query.
+func loadPaginationState(path, query string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pagination state: %w", err)
+	}
+
+	var state paginationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("failed to parse pagination state: %w", err)
+	}
+	if state.Query != query {
+		return 0, nil
+	}
+	return state.Start, nil
+}
+
+// savePaginationState records in the state file of opts that every result
+// of its search before start has been saved.
+func savePaginationState(opts Options, start int) error {
+	data, err := json.Marshal(paginationState{Query: opts.searchQuery(), Start: start})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pagination state: %w", err)
+	}
+	if err := opts.writeFile(opts.path(PaginationStateFile), data); err != nil {
+		return fmt.Errorf("failed to write pagination state: %w", err)
+	}
+	return nil
+}
+
+// clearPaginationState removes the state file once a harvest completes.
+func clearPaginationState(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear pagination state: %w", err)
+	}
+	return nil
+}
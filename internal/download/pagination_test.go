@@ -0,0 +1,407 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFetchPagesAll(t *testing.T) {
+	var mu sync.Mutex
+	var starts []string
+	handler := pagedFeedHandler(5)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, r.URL.Query().Get("start"))
+		mu.Unlock()
+		handler(w, r)
+	}))
+
+	var got []ArxivPaper
+	_, err := fetchPages(testingContext(t), Options{Query: "cat:cs.DL", All: true, PageSize: 2}, 0, func(papers []ArxivPaper, next int) (bool, error) {
+		got = append(got, papers...)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("fetchPages() error = %v", err)
+	}
+
+	if len(got) != 5 {
+		t.Errorf("fetched %d papers, want 5", len(got))
+	}
+	if strings.Join(starts, ",") != "0,2,4" {
+		t.Errorf("requested start offsets %v, want [0 2 4]", starts)
+	}
+}
+
+func TestFetchPagesFollowsServerPageSize(t *testing.T) {
+	const total, served = 7, 3
+	var mu sync.Mutex
+	var starts, sizes []string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		mu.Lock()
+		starts = append(starts, r.URL.Query().Get("start"))
+		sizes = append(sizes, r.URL.Query().Get("max_results"))
+		mu.Unlock()
+
+		// The server caps every page at 3 results whatever was asked for,
+		// and says so in itemsPerPage.
+		var entries []string
+		for i := start; i < total && i < start+served; i++ {
+			entries = append(entries, fakeEntry(fmt.Sprintf("2401.%05dv1", i), fmt.Sprintf("Paper %d", i)))
+		}
+		feed := fakeFeed(total, entries...)
+		feed = strings.Replace(feed, "<opensearch:totalResults>", fmt.Sprintf("<opensearch:startIndex>%d</opensearch:startIndex>\n  <opensearch:itemsPerPage>%d</opensearch:itemsPerPage>\n  <opensearch:totalResults>", start, served), 1)
+		_, _ = w.Write([]byte(feed))
+	}))
+
+	var ids []string
+	_, err := fetchPages(testingContext(t), Options{Query: "cat:cs.DL", All: true, PageSize: 5}, 0, func(papers []ArxivPaper, next int) (bool, error) {
+		for _, paper := range papers {
+			ids = append(ids, paper.ID)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("fetchPages() error = %v", err)
+	}
+
+	if len(ids) != total {
+		t.Errorf("fetched %d papers, want %d: %v", len(ids), total, ids)
+	}
+	if got := strings.Join(starts, ","); got != "0,3,6" {
+		t.Errorf("requested start offsets %s, want 0,3,6", got)
+	}
+	if got := strings.Join(sizes, ","); got != "5,3,3" {
+		t.Errorf("requested page sizes %s, want 5,3,3", got)
+	}
+}
+
+func TestSearchPageNext(t *testing.T) {
+	tests := []struct {
+		name string
+		page searchPage
+		want int
+	}{
+		{"counts returned papers", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: -1, NextStart: -1}, 12},
+		{"uses reported start index", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: 20, NextStart: -1}, 22},
+		{"prefers next link", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: 10, NextStart: 15}, 15},
+		{"ignores backward next link", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: -1, NextStart: 5}, 12},
+		{"ignores next link to the reported start", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: 20, NextStart: 20}, 22},
+		{"ignores next link before the reported start", searchPage{Papers: make([]ArxivPaper, 2), StartIndex: 20, NextStart: 15}, 22},
+		{"never goes backwards", searchPage{Papers: make([]ArxivPaper, 1), StartIndex: 0, NextStart: -1}, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.page.next(10); got != tt.want {
+				t.Errorf("next(10) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeedPagingFields(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">
+  <link href="http://export.arxiv.org/api/query?search_query=all:llm&amp;start=40&amp;max_results=20" rel="self" type="application/atom+xml"/>
+  <link href="http://export.arxiv.org/api/query?search_query=all:llm&amp;start=60&amp;max_results=20" rel="next" type="application/atom+xml"/>
+  <opensearch:totalResults>100</opensearch:totalResults>
+  <opensearch:itemsPerPage>20</opensearch:itemsPerPage>
+</feed>`
+
+	page, err := parseFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	if page.StartIndex != 40 || page.NextStart != 60 || page.ItemsPerPage != 20 || page.TotalResults != 100 {
+		t.Errorf("parseFeed() = start %d, next %d, items %d, total %d; want 40, 60, 20, 100",
+			page.StartIndex, page.NextStart, page.ItemsPerPage, page.TotalResults)
+	}
+}
+
+func TestFetchPagesStopsOnEmptyPage(t *testing.T) {
+	requests := 0
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("start") == "0" {
+			_, _ = w.Write([]byte(fakeFeed(0, fakeEntry("2401.00001v1", "Only paper"))))
+			return
+		}
+		_, _ = w.Write([]byte(fakeFeed(0)))
+	}))
+
+	count := 0
+	_, err := fetchPages(testingContext(t), Options{Query: "cat:cs.DL", All: true, PageSize: 1}, 0, func(papers []ArxivPaper, next int) (bool, error) {
+		count += len(papers)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("fetchPages() error = %v", err)
+	}
+	if count != 1 || requests != 2 {
+		t.Errorf("got %d papers in %d requests, want 1 paper in 2 requests", count, requests)
+	}
+}
+
+func TestRunAllInterruptedKeepsWrittenPages(t *testing.T) {
+	chdirTemp(t)
+
+	ctx, cancel := context.WithCancel(testingContext(t))
+	defer cancel()
+
+	handler := pagedFeedHandler(10)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") != "0" {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		handler(w, r)
+	}))
+
+	err := Run(ctx, Options{Query: "cat:cs.DL", All: true, PageSize: 3, SaveMetadata: true})
+	if err == nil {
+		t.Fatal("Run() error = nil, want cancellation error")
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 3)
+}
+
+func TestRunChunksLargeLimits(t *testing.T) {
+	chdirTemp(t)
+
+	var mu sync.Mutex
+	var requests []string
+	handler := pagedFeedHandler(100)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Query().Get("start")+"+"+r.URL.Query().Get("max_results"))
+		mu.Unlock()
+		if r.URL.Query().Get("start") == "6" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler(w, r)
+	}))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "cat:cs.DL", Limit: 5, PageSize: 2, SaveMetadata: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := strings.Join(requests, ","); got != "0+2,2+2,4+1" {
+		t.Errorf("requests (start+max_results) = %s, want 0+2,2+2,4+1", got)
+	}
+	for _, want := range []string{"fetched 2 of 5 papers\n", "fetched 4 of 5 papers\n", "fetched 5 of 5 papers\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 5)
+
+	// A failed page keeps the pages before it.
+	err = Run(testingContext(t), Options{Query: "cat:cs.DL", Limit: 8, PageSize: 3, SaveMetadata: true, Out: &out})
+	if err == nil {
+		t.Fatal("Run() error = nil, want the failed page")
+	}
+	content, err = os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 6)
+}
+
+func TestRunResumePagination(t *testing.T) {
+	chdirTemp(t)
+
+	var mu sync.Mutex
+	var starts []string
+	failAt := "4"
+	handler := pagedFeedHandler(6)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		start := r.URL.Query().Get("start")
+		starts = append(starts, start)
+		if start == failAt {
+			http.Error(w, "simulated outage", http.StatusServiceUnavailable)
+			return
+		}
+		handler(w, r)
+	}))
+
+	opts := Options{Query: "cat:cs.DL", All: true, PageSize: 2, SaveMetadata: true, ResumePagination: true}
+	if err := Run(testingContext(t), opts); err == nil {
+		t.Fatal("Run() error = nil, want simulated outage")
+	}
+
+	saved, err := loadPaginationState(PaginationStateFile, opts.Query)
+	if err != nil {
+		t.Fatalf("loadPaginationState() error = %v", err)
+	}
+	if saved != 4 {
+		t.Fatalf("saved offset = %d, want 4", saved)
+	}
+
+	mu.Lock()
+	failAt, starts = "", nil
+	mu.Unlock()
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("resumed Run() error = %v", err)
+	}
+
+	if len(starts) == 0 || starts[0] != "4" {
+		t.Errorf("resumed run requested offsets %v, want to start at 4", starts)
+	}
+	if _, err := os.Stat(PaginationStateFile); !os.IsNotExist(err) {
+		t.Error("pagination state should be cleared after completion")
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 6)
+}
+
+// killedHarvestEnv, when set, makes TestRunResumeAfterKill act as the
+// harvest that is killed, in the directory it names.
+const killedHarvestEnv = "ARXIV_CLI_KILLED_HARVEST_DIR"
+
+func TestRunResumeAfterKill(t *testing.T) {
+	opts := Options{Query: "cat:cs.DL", All: true, PageSize: 2, SaveMetadata: true, ResumePagination: true}
+	handler := pagedFeedHandler(6)
+
+	if dir := os.Getenv(killedHarvestEnv); dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		// Die without returning, as a killed process would, while the
+		// third page is requested.
+		useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("start") == "4" {
+				os.Exit(3)
+			}
+			handler(w, r)
+		}))
+		_ = Run(testingContext(t), opts)
+		t.Fatal("Run() returned, want the process killed")
+	}
+	dir := chdirTemp(t)
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunResumeAfterKill$")
+	cmd.Env = append(os.Environ(), killedHarvestEnv+"="+dir)
+	var exitErr *exec.ExitError
+	if out, err := cmd.CombinedOutput(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("killed harvest error = %v, want exit status 3\n%s", err, out)
+	}
+
+	saved, err := loadPaginationState(PaginationStateFile, opts.Query)
+	if err != nil {
+		t.Fatalf("loadPaginationState() error = %v", err)
+	}
+	if saved != 4 {
+		t.Fatalf("saved offset = %d, want 4", saved)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("the killed harvest left no metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 4)
+
+	useFakeAPI(t, handler)
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("resumed Run() error = %v", err)
+	}
+	content, err = os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata file: %v", err)
+	}
+	assertValidJSONL(t, string(content), 6)
+}
+
+func TestRunWarnsOnEmptySearch(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(0))
+	chdirTemp(t)
+
+	for _, all := range []bool{false, true} {
+		var logs bytes.Buffer
+		opts := Options{
+			Query:        "cat:cs.CLL",
+			Limit:        5,
+			All:          all,
+			SaveMetadata: true,
+			Out:          &strings.Builder{},
+			Logger:       slog.New(slog.NewTextHandler(&logs, nil)),
+		}
+		if err := Run(testingContext(t), opts); err != nil {
+			t.Fatalf("Run(All=%v) error = %v", all, err)
+		}
+		for _, want := range []string{"level=WARN", "search returned no papers", `search_query=cat:cs.CLL`, "total_results=0"} {
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("All=%v: log %q lacks %q", all, logs.String(), want)
+			}
+		}
+
+		opts.FailOnEmpty = true
+		if err := Run(testingContext(t), opts); !errors.Is(err, ErrNoResults) {
+			t.Errorf("Run(All=%v, FailOnEmpty) error = %v, want ErrNoResults", all, err)
+		}
+	}
+}
+
+func TestRunReportsTotalResults(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(1234))
+	chdirTemp(t)
+
+	var out strings.Builder
+	var logs bytes.Buffer
+	opts := Options{Query: "cat:cs.CL", Limit: 5, SaveMetadata: true, Out: &out, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "showing 5 of 1,234 matching papers\n") {
+		t.Errorf("output = %q, want the total", out.String())
+	}
+	if logs.Len() > 0 {
+		t.Errorf("unexpected log output %q", logs.String())
+	}
+
+	useFakeAPI(t, pagedFeedHandler(3))
+	out.Reset()
+	opts.Limit = 10
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "showing 3 of 3 matching papers\n") {
+		t.Errorf("output = %q, want the total", out.String())
+	}
+	if !strings.Contains(logs.String(), "limit exceeds the number of matching papers") || !strings.Contains(logs.String(), "total_results=3") {
+		t.Errorf("log = %q, want a warning about the limit", logs.String())
+	}
+}
+
+func TestGroupDigits(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 12: "12", 999: "999", 1000: "1,000", 1234: "1,234", 1234567: "1,234,567", -4321: "-4,321"} {
+		if got := groupDigits(n); got != want {
+			t.Errorf("groupDigits(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Paper types returned by InferPaperType.
+const (
+	PaperTypeConference = "conference"
+	PaperTypeJournal    = "journal"
+	PaperTypeWorkshop   = "workshop"
+	PaperTypePreprint   = "preprint"
+)
+
+// PaperTypes lists the values accepted by the --paper-type filter.
+var PaperTypes = []string{PaperTypeConference, PaperTypeJournal, PaperTypeWorkshop, PaperTypePreprint}
+
+var (
+	workshopPattern   = regexp.MustCompile(`(?i)\bworkshops?\b`)
+	conferencePattern = regexp.MustCompile(`(?i)\b(conference|proceedings|symposium|accepted (at|to|by|in)|to appear (at|in)|neurips|nips|icml|iclr|acl|emnlp|naacl|eacl|coling|cvpr|iccv|eccv|aaai|ijcai|kdd|sigir|www|chi|uai|aistats|colt|interspeech|icassp)\b`)
+	journalPattern    = regexp.MustCompile(`(?i)\b(journal|transactions|letters|published in|phys\. ?rev|nature)\b|\b(j|trans)\. `)
+)
+
+// InferPaperType guesses whether a paper is a conference, journal or
+// workshop paper from its JournalRef and Comment, falling back to preprint.
+// A journal reference wins over comments, and workshop mentions win over
+// conference ones since workshops are usually co-located with a conference.
+func InferPaperType(p ArxivPaper) string {
+	comment := ""
+	if p.Comment != nil {
+		comment = *p.Comment
+	}
+
+	for _, text := range []string{p.JournalRef, comment} {
+		switch {
+		case text == "":
+			continue
+		case workshopPattern.MatchString(text):
+			return PaperTypeWorkshop
+		case conferencePattern.MatchString(text):
+			return PaperTypeConference
+		case journalPattern.MatchString(text):
+			return PaperTypeJournal
+		}
+	}
+
+	if p.JournalRef != "" {
+		return PaperTypeJournal
+	}
+	return PaperTypePreprint
+}
+
+// ValidatePaperType checks that value is one of PaperTypes.
+func ValidatePaperType(value string) error {
+	for _, known := range PaperTypes {
+		if value == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown paper type %q (expected one of %s)", value, strings.Join(PaperTypes, ", "))
+}
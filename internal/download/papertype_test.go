@@ -0,0 +1,35 @@
+package download
+
+import (
+	"testing"
+)
+
+func TestInferPaperType(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		paper    ArxivPaper
+		expected string
+	}{
+		{"no hints", ArxivPaper{}, PaperTypePreprint},
+		{"page count only", ArxivPaper{Comment: str("12 pages, 4 figures")}, PaperTypePreprint},
+		{"conference proceedings", ArxivPaper{Comment: str("Proceedings of NeurIPS 2024")}, PaperTypeConference},
+		{"accepted at venue", ArxivPaper{Comment: str("Accepted at ICML")}, PaperTypeConference},
+		{"workshop", ArxivPaper{Comment: str("Accepted at the NeurIPS 2024 Workshop on Efficiency")}, PaperTypeWorkshop},
+		{"journal comment", ArxivPaper{Comment: str("Published in IEEE Transactions on Pattern Analysis")}, PaperTypeJournal},
+		{"under review", ArxivPaper{Comment: str("Under review")}, PaperTypePreprint},
+		{"abbreviated journal", ArxivPaper{Comment: str("Published as J. Chem. Phys. 160")}, PaperTypeJournal},
+		{"journal ref", ArxivPaper{JournalRef: "Phys. Rev. D 110, 012345 (2024)"}, PaperTypeJournal},
+		{"unrecognized journal ref", ArxivPaper{JournalRef: "Ann. Math. 200 (2024) 1-50"}, PaperTypeJournal},
+		{"journal ref wins", ArxivPaper{JournalRef: "Nature 600, 1 (2024)", Comment: str("Accepted at ICML")}, PaperTypeJournal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferPaperType(tt.paper); got != tt.expected {
+				t.Errorf("InferPaperType() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// fullTextSuffix ends the name of the full text extracted with
+// Options.PDFToText, which is saved next to the summaries.
+const fullTextSuffix = "-fulltext.txt"
+
+// fullTextPath is where the full text of paper is saved.
+func (o Options) fullTextPath(paper ArxivPaper) string {
+	return filepath.Join(o.textDir(), FormatFilename(o.FilenameTemplate, paper)+fullTextSuffix)
+}
+
+// ExtractPDFText returns the plain text of the PDF at path, page by page.
+// Text drawn as images, as in scanned papers, is not recovered.
+func ExtractPDFText(path string) (text string, err error) {
+	// The parser panics on some malformed files rather than failing.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse PDF %s: %v", path, r)
+		}
+	}()
+
+	file, reader, err := pdf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract text from PDF: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, plain); err != nil {
+		return "", fmt.Errorf("failed to extract text from PDF: %w", err)
+	}
+	return strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// writeFullText extracts the text of the PDF at pdfPath into outPath.
+func (o Options) writeFullText(pdfPath, outPath string) error {
+	text, err := ExtractPDFText(pdfPath)
+	if err != nil {
+		return err
+	}
+	if err := o.mkdirAll(filepath.Dir(outPath)); err != nil {
+		return fmt.Errorf("failed to create text directory: %w", err)
+	}
+	return o.writeFile(outPath, []byte(text))
+}
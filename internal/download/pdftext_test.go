@@ -0,0 +1,91 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// minimalPDF returns a one-page PDF showing text in Helvetica.
+func minimalPDF(text string) []byte {
+	content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paper.pdf")
+	if err := os.WriteFile(path, minimalPDF("Attention is all you need"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	text, err := ExtractPDFText(path)
+	if err != nil {
+		t.Fatalf("ExtractPDFText() error = %v", err)
+	}
+	if !strings.Contains(text, "Attention is all you need") {
+		t.Errorf("ExtractPDFText() = %q, want the page's text", text)
+	}
+
+	broken := filepath.Join(t.TempDir(), "broken.pdf")
+	if err := os.WriteFile(broken, []byte("%PDF-1.5\n..."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractPDFText(broken); err == nil {
+		t.Error("ExtractPDFText() of a broken PDF succeeded")
+	}
+}
+
+func TestRunPDFToText(t *testing.T) {
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/pdf/") {
+			_, _ = w.Write(minimalPDF("Full text of the paper"))
+			return
+		}
+		feed := strings.ReplaceAll(fakeFeed(1, fakeEntry("2401.00001v1", "Paper 1")), "http://arxiv.org/pdf/", "http://"+r.Host+"/pdf/")
+		_, _ = fmt.Fprint(w, feed)
+	}))
+	chdirTemp(t)
+
+	err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, SavePDFs: true, PDFToText: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	text, err := os.ReadFile(filepath.Join(TextDirectory, "Paper 1-fulltext.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), "Full text of the paper") {
+		t.Errorf("full text = %q", text)
+	}
+	if files := readFiles(t, JSONFile); files[0][FileFullText] != "texts/Paper 1-fulltext.txt" {
+		t.Errorf("files = %v, want the full text recorded", files[0])
+	}
+
+	err = Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, PDFToText: true, Out: &strings.Builder{}})
+	if err == nil {
+		t.Error("Run() extracted text without saving PDFs")
+	}
+}
@@ -0,0 +1,108 @@
+package download
+
+import (
+	"fmt"
+	"os"
+)
+
+// Modes of the files and directories a run creates when Options.FileMode
+// and Options.DirMode are unset. The umask applies to them as usual.
+const (
+	defaultFileMode os.FileMode = 0644
+	defaultDirMode  os.FileMode = 0755
+)
+
+// fileMode is the mode new files are created with.
+func (o Options) fileMode() os.FileMode {
+	if o.FileMode != 0 {
+		return o.FileMode
+	}
+	return defaultFileMode
+}
+
+// mkdirAll creates the directory path and any missing parents. A
+// configured DirMode is set on path explicitly, so that the umask cannot
+// narrow it.
+func (o Options) mkdirAll(path string) error {
+	mode := defaultDirMode
+	if o.DirMode != 0 {
+		mode = o.DirMode
+	}
+	if err := os.MkdirAll(path, mode); err != nil {
+		return err
+	}
+	if o.DirMode != 0 {
+		return os.Chmod(path, o.DirMode)
+	}
+	return nil
+}
+
+// writeFile writes data to path with the configured file mode.
+func (o Options) writeFile(path string, data []byte) error {
+	if err := removeReadOnly(path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, o.fileMode()); err != nil {
+		return err
+	}
+	return o.applyFileMode(path)
+}
+
+// applyFileMode sets a configured FileMode on a file the run wrote. Files
+// are created before their mode is known to matter, with whatever the umask
+// leaves, so the mode is set explicitly afterwards.
+func (o Options) applyFileMode(path string) error {
+	if o.FileMode == 0 {
+		return nil
+	}
+	if err := os.Chmod(path, o.FileMode); err != nil {
+		return fmt.Errorf("failed to set the mode of %s: %w", path, err)
+	}
+	return nil
+}
+
+// finalize settles a completed artifact of a paper whose metadata has been
+// recorded: it gets the configured file mode and, with FinalizeReadOnly,
+// loses its write bits. Later runs only read such files (SkipExisting
+// checks them, bundle export copies them), so they keep working.
+func (o Options) finalize(path string) error {
+	if !o.FinalizeReadOnly {
+		return o.applyFileMode(path)
+	}
+	mode := o.FileMode
+	if mode == 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to finalize %s: %w", path, err)
+		}
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(path, mode&^0222); err != nil {
+		return fmt.Errorf("failed to finalize %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeReadOnly removes the file at path if it has no owner write bit, as
+// after a run with FinalizeReadOnly, so that it can be written again
+// instead of failing with a permission error. Writing a file replaces its
+// content anyway.
+func removeReadOnly(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0200 != 0 {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to replace read-only %s: %w", path, err)
+	}
+	return nil
+}
+
+// createFile is os.Create for a file a previous run may have made read-only
+// (see removeReadOnly).
+func createFile(path string) (*os.File, error) {
+	if err := removeReadOnly(path); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
@@ -0,0 +1,169 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunAppliesModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permission bits; os.Chmod only toggles the read-only attribute")
+	}
+	useFakeAPI(t, pagedFeedHandler(2))
+	dir := chdirTemp(t)
+
+	opts := Options{
+		Query:            "graphrag",
+		Limit:            2,
+		SaveMetadata:     true,
+		SaveSummaries:    true,
+		OutputDir:        "out",
+		FileMode:         0640,
+		DirMode:          0750,
+		FinalizeReadOnly: true,
+		Out:              &strings.Builder{},
+	}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	summaries, _ := filepath.Glob(filepath.Join(dir, "out", TextDirectory, "*.txt"))
+	if len(summaries) != 2 {
+		t.Fatalf("summaries = %v, want 2", summaries)
+	}
+	want := map[string]os.FileMode{
+		filepath.Join(dir, "out"):                0750,
+		filepath.Join(dir, "out", TextDirectory): 0750,
+		filepath.Join(dir, "out", JSONFile):      0640,
+		summaries[0]:                             0440,
+		summaries[1]:                             0440,
+	}
+	for path, mode := range want {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("mode of %s = %o, want %o", path, got, mode)
+		}
+	}
+
+	// Read-only artifacts must not get in the way of a run that skips them.
+	opts.SkipExisting = true
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+}
+
+func TestFinalizeKeepsUmaskModeWithoutFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permission bits; os.Chmod only toggles the read-only attribute")
+	}
+	path := filepath.Join(t.TempDir(), "paper.txt")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Options{FinalizeReadOnly: true}).finalize(path); err != nil {
+		t.Fatalf("finalize() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0444 {
+		t.Errorf("mode = %o, want 444", got)
+	}
+}
+
+func TestStateFilesApplyFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permission bits; os.Chmod only toggles the read-only attribute")
+	}
+	dir := chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeFeed(1, authorEntry("2401.00001v1", "First", "Jane Doe"))))
+	}))
+
+	if err := savePaginationState(Options{Query: "graphrag", OutputDir: dir, FileMode: 0600}, 4); err != nil {
+		t.Fatalf("savePaginationState() error = %v", err)
+	}
+	err := WatchAuthors(testingContext(t), AuthorWatchOptions{
+		Authors:   []WatchedAuthor{{Name: "Jane Doe"}},
+		Limit:     10,
+		StatePath: AuthorStateFile,
+		FileMode:  0600,
+		Out:       &strings.Builder{},
+	})
+	if err != nil {
+		t.Fatalf("WatchAuthors() error = %v", err)
+	}
+
+	for _, name := range []string{PaginationStateFile, AuthorStateFile, JSONFile} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != 0600 {
+			t.Errorf("mode of %s = %o, want 600", name, got)
+		}
+	}
+}
+
+func TestRunReplacesReadOnlyArtifacts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permission bits; os.Chmod only toggles the read-only attribute")
+	}
+	useFakeAPI(t, pagedFeedHandler(2))
+	dir := chdirTemp(t)
+
+	opts := Options{
+		Query:            "graphrag",
+		Limit:            2,
+		SaveMetadata:     true,
+		SaveSummaries:    true,
+		StoreRawEntry:    true,
+		DublinCore:       true,
+		FinalizeReadOnly: true,
+		Out:              &strings.Builder{},
+	}
+	patterns := []string{TextDirectory + "*.txt", RawDirectory + "*", DublinCoreDirectory + "*"}
+	// Permission checks do not stop root from writing into a read-only
+	// file, so links to the first run's files check that the second run
+	// replaced them instead.
+	links := t.TempDir()
+	for run := 1; run <= 2; run++ {
+		if err := Run(testingContext(t), opts); err != nil {
+			t.Fatalf("Run() #%d error = %v", run, err)
+		}
+		for _, pattern := range patterns {
+			files, _ := filepath.Glob(filepath.Join(dir, pattern))
+			if len(files) != 2 {
+				t.Errorf("run #%d: %s matches %v, want 2 files", run, pattern, files)
+			}
+			for _, file := range files {
+				info, err := os.Stat(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm()&0222 != 0 {
+					t.Errorf("run #%d: mode of %s = %o, want read-only", run, file, info.Mode().Perm())
+				}
+				link := filepath.Join(links, strings.ReplaceAll(strings.TrimPrefix(file, dir), string(filepath.Separator), "_"))
+				if run == 1 {
+					if err := os.Link(file, link); err != nil {
+						t.Skipf("cannot link files: %v", err)
+					}
+				} else if old, err := os.Stat(link); err == nil && os.SameFile(old, info) {
+					t.Errorf("%s was written in place, want it replaced", file)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PodcastDirectory holds the podcast scripts and audio generated with
+// Options.Podcast.
+const PodcastDirectory = "podcasts/"
+
+// Defaults for PodcastOptions, matching the OpenAI API.
+const (
+	DefaultLLMAPIURL = "https://api.openai.com/v1/chat/completions"
+	DefaultLLMModel  = "gpt-4o-mini"
+	DefaultTTSModel  = "tts-1"
+)
+
+// podcastPrompt instructs the model how to write a script.
+const podcastPrompt = "You write short podcast intros about research papers for a general audience. " +
+	"Given a paper's title, authors and abstract, write two to three paragraphs of plain prose, " +
+	"meant to be read aloud, explaining what problem the paper tackles, what it contributes and why it matters. " +
+	"Avoid jargon, equations, lists and markdown."
+
+// PodcastOptions configures podcast generation. Scripts are written with an
+// OpenAI-compatible chat completions API; audio is only generated when
+// TTSAPIURL and TTSVoice are set, using an OpenAI-compatible speech API.
+type PodcastOptions struct {
+	APIURL string // chat completions endpoint; DefaultLLMAPIURL if empty
+	APIKey string // sent as a bearer token to the chat completions API
+	Model  string // DefaultLLMModel if empty
+
+	TTSAPIURL string
+	TTSVoice  string
+	TTSModel  string // DefaultTTSModel if empty
+
+	// TTSAPIKey is sent as a bearer token to the TTS API. When empty,
+	// APIKey is sent instead if both APIs are on the same host, and no
+	// key otherwise.
+	TTSAPIKey string
+}
+
+// GeneratePodcastScript asks the language model for a podcast intro to
+// paper.
+func (o PodcastOptions) GeneratePodcastScript(ctx context.Context, paper ArxivPaper) (string, error) {
+	request := map[string]any{
+		"model": valueOr(o.Model, DefaultLLMModel),
+		"messages": []map[string]string{
+			{"role": "system", "content": podcastPrompt},
+			{"role": "user", "content": fmt.Sprintf("Title: %s\nAuthors: %s\n\nAbstract:\n%s",
+				paper.Title, strings.Join(paper.Authors, ", "), paper.Summary)},
+		},
+	}
+	resp, err := postJSON(ctx, valueOr(o.APIURL, DefaultLLMAPIURL), o.APIKey, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to query language model: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse language model response: %w", err)
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("language model returned no script")
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// SynthesizeSpeech reads script aloud with the TTS API and writes the audio
+// to w as received.
+func (o PodcastOptions) SynthesizeSpeech(ctx context.Context, script string, w io.Writer) error {
+	request := map[string]any{
+		"model": valueOr(o.TTSModel, DefaultTTSModel),
+		"voice": o.TTSVoice,
+		"input": script,
+	}
+	resp, err := postJSON(ctx, o.TTSAPIURL, o.ttsAPIKey(), request)
+	if err != nil {
+		return fmt.Errorf("failed to query TTS API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+	return nil
+}
+
+// ttsAPIKey returns the key to send to the TTS API, keeping the chat
+// completions key from any other host.
+func (o PodcastOptions) ttsAPIKey() string {
+	if o.TTSAPIKey != "" {
+		return o.TTSAPIKey
+	}
+	chat, err := url.Parse(valueOr(o.APIURL, DefaultLLMAPIURL))
+	if err != nil {
+		return ""
+	}
+	tts, err := url.Parse(o.TTSAPIURL)
+	if err != nil || !strings.EqualFold(chat.Host, tts.Host) {
+		return ""
+	}
+	return o.APIKey
+}
+
+// postJSON sends body as JSON, with key as a bearer token if set, and returns
+// the response if it is a 200.
+func postJSON(ctx context.Context, endpoint, key string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if key != "" {
+		header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := httpDo(ctx, "POST", endpoint, header, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// savePodcast writes the script, and audio when TTS is configured, for
+// paper to PodcastDirectory, and returns them.
+func savePodcast(ctx context.Context, paper ArxivPaper, opts Options) ([]artifact, error) {
+	podcast := opts.Podcast
+	if err := opts.mkdirAll(opts.path(PodcastDirectory)); err != nil {
+		return nil, fmt.Errorf("failed to create podcast directory: %w", err)
+	}
+	base := filepath.Join(opts.path(PodcastDirectory), FormatFilename(opts.FilenameTemplate, paper))
+
+	var artifacts []artifact
+	scriptPath := base + ".txt"
+	var script string
+	if opts.SkipExisting && fileExists(scriptPath) {
+		opts.printf("skipping %s (already exists)\n", scriptPath)
+		data, err := os.ReadFile(scriptPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read podcast script: %w", err)
+		}
+		script = string(data)
+		artifacts = append(artifacts, artifact{kind: FilePodcastScript, path: scriptPath})
+	} else {
+		var err error
+		if script, err = podcast.GeneratePodcastScript(ctx, paper); err != nil {
+			return nil, err
+		}
+		if err := opts.writeFile(scriptPath, []byte(script+"\n")); err != nil {
+			return nil, fmt.Errorf("failed to write podcast script: %w", err)
+		}
+		artifacts = append(artifacts, artifact{kind: FilePodcastScript, path: scriptPath, written: true})
+	}
+
+	if podcast.TTSAPIURL == "" || podcast.TTSVoice == "" {
+		return artifacts, nil
+	}
+	audioPath := base + ".mp3"
+	if opts.SkipExisting && fileExists(audioPath) {
+		opts.printf("skipping %s (already exists)\n", audioPath)
+		return append(artifacts, artifact{kind: FileAudio, path: audioPath}), nil
+	}
+	file, err := createFile(audioPath)
+	if err != nil {
+		return artifacts, fmt.Errorf("failed to create audio file: %w", err)
+	}
+	err = podcast.SynthesizeSpeech(ctx, script, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(audioPath)
+		return artifacts, err
+	}
+	return append(artifacts, artifact{kind: FileAudio, path: audioPath, written: true}), nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
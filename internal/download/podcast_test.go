@@ -0,0 +1,118 @@
+package download
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunGeneratesPodcast(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(1))
+
+	var prompts, inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Voice    string `json:"voice"`
+			Input    string `json:"input"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("request body: %v", err)
+		}
+		switch r.URL.Path {
+		case "/chat":
+			prompts = append(prompts, body.Messages[len(body.Messages)-1].Content)
+			_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "Welcome to the show."}}]}`))
+		case "/speech":
+			if body.Voice != "alloy" || body.Model != DefaultTTSModel {
+				t.Errorf("TTS voice, model = %q, %q", body.Voice, body.Model)
+			}
+			inputs = append(inputs, body.Input)
+			_, _ = w.Write([]byte("ID3 audio"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	podcast := &PodcastOptions{
+		APIURL:    server.URL + "/chat",
+		APIKey:    "secret",
+		TTSAPIURL: server.URL + "/speech",
+		TTSVoice:  "alloy",
+	}
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, Podcast: podcast}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Title: Paper 0") || !strings.Contains(prompts[0], "Summary of Paper 0.") {
+		t.Errorf("prompts = %q", prompts)
+	}
+	script, err := os.ReadFile(filepath.Join(PodcastDirectory, "Paper 0.txt"))
+	if err != nil || string(script) != "Welcome to the show.\n" {
+		t.Errorf("script = %q, %v", script, err)
+	}
+	if len(inputs) != 1 || inputs[0] != "Welcome to the show." {
+		t.Errorf("TTS inputs = %q", inputs)
+	}
+	audio, err := os.ReadFile(filepath.Join(PodcastDirectory, "Paper 0.mp3"))
+	if err != nil || string(audio) != "ID3 audio" {
+		t.Errorf("audio = %q, %v", audio, err)
+	}
+}
+
+func TestGeneratePodcastScriptError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := PodcastOptions{APIURL: server.URL}.GeneratePodcastScript(testingContext(t), ArxivPaper{Title: "T"})
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("GeneratePodcastScript() error = %v, want HTTP 429", err)
+	}
+}
+
+func TestPodcastTTSAPIKey(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PodcastOptions
+		want string
+	}{
+		{"same host as the default chat API", PodcastOptions{APIKey: "secret", TTSAPIURL: "https://API.openai.com/v1/audio/speech"}, "secret"},
+		{"same host", PodcastOptions{APIURL: "http://localhost:8080/chat", APIKey: "secret", TTSAPIURL: "http://localhost:8080/speech"}, "secret"},
+		{"other host", PodcastOptions{APIKey: "secret", TTSAPIURL: "https://tts.example.com/speech"}, ""},
+		{"other port", PodcastOptions{APIURL: "http://localhost:8080/chat", APIKey: "secret", TTSAPIURL: "http://localhost:9090/speech"}, ""},
+		{"own key", PodcastOptions{APIKey: "secret", TTSAPIURL: "https://tts.example.com/speech", TTSAPIKey: "tts"}, "tts"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.ttsAPIKey(); got != tt.want {
+			t.Errorf("%s: ttsAPIKey() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// The chat completions key is not sent to a TTS API elsewhere.
+	tts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("TTS API received Authorization = %q", auth)
+		}
+		_, _ = w.Write([]byte("ID3 audio"))
+	}))
+	t.Cleanup(tts.Close)
+	podcast := PodcastOptions{APIURL: "https://llm.example.com/chat", APIKey: "secret", TTSAPIURL: tts.URL, TTSVoice: "alloy"}
+	if err := podcast.SynthesizeSpeech(testingContext(t), "Hello.", io.Discard); err != nil {
+		t.Fatalf("SynthesizeSpeech() error = %v", err)
+	}
+}
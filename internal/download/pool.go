@@ -0,0 +1,93 @@
+package download
+
+import (
+	"context"
+	"errors"
+)
+
+// savePool saves papers with up to Options.Concurrency of them downloading
+// at once; the shared transport's host limits (see SetHostLimits) further
+// cap the requests to each host. Each paper's metadata is still written
+// from the calling goroutine, in the order the papers were submitted, so
+// the metadata files read the same as with one download at a time.
+type savePool struct {
+	ctx      context.Context
+	opts     *Options
+	metadata *metadataExport
+	recorded func(ArxivPaper, []artifact) // called once a paper is saved
+
+	slots   chan struct{} // nil when papers are saved one at a time
+	pending []*pendingSave
+}
+
+// pendingSave is a paper whose artifacts are being saved.
+type pendingSave struct {
+	paper     ArxivPaper
+	artifacts []artifact
+	err       error
+	done      chan struct{}
+}
+
+func newSavePool(ctx context.Context, opts *Options, metadata *metadataExport, recorded func(ArxivPaper, []artifact)) *savePool {
+	p := &savePool{ctx: ctx, opts: opts, metadata: metadata, recorded: recorded}
+	if opts.Concurrency > 1 {
+		p.slots = make(chan struct{}, opts.Concurrency)
+	}
+	return p
+}
+
+// submit starts saving paper once a slot is free, then records the papers
+// submitted earlier that are done. It returns the first error among them.
+func (p *savePool) submit(paper ArxivPaper) error {
+	if p.slots == nil {
+		paper, artifacts, err := prepareSave(p.ctx, paper, *p.opts)
+		return p.record(paper, artifacts, err)
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+	s := &pendingSave{paper: paper, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer func() { <-p.slots }()
+		s.paper, s.artifacts, s.err = prepareSave(p.ctx, s.paper, *p.opts)
+	}()
+	p.pending = append(p.pending, s)
+
+	for len(p.pending) > 0 {
+		select {
+		case <-p.pending[0].done:
+		default:
+			return nil
+		}
+		s := p.pending[0]
+		p.pending = p.pending[1:]
+		if err := p.record(s.paper, s.artifacts, s.err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wait records every paper still being saved, even after one of them
+// failed, so that none that made it to disk is missing from the metadata.
+func (p *savePool) wait() error {
+	var errs []error
+	for _, s := range p.pending {
+		<-s.done
+		errs = append(errs, p.record(s.paper, s.artifacts, s.err))
+	}
+	p.pending = nil
+	return errors.Join(errs...)
+}
+
+func (p *savePool) record(paper ArxivPaper, artifacts []artifact, err error) error {
+	if err := recordSave(p.ctx, paper, artifacts, err, *p.opts, p.metadata); err != nil {
+		return err
+	}
+	p.recorded(paper, artifacts)
+	return nil
+}
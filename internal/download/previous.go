@@ -0,0 +1,85 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PreviousRunFile keeps, per search, the papers the last run with
+// CompareToPrevious returned, so that the next one only shows what is new.
+const PreviousRunFile = ".arxiv-cli-previous.json"
+
+// previousRuns is the content of PreviousRunFile, by search (see
+// Options.previousRunKey).
+type previousRuns struct {
+	Searches map[string][]seenPaper `json:"searches"`
+}
+
+// seenPaper is a paper returned by a previous run.
+type seenPaper struct {
+	ID    string `json:"id"` // base arXiv ID
+	Title string `json:"title"`
+}
+
+// loadPreviousRuns reads the state file at path; a missing file records no
+// runs.
+func loadPreviousRuns(path string) (*previousRuns, error) {
+	runs := &previousRuns{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read previous run state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, runs); err != nil {
+			return nil, fmt.Errorf("failed to parse previous run state: %w", err)
+		}
+	}
+	if runs.Searches == nil {
+		runs.Searches = map[string][]seenPaper{}
+	}
+	return runs, nil
+}
+
+// seen returns the base IDs of the papers the previous run of search
+// returned.
+func (r *previousRuns) seen(search string) map[string]bool {
+	ids := map[string]bool{}
+	for _, paper := range r.Searches[search] {
+		ids[paper.ID] = true
+	}
+	return ids
+}
+
+// save replaces the papers recorded for search with papers and writes the
+// state to path.
+func (r *previousRuns) save(opts Options, path, search string, papers []seenPaper) error {
+	if papers == nil {
+		papers = []seenPaper{}
+	}
+	r.Searches[search] = papers
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal previous run state: %w", err)
+	}
+	if err := opts.writeFile(path, data); err != nil {
+		return fmt.Errorf("failed to write previous run state: %w", err)
+	}
+	return nil
+}
+
+// previousRunKey identifies the search of a run in PreviousRunFile: the
+// query sent to the API, with its date range, or those of each of Queries.
+func (o Options) previousRunKey() string {
+	if len(o.Queries) == 0 {
+		return o.searchQuery()
+	}
+	keys := make([]string, len(o.Queries))
+	for i, query := range o.Queries {
+		o.Query = query
+		keys[i] = o.searchQuery()
+	}
+	return strings.Join(keys, "\n")
+}
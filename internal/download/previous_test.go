@@ -0,0 +1,94 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunCompareToPrevious(t *testing.T) {
+	runs := [][]string{
+		{fakeEntry("2401.00002v1", "Second"), fakeEntry("2401.00001v1", "First")},
+		// A new paper, and a new version of one already seen.
+		{fakeEntry("2401.00003v1", "Third"), fakeEntry("2401.00002v2", "Second")},
+	}
+	var run atomic.Int32
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, fakeFeed(2, runs[run.Load()]...))
+	}))
+	chdirTemp(t)
+
+	// printed runs opts and returns the IDs of the papers it output.
+	printed := func(opts Options) string {
+		t.Helper()
+		var records strings.Builder
+		opts.MetadataOut, opts.Out = &records, &strings.Builder{}
+		if err := Run(testingContext(t), opts); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return records.String()
+	}
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, CompareToPrevious: true}
+
+	if out := printed(opts); !strings.Contains(out, "2401.00001") || !strings.Contains(out, "2401.00002") {
+		t.Errorf("first run printed %q, want every paper", out)
+	}
+	// Another search keeps its own record.
+	if out := printed(Options{Query: "cat:cs.LG", Limit: 2, SaveMetadata: true, CompareToPrevious: true}); !strings.Contains(out, "2401.00001") {
+		t.Errorf("other search printed %q, want every paper", out)
+	}
+
+	run.Store(1)
+	// A dry run compares without recording what it found.
+	var dryRun strings.Builder
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, DryRun: true, CompareToPrevious: true, Out: &dryRun}); err != nil {
+		t.Fatalf("dry Run() error = %v", err)
+	}
+	if !strings.Contains(dryRun.String(), "Third") || strings.Contains(dryRun.String(), "Second") {
+		t.Errorf("dry run printed %q, want only the third paper", dryRun.String())
+	}
+	out := printed(opts)
+	if !strings.Contains(out, "2401.00003") || strings.Contains(out, "2401.00002") {
+		t.Errorf("second run printed %q, want only 2401.00003", out)
+	}
+	if out := printed(opts); out != "" {
+		t.Errorf("third run printed %q, want nothing new", out)
+	}
+
+	runs[1] = runs[0]
+	var messages strings.Builder
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, CompareToPrevious: true, Out: &messages}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(messages.String(), "1 new since the previous run, 1 seen before") {
+		t.Errorf("saving run printed %q, want the count of new papers", messages.String())
+	}
+
+	if err := Run(testingContext(t), Options{IDs: []string{"2401.00001"}, CompareToPrevious: true, Out: &strings.Builder{}}); err == nil {
+		t.Error("Run() compared an ID list to a previous run")
+	}
+}
+
+func TestRunDryRunLeavesPreviousRunAlone(t *testing.T) {
+	useFakeAPI(t, pagedFeedHandler(2))
+	dir := chdirTemp(t)
+
+	for _, opts := range []Options{
+		{DryRun: true},
+		{PrintURLs: true},
+		{PrintIDs: true},
+		{Table: true},
+	} {
+		opts.Query, opts.Limit, opts.CompareToPrevious, opts.Out = "cat:cs.CL", 2, true, &strings.Builder{}
+		if err := Run(testingContext(t), opts); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, PreviousRunFile)); !os.IsNotExist(err) {
+		t.Errorf("runs that save nothing created %s (err = %v)", PreviousRunFile, err)
+	}
+}
@@ -0,0 +1,74 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	progressBarWidth   = 20
+	progressTitleWidth = 40
+)
+
+// progress draws a single-line progress bar that is redrawn in place as
+// papers complete. A nil *progress draws nothing.
+type progress struct {
+	w     io.Writer
+	total int // 0 when unknown, as in All mode
+	done  int
+}
+
+func newProgress(w io.Writer, total int) *progress {
+	if w == nil {
+		return nil
+	}
+	return &progress{w: w, total: total}
+}
+
+// advance records a completed paper and redraws the bar with its title.
+func (p *progress) advance(title string) {
+	if p == nil {
+		return
+	}
+	p.done++
+
+	var line string
+	if p.total > 0 {
+		filled := min(p.done*progressBarWidth/p.total, progressBarWidth)
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+		line = fmt.Sprintf("[%s] %d/%d %s", bar, p.done, p.total, truncate(title, progressTitleWidth))
+	} else {
+		line = fmt.Sprintf("%d papers %s", p.done, truncate(title, progressTitleWidth))
+	}
+	_, _ = fmt.Fprintf(p.w, "\r\033[K%s", line)
+}
+
+// clear erases the bar so that other output starts on a clean line.
+func (p *progress) clear() {
+	if p == nil || p.done == 0 {
+		return
+	}
+	_, _ = fmt.Fprint(p.w, "\r\033[K")
+}
+
+// finish ends the bar's line, leaving its final state on screen.
+func (p *progress) finish() {
+	if p == nil || p.done == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(p.w)
+	p.done = 0
+}
+
+// truncate shortens s to at most width runes, marking the cut with "...".
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
@@ -0,0 +1,48 @@
+package download
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var out strings.Builder
+	bar := newProgress(&out, 4)
+	bar.advance("First")
+	bar.advance("A title that is much too long to be shown in full on the line")
+	bar.finish()
+
+	want := "\r\033[K[=====               ] 1/4 First" +
+		"\r\033[K[==========          ] 2/4 A title that is much too long to be s...\n"
+	if out.String() != want {
+		t.Errorf("progress output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestProgressNilWriter(t *testing.T) {
+	bar := newProgress(nil, 3)
+	bar.advance("ignored")
+	bar.clear()
+	bar.finish()
+}
+
+func TestRunProgressAndMessages(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{
+		Query:         "cat:cs.CL",
+		Limit:         2,
+		SaveSummaries: true,
+		Out:           &out,
+		Progress:      &out,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "] 2/2 Paper 1\n") {
+		t.Errorf("output %q lacks the final progress line", out.String())
+	}
+}
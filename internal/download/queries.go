@@ -0,0 +1,110 @@
+package download
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadQueries reads one search query per line from r, skipping blank lines
+// and lines starting with #.
+func ReadQueries(r io.Reader) ([]string, error) {
+	var queries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries: %w", err)
+	}
+	return queries, nil
+}
+
+// collectQueries runs each of opts.Queries in turn and merges the results
+// by base arXiv ID, in the order they were first found. A paper found by
+// several searches is kept once, listing all of them in Queries, and one
+// returned twice by a search, as when pages overlap, is dropped with
+// DeduplicatePapers.
+//
+// A failed search does not stop the others: the papers of the successful
+// ones are returned along with an error listing every failure. Only
+// cancelling ctx cuts the run short.
+func collectQueries(ctx context.Context, opts Options, stats *runStats, dedupe *titleDeduper) ([]ArxivPaper, error) {
+	var merged []ArxivPaper
+	var failures []error
+	index := map[string]int{}
+	onDrop := func(dropped, kept ArxivPaper) {
+		stats.duplicate(dropped, kept, DuplicateID)
+	}
+	// full reports whether LimitTotal papers have been kept.
+	full := func() bool {
+		return !opts.All && opts.LimitTotal > 0 && len(merged) >= opts.LimitTotal
+	}
+	for _, query := range opts.Queries {
+		if full() {
+			break
+		}
+		search := opts
+		search.Query = query
+		kept := 0
+		_, err := fetchPages(ctx, search, 0, func(papers []ArxivPaper, next int) (bool, error) {
+			stats.fetched += len(papers)
+			papers = search.applyFilters(papers, stats)
+
+			// Papers already found by an earlier search are merged by ID
+			// before title deduplication, which would drop them as
+			// duplicates of themselves.
+			var found []ArxivPaper
+			for _, paper := range papers {
+				if i, ok := index[BaseID(paper.ID)]; ok {
+					if !opts.All && kept >= opts.Limit {
+						break
+					}
+					merged[i].Queries = append(merged[i].Queries, query)
+					// Only another version is lost; the same
+					// paper is merged.
+					if paper.ID != merged[i].ID {
+						stats.duplicate(paper, merged[i], DuplicateID)
+					}
+					kept++
+					continue
+				}
+				found = append(found, paper)
+			}
+			if dedupe != nil {
+				before := len(found)
+				found = dedupe.filter(found)
+				stats.drop("duplicate titles", before-len(found))
+			}
+
+			for _, paper := range found {
+				if !opts.All && kept >= opts.Limit || full() {
+					break
+				}
+				paper.Queries = []string{query}
+				index[BaseID(paper.ID)] = len(merged)
+				merged = append(merged, paper)
+				kept++
+			}
+			return !opts.All && kept >= opts.Limit || full(), nil
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return deduplicatePapers(merged, onDrop), ctxErr
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("query %q: %w", query, err))
+		}
+	}
+	merged = deduplicatePapers(merged, onDrop)
+	if len(failures) > 0 {
+		return merged, fmt.Errorf("%d of %d queries failed:\n%w", len(failures), len(opts.Queries), errors.Join(failures...))
+	}
+	return merged, nil
+}
@@ -0,0 +1,174 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunMergesQueries(t *testing.T) {
+	chdirTemp(t)
+	results := map[string][]string{
+		"cat:cs.CL": {"2401.00001v1", "2401.00002v1"},
+		// A paper repeated within a search is kept once.
+		"cat:cs.LG": {"2401.00002v2", "2401.00003v1", "2401.00003v1"},
+	}
+	var searched []string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("search_query")
+		searched = append(searched, query)
+		var entries []string
+		for _, id := range results[query] {
+			entries = append(entries, fakeEntry(id, "Paper "+BaseID(id)))
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(len(entries), entries...))
+	}))
+
+	err := Run(testingContext(t), Options{Queries: []string{"cat:cs.CL", "cat:cs.LG"}, Limit: 5, SaveMetadata: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(searched, []string{"cat:cs.CL", "cat:cs.LG"}) {
+		t.Errorf("searched %v", searched)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	assertValidJSONL(t, string(content), 3)
+
+	want := map[string][]string{
+		"http://arxiv.org/abs/2401.00001v1": {"cat:cs.CL"},
+		"http://arxiv.org/abs/2401.00002v1": {"cat:cs.CL", "cat:cs.LG"},
+		"http://arxiv.org/abs/2401.00003v1": {"cat:cs.LG"},
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var record ArxivPaper
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(record.Queries, want[record.ID]) {
+			t.Errorf("%s: queries = %v, want %v", record.ID, record.Queries, want[record.ID])
+		}
+	}
+}
+
+func TestRunMergesQueriesWithTitleDedupe(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	err := Run(testingContext(t), Options{
+		Queries:            []string{"cat:cs.CL", "cat:cs.AI"},
+		Limit:              2,
+		SaveMetadata:       true,
+		DeduplicateByTitle: true,
+		TitleDistance:      DefaultTitleDistance,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	assertValidJSONL(t, string(content), 2)
+	if strings.Count(string(content), `"queries":["cat:cs.CL","cat:cs.AI"]`) != 2 {
+		t.Errorf("papers found by both searches do not list both:\n%s", content)
+	}
+}
+
+func TestReadQueries(t *testing.T) {
+	input := "# saved searches\ncat:cs.CL\n\n  all:\"graph neural network\"  \n# cat:cs.AI\nau:Doe\n"
+	got, err := ReadQueries(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadQueries() error = %v", err)
+	}
+	want := []string{"cat:cs.CL", `all:"graph neural network"`, "au:Doe"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadQueries() = %q, want %q", got, want)
+	}
+}
+
+func TestRunKeepsResultsOfSuccessfulQueries(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("search_query") == "cat:broken" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(1, fakeEntry("2401.00001v1", "Paper 1")))
+	}))
+
+	err := Run(testingContext(t), Options{Queries: []string{"cat:broken", "cat:cs.CL"}, Limit: 5, SaveMetadata: true})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 queries failed") || !strings.Contains(err.Error(), `"cat:broken"`) {
+		t.Errorf("Run() error = %v, want the failed query reported", err)
+	}
+
+	content, readErr := os.ReadFile(JSONFile)
+	if readErr != nil {
+		t.Fatalf("Failed to read metadata: %v", readErr)
+	}
+	assertValidJSONL(t, string(content), 1)
+}
+
+func TestRunPerQueryAndTotalLimits(t *testing.T) {
+	var searched []string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("search_query")
+		searched = append(searched, query)
+		var entries []string
+		for i := range 3 {
+			id := fmt.Sprintf("2401.%05dv1", 10*len(searched)+i)
+			entries = append(entries, fakeEntry(id, "Paper "+id))
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(len(entries), entries...))
+	}))
+	queries := []string{"cat:cs.CL", "cat:cs.LG", "cat:cs.AI"}
+
+	tests := []struct {
+		name         string
+		total        int
+		wantSearched []string
+		wantPerQuery map[string]int
+	}{
+		{"per query", 0, queries, map[string]int{"cat:cs.CL": 2, "cat:cs.LG": 2, "cat:cs.AI": 2}},
+		{"total caps the last search", 5, queries, map[string]int{"cat:cs.CL": 2, "cat:cs.LG": 2, "cat:cs.AI": 1}},
+		{"total reached before the last search", 4, queries[:2], map[string]int{"cat:cs.CL": 2, "cat:cs.LG": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t)
+			searched = nil
+			err := Run(testingContext(t), Options{Queries: queries, Limit: 10, PerQueryLimit: 2, LimitTotal: tt.total, SaveMetadata: true})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !slices.Equal(searched, tt.wantSearched) {
+				t.Errorf("searched %v, want %v", searched, tt.wantSearched)
+			}
+
+			records, err := ReadMetadata(JSONFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			perQuery := map[string]int{}
+			for _, record := range records {
+				perQuery[record.Queries[0]]++
+			}
+			if !reflect.DeepEqual(perQuery, tt.wantPerQuery) {
+				t.Errorf("kept %v papers per query, want %v", perQuery, tt.wantPerQuery)
+			}
+		})
+	}
+
+	if err := Run(testingContext(t), Options{Query: "graphs", Limit: 5, LimitTotal: -1}); err == nil {
+		t.Error("Run() with a negative total limit succeeded")
+	}
+}
@@ -0,0 +1,135 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// submittedDateLayout is the timestamp format of arXiv's submittedDate
+// range queries.
+const submittedDateLayout = "200601021504"
+
+// ParseDateBound parses a --from/--to value: either a YYYY-MM-DD date or a
+// relative "<N>d" meaning N days before now. Dates are interpreted in UTC,
+// which is also the timezone arXiv uses for submittedDate.
+func ParseDateBound(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid relative date %q: expected e.g. 30d", value)
+		}
+		now = now.UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return today.AddDate(0, 0, -n), nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD or a relative form like 30d", value)
+	}
+	return t, nil
+}
+
+// SubmittedDateClause returns the arXiv range clause covering whole days
+// from the day of from through the day of to, e.g.
+// "submittedDate:[202401010000 TO 202401312359]". A zero bound leaves that
+// side open.
+func SubmittedDateClause(from, to time.Time) (string, error) {
+	if from.IsZero() && to.IsZero() {
+		return "", nil
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return "", fmt.Errorf("--from %s is after --to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	lower, upper := "000001010000", "999912312359"
+	if !from.IsZero() {
+		from = from.UTC()
+		lower = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC).Format(submittedDateLayout)
+	}
+	if !to.IsZero() {
+		to = to.UTC()
+		upper = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 0, 0, time.UTC).Format(submittedDateLayout)
+	}
+	return fmt.Sprintf("submittedDate:[%s TO %s]", lower, upper), nil
+}
+
+// fieldPrefixPattern matches a word starting with one of arXiv's search
+// field prefixes, such as "ti:graph".
+var fieldPrefixPattern = regexp.MustCompile(`^(ti|au|abs|co|jr|cat|rn|all):`)
+
+// termFields are the fields whose value is a single term, such as a
+// category, rather than words.
+var termFields = map[string]bool{"cat": true, "rn": true}
+
+// queryOperatorPattern matches what makes a query more than a list of
+// words: boolean operators, grouping and quotes.
+var queryOperatorPattern = regexp.MustCompile(`\b(AND|OR|ANDNOT)\b|[()"]`)
+
+// PhraseQuery quotes the words of a multi-word query, which arXiv would
+// otherwise match independently, so that they are searched as a phrase:
+// "graph neural networks" becomes `all:"graph neural networks"` and
+// "ti:graph neural networks" becomes `ti:"graph neural networks"`. Single
+// words, queries with quotes, parentheses or boolean operators, and
+// queries that mix a field term with free words, such as
+// "cat:cs.LG deep learning", are returned as they are.
+func PhraseQuery(query string) string {
+	query = strings.TrimSpace(query)
+	if queryOperatorPattern.MatchString(query) {
+		return query
+	}
+	field, words := "all", strings.Fields(query)
+	if len(words) > 0 {
+		if m := fieldPrefixPattern.FindStringSubmatch(words[0]); m != nil {
+			field = m[1]
+			if words[0] = strings.TrimPrefix(words[0], m[0]); words[0] == "" {
+				words = words[1:]
+			}
+		}
+	}
+	if len(words) < 2 || termFields[field] {
+		return query
+	}
+	for _, word := range words {
+		if fieldPrefixPattern.MatchString(word) {
+			return query
+		}
+	}
+	return fmt.Sprintf(`%s:"%s"`, field, strings.Join(words, " "))
+}
+
+// searchQuery is the search_query sent to the API: opts.Query, quoted with
+// PhraseQuery when Phrase is set, ANDed with the submittedDate range of
+// opts.Month or of the submission dates when one is set. A RawQuery is sent
+// as it is.
+func (o Options) searchQuery() string {
+	if o.RawQuery {
+		return o.Query
+	}
+	query := o.Query
+	if o.Phrase {
+		query = PhraseQuery(query)
+	}
+	if !o.Month.IsZero() {
+		return fmt.Sprintf("(%s) AND %s", query, monthClause(o.Month))
+	}
+	clause, err := SubmittedDateClause(o.SubmittedFrom, o.SubmittedTo)
+	if err != nil || clause == "" {
+		return query
+	}
+	return fmt.Sprintf("(%s) AND %s", query, clause)
+}
+
+// validateRawQuery rejects the options that would add to a RawQuery.
+func (o Options) validateRawQuery() error {
+	if len(o.Queries) > 0 || len(o.IDs) > 0 || o.OAI != nil {
+		return fmt.Errorf("a raw query cannot be combined with several queries, ID lists or an OAI-PMH harvest")
+	}
+	if !o.SubmittedFrom.IsZero() || !o.SubmittedTo.IsZero() || !o.Month.IsZero() || o.Phrase {
+		return fmt.Errorf("a raw query is sent as it is and cannot be combined with a date range, month or phrase search")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package download
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseDateBound(t *testing.T) {
+	now := time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"30d", time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)},
+		{"0d", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateBound(tt.input, now)
+		if err != nil {
+			t.Fatalf("ParseDateBound(%q) error = %v", tt.input, err)
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("ParseDateBound(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"yesterday", "2024/01/31", "-3d", "xd"} {
+		if _, err := ParseDateBound(input, now); err == nil {
+			t.Errorf("ParseDateBound(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestSubmittedDateClause(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		expected string
+	}{
+		{"both bounds", jan1, jan31, "submittedDate:[202401010000 TO 202401312359]"},
+		{"same day", jan31, jan31, "submittedDate:[202401310000 TO 202401312359]"},
+		{"from only", jan1, time.Time{}, "submittedDate:[202401010000 TO 999912312359]"},
+		{"to only", time.Time{}, jan31, "submittedDate:[000001010000 TO 202401312359]"},
+		{"no bounds", time.Time{}, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SubmittedDateClause(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("SubmittedDateClause() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("SubmittedDateClause() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := SubmittedDateClause(jan31, jan1); err == nil {
+		t.Error("SubmittedDateClause() error = nil, want error when from is after to")
+	}
+}
+
+func TestSearchQueryAndsDateRange(t *testing.T) {
+	opts := Options{
+		Query:         "cat:cs.CL OR cat:cs.AI",
+		SubmittedFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		SubmittedTo:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	want := "(cat:cs.CL OR cat:cs.AI) AND submittedDate:[202401010000 TO 202401312359]"
+	if got := opts.searchQuery(); got != want {
+		t.Errorf("searchQuery() = %q, want %q", got, want)
+	}
+	if got := (Options{Query: "graphrag"}).searchQuery(); got != "graphrag" {
+		t.Errorf("searchQuery() = %q, want the query unchanged", got)
+	}
+}
+
+func TestRunRawQuery(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	handler := pagedFeedHandler(1)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.URL.Query().Get("search_query"))
+		mu.Unlock()
+		handler(w, r)
+	}))
+	chdirTemp(t)
+
+	queries := []string{
+		`(ti:"large language model" OR abs:LLM) ANDNOT cat:cs.CV`,
+		`au:del_maestro AND ti:"checkerboard"`,
+		`((cat:hep-th OR cat:gr-qc) AND abs:"black hole") ANDNOT ti:review`,
+		`all:"quantum  error" AND submittedDate:[202301010000 TO 202312312359]`,
+		`abs:100% & ti:a+b=c?`,
+	}
+	for _, query := range queries {
+		err := Run(testingContext(t), Options{Query: query, RawQuery: true, Limit: 1, SaveMetadata: true, Out: &strings.Builder{}})
+		if err != nil {
+			t.Fatalf("Run(%q) error = %v", query, err)
+		}
+	}
+	if !slices.Equal(received, queries) {
+		t.Errorf("search_query sent = %q, want %q", received, queries)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, opts := range []Options{
+		{Query: "cat:cs.CL", RawQuery: true, SubmittedFrom: from},
+		{Query: "cat:cs.CL", RawQuery: true, Month: from},
+		{Query: "cat:cs.CL", RawQuery: true, IDs: []string{"2401.00001"}},
+	} {
+		opts.Out = &strings.Builder{}
+		if err := Run(testingContext(t), opts); err == nil {
+			t.Errorf("Run(%+v) accepted options that change a raw query", opts)
+		}
+	}
+}
+
+func TestPhraseQuery(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"transformers", "transformers"},
+		{"cat:cs.CL", "cat:cs.CL"},
+		{"graph neural networks", `all:"graph neural networks"`},
+		{"  graph   neural networks ", `all:"graph neural networks"`},
+		{"ti:graph neural networks", `ti:"graph neural networks"`},
+		{"abs: large language model", `abs:"large language model"`},
+		{`"graph neural networks"`, `"graph neural networks"`},
+		{`ti:"graph neural" networks`, `ti:"graph neural" networks`},
+		{"ti:graph AND abs:neural networks", "ti:graph AND abs:neural networks"},
+		{"(graph networks)", "(graph networks)"},
+		{"cat:cs.LG deep learning", "cat:cs.LG deep learning"},
+		{"deep learning cat:cs.LG", "deep learning cat:cs.LG"},
+		{"au:smith graph networks cat:cs.LG", "au:smith graph networks cat:cs.LG"},
+		{"note: graph networks", `all:"note: graph networks"`},
+	}
+	for _, tt := range tests {
+		if got := PhraseQuery(tt.query); got != tt.want {
+			t.Errorf("PhraseQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRunPhrase(t *testing.T) {
+	var received string
+	handler := pagedFeedHandler(1)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query().Get("search_query")
+		handler(w, r)
+	}))
+	chdirTemp(t)
+
+	var logs strings.Builder
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := Run(testingContext(t), Options{
+		Query: "graph neural networks", Phrase: true, SubmittedFrom: from, Limit: 1, SaveMetadata: true,
+		Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})), Out: &strings.Builder{},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := `(all:"graph neural networks") AND submittedDate:[202401010000 TO 999912312359]`
+	if received != want {
+		t.Errorf("search_query = %q, want %q", received, want)
+	}
+	if !strings.Contains(logs.String(), "search_query="+strconv.Quote(want)) {
+		t.Errorf("verbose log does not show the final query:\n%s", logs.String())
+	}
+
+	if err := Run(testingContext(t), Options{Query: "graph networks", Phrase: true, RawQuery: true, Out: &strings.Builder{}}); err == nil {
+		t.Error("Run() accepted a phrase search of a raw query")
+	}
+}
@@ -0,0 +1,115 @@
+package download
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// RawDirectory holds the original Atom <entry> of each paper saved with
+// Options.StoreRawEntry.
+const RawDirectory = "raw/"
+
+// rawEntries returns the source text of every <entry> child of the feed
+// element in data, in document order. The namespace declarations of the feed
+// element are copied onto each entry so that it is well-formed on its own.
+func rawEntries(data []byte) ([][]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var entries [][]byte
+	var namespaces []xml.Attr
+	depth := 0
+	entryStart := int64(-1)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read feed: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+						namespaces = append(namespaces, attr)
+					}
+				}
+			}
+			if depth == 2 && t.Name.Local == "entry" {
+				entryStart = offset
+			}
+		case xml.EndElement:
+			if depth == 2 && entryStart >= 0 {
+				entry := data[entryStart:decoder.InputOffset()]
+				entries = append(entries, withNamespaces(entry, namespaces))
+				entryStart = -1
+			}
+			depth--
+		}
+	}
+}
+
+// withNamespaces inserts the given xmlns attributes into the start tag of
+// element, skipping those it already declares.
+func withNamespaces(element []byte, namespaces []xml.Attr) []byte {
+	nameEnd := bytes.IndexAny(element, " \t\r\n/>")
+	if nameEnd < 0 {
+		return element
+	}
+	tagEnd := bytes.IndexByte(element, '>')
+
+	var decls bytes.Buffer
+	for _, attr := range namespaces {
+		name := "xmlns"
+		if attr.Name.Space == "xmlns" {
+			name += ":" + attr.Name.Local
+		}
+		if bytes.Contains(element[:tagEnd], []byte(name+"=")) {
+			continue
+		}
+		decls.WriteString(" " + name + `="`)
+		_ = xml.EscapeText(&decls, []byte(attr.Value))
+		decls.WriteString(`"`)
+	}
+
+	out := make([]byte, 0, len(element)+decls.Len())
+	out = append(out, element[:nameEnd]...)
+	out = append(out, decls.Bytes()...)
+	return append(out, element[nameEnd:]...)
+}
+
+// writeRawEntry saves the original Atom entry of paper to path as a
+// standalone XML document.
+func writeRawEntry(paper ArxivPaper, path string) error {
+	if len(paper.rawEntry) == 0 {
+		return fmt.Errorf("no raw entry recorded for %s", paper.ID)
+	}
+	data := append([]byte(xml.Header), paper.rawEntry...)
+	data = append(data, '\n')
+	if err := removeReadOnly(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// rawEntryPath is where the raw entry of paper is stored.
+func (o Options) rawEntryPath(paper ArxivPaper) string {
+	return filepath.Join(o.path(RawDirectory), FormatFilename("{id}", paper)+".xml")
+}
+
+// DublinCoreDirectory holds the Dublin Core record of each paper saved with
+// Options.DublinCore.
+const DublinCoreDirectory = "dublincore/"
+
+// dublinCorePath is where the Dublin Core record of paper is stored.
+func (o Options) dublinCorePath(paper ArxivPaper) string {
+	return filepath.Join(o.path(DublinCoreDirectory), FormatFilename("{id}", paper)+".xml")
+}
@@ -0,0 +1,113 @@
+package download
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRawEntries(t *testing.T) {
+	feed := fakeFeed(2,
+		strings.Replace(fakeEntry("2401.00001v1", "First"), "</entry>", "<arxiv:comment>12 pages</arxiv:comment></entry>", 1),
+		fakeEntry("2401.00002v1", "Second &amp; Last"),
+	)
+
+	entries, err := rawEntries([]byte(feed))
+	if err != nil {
+		t.Fatalf("rawEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	var first Entry
+	if err := xml.Unmarshal(entries[0], &first); err != nil {
+		t.Fatalf("first entry is not well-formed: %v\n%s", err, entries[0])
+	}
+	if first.Comment.Value != "12 pages" {
+		t.Errorf("namespaced comment = %q, want %q", first.Comment.Value, "12 pages")
+	}
+	if !strings.Contains(string(entries[1]), "Second &amp; Last") {
+		t.Errorf("second entry was not kept verbatim:\n%s", entries[1])
+	}
+}
+
+func TestRunStoresRawEntries(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 3, StoreRawEntry: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for i := range 3 {
+		id := fmt.Sprintf("2401.%05dv1", i)
+		data, err := os.ReadFile(filepath.Join(RawDirectory, id+".xml"))
+		if err != nil {
+			t.Fatalf("raw entry for %s: %v", id, err)
+		}
+		if !strings.Contains(string(data), id) {
+			t.Errorf("raw entry for %s does not mention its ID:\n%s", id, data)
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(string(data)))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("raw entry for %s is not well-formed: %v", id, err)
+			}
+		}
+	}
+}
+
+func TestParseFeedKeepsRawEntry(t *testing.T) {
+	page, err := parseFeed(strings.NewReader(fakeFeed(1, fakeEntry("2401.00001v1", "Only"))))
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	raw := string(page.Papers[0].rawEntry)
+	if !strings.HasPrefix(raw, `<entry xmlns="http://www.w3.org/2005/Atom"`) || !strings.HasSuffix(raw, "</entry>") {
+		t.Errorf("raw entry = %s", raw)
+	}
+}
+
+func TestRunSavesDublinCore(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, fakeFeed(1, fakeEntry("2401.00001v1", "Graphs &amp; &lt;Trees&gt;")))
+	}))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, DublinCore: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	path := filepath.Join(DublinCoreDirectory, "2401.00001v1.xml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Dublin Core record: %v", err)
+	}
+	for _, want := range []string{
+		"<dc:title>Graphs &amp; &lt;Trees&gt;</dc:title>",
+		"<dc:identifier>https://arxiv.org/abs/2401.00001</dc:identifier>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("record lacks %q:\n%s", want, data)
+		}
+	}
+
+	records, err := ReadMetadata(JSONFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := records[0].Files[FileDublinCore]; got != filepath.ToSlash(path) {
+		t.Errorf("metadata lists the record as %q, want %q", got, path)
+	}
+}
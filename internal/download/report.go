@@ -0,0 +1,96 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// runStats counts what happened to the papers fetched during a run.
+type runStats struct {
+	fetched int
+	dropped map[string]int
+	reasons []string // in the order they were first seen
+
+	// totalResults is the number of papers matching the search, as
+	// reported by arXiv; 0 when unknown, as with several queries.
+	totalResults int
+
+	// duplicates lists the papers dropped as duplicates when
+	// reportDuplicates is set, for Options.DedupeReport.
+	duplicates       []DroppedDuplicate
+	reportDuplicates bool
+
+	// excludedIDs lists the IDs Options.IDQuery left out of the results.
+	excludedIDs []string
+}
+
+// drop records n papers filtered out for reason.
+func (s *runStats) drop(reason string, n int) {
+	if n <= 0 {
+		return
+	}
+	if s.dropped == nil {
+		s.dropped = map[string]int{}
+	}
+	if _, ok := s.dropped[reason]; !ok {
+		s.reasons = append(s.reasons, reason)
+	}
+	s.dropped[reason] += n
+}
+
+// droppedTotal is the number of papers filtered out for any reason.
+func (s *runStats) droppedTotal() int {
+	total := 0
+	for _, n := range s.dropped {
+		total += n
+	}
+	return total
+}
+
+// report prints a summary of the filtered papers, if any were dropped.
+func (s *runStats) report(opts Options) {
+	total := s.droppedTotal()
+	if total == 0 {
+		return
+	}
+	parts := make([]string, 0, len(s.reasons))
+	for _, reason := range s.reasons {
+		parts = append(parts, fmt.Sprintf("%d %s", s.dropped[reason], reason))
+	}
+	opts.printf("filtered out %d of %d fetched papers (%s)\n", total, s.fetched, strings.Join(parts, ", "))
+}
+
+// reportTotal prints how many of the matching papers the run kept, as
+// "showing 5 of 1,234 matching papers", and warns when the limit asked for
+// more papers than match. Runs printing papers to stdout, whose output
+// scripts read, print nothing.
+func (s *runStats) reportTotal(ctx context.Context, opts Options, shown int) {
+	if s.totalResults <= 0 {
+		return
+	}
+	if !opts.All && opts.Limit > s.totalResults {
+		loggerFrom(ctx).Warn("limit exceeds the number of matching papers", "limit", opts.Limit, "total_results", s.totalResults)
+	}
+	if opts.writesFiles() {
+		opts.printf("showing %s of %s matching papers\n", groupDigits(shown), groupDigits(s.totalResults))
+	}
+}
+
+// groupDigits formats n with commas between groups of three digits.
+func groupDigits(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
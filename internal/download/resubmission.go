@@ -0,0 +1,59 @@
+package download
+
+import (
+	"sort"
+
+	"github.com/AstraBert/arxiv-cli/internal/text"
+)
+
+// DefaultDuplicateThreshold is the abstract similarity above which
+// DetectDuplicateSubmissions flags a pair.
+const DefaultDuplicateThreshold = 0.85
+
+// DuplicatePair is two papers whose abstracts are suspiciously similar.
+type DuplicatePair struct {
+	A, B       ArxivPaper
+	Similarity float64 // cosine similarity of the abstracts' TF-IDF vectors
+}
+
+// DetectDuplicateSubmissions compares the abstracts of every pair of papers
+// and returns the pairs more similar than threshold, most similar first.
+// This catches the same work submitted again under a different title, which
+// DeduplicateByTitle cannot. Versions of one paper are not compared.
+func DetectDuplicateSubmissions(papers []ArxivPaper, threshold float64) []DuplicatePair {
+	abstracts := make([]string, len(papers))
+	for i, paper := range papers {
+		abstracts[i] = paper.Summary
+	}
+	vectors := text.TFIDF(abstracts)
+
+	var pairs []DuplicatePair
+	for i := range papers {
+		for j := i + 1; j < len(papers); j++ {
+			if BaseID(papers[i].ID) == BaseID(papers[j].ID) {
+				continue
+			}
+			if similarity := text.CosineSimilarity(vectors[i], vectors[j]); similarity > threshold {
+				pairs = append(pairs, DuplicatePair{A: papers[i], B: papers[j], Similarity: similarity})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Similarity > pairs[j].Similarity
+	})
+	return pairs
+}
+
+// reportDuplicateSubmissions prints the pairs found among papers.
+func reportDuplicateSubmissions(opts Options, papers []ArxivPaper) {
+	pairs := DetectDuplicateSubmissions(papers, opts.DuplicateThreshold)
+	if len(pairs) == 0 {
+		opts.printf("no duplicate submissions found\n")
+		return
+	}
+	opts.printf("possible duplicate submissions:\n")
+	for _, pair := range pairs {
+		opts.printf("  %.2f  %s  %s\n        %s  %s\n",
+			pair.Similarity, BaseID(pair.A.ID), pair.A.Title, BaseID(pair.B.ID), pair.B.Title)
+	}
+}
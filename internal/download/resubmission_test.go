@@ -0,0 +1,60 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDetectDuplicateSubmissions(t *testing.T) {
+	abstract := "We introduce a retrieval augmented generator that grounds answers in cited passages and reduces hallucination on open domain question answering."
+	papers := []ArxivPaper{
+		{ID: "2401.00001v1", Title: "Grounded Generation", Summary: abstract},
+		{ID: "2401.00002v1", Title: "Robotic Grasping", Summary: "A survey of reinforcement learning methods for robotic grasping in clutter."},
+		{ID: "2402.00003v1", Title: "Citing Passages Reduces Hallucination", Summary: abstract + " Code is available."},
+		{ID: "2401.00001v2", Title: "Grounded Generation", Summary: abstract},
+	}
+
+	pairs := DetectDuplicateSubmissions(papers, DefaultDuplicateThreshold)
+	if len(pairs) != 2 {
+		t.Fatalf("got %d pairs, want 2: %+v", len(pairs), pairs)
+	}
+	for _, pair := range pairs {
+		if BaseID(pair.A.ID) == BaseID(pair.B.ID) {
+			t.Errorf("versions of %s were flagged", BaseID(pair.A.ID))
+		}
+		if (pair.A.ID != "2402.00003v1" && pair.B.ID != "2402.00003v1") || pair.Similarity <= DefaultDuplicateThreshold || pair.Similarity > 1+1e-9 {
+			t.Errorf("unexpected pair %s / %s (%.2f)", pair.A.ID, pair.B.ID, pair.Similarity)
+		}
+	}
+
+	if pairs := DetectDuplicateSubmissions(papers, 1.01); len(pairs) != 0 {
+		t.Errorf("threshold above 1 flagged %d pairs", len(pairs))
+	}
+}
+
+func TestRunReportsDuplicateSubmissions(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := func(id, title, summary string) string {
+			return strings.Replace(fakeEntry(id, title), "Summary of "+title+".", summary, 1)
+		}
+		_, _ = fmt.Fprint(w, fakeFeed(3,
+			entry("2401.00001v1", "Sparse Attention", "We propose sparse attention for long documents, reaching linear cost."),
+			entry("2401.00002v1", "Linear Attention For Long Texts", "We propose sparse attention for long documents, reaching linear cost!"),
+			entry("2401.00003v1", "Grasping", "Robots grasp objects."),
+		))
+	}))
+
+	var out bytes.Buffer
+	opts := Options{Query: "cat:cs.CL", Limit: 3, DryRun: true, DetectDuplicateSubmissions: true, DuplicateThreshold: 0.85, Out: &out}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := "possible duplicate submissions:\n  1.00  2401.00001  Sparse Attention\n        2401.00002  Linear Attention For Long Texts\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Run() printed:\n%s\nwant it to end with:\n%s", out.String(), want)
+	}
+}
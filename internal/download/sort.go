@@ -0,0 +1,60 @@
+package download
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKey selects the field SortPapers orders by.
+type SortKey string
+
+// Sort keys accepted by SortPapers.
+const (
+	SortByPublished SortKey = "published"
+	SortByUpdated   SortKey = "updated"
+	SortByTitle     SortKey = "title"
+	SortByID        SortKey = "id"
+)
+
+// SortPapers sorts papers in place by key. Dates are compared as parsed
+// timestamps, so that differently formatted values still order correctly;
+// titles are compared ignoring case. Papers that compare equal, as papers
+// announced on the same day do by date, are ordered by arXiv ID, ascending
+// whatever the direction, so that the result does not depend on the order
+// they came in. An unknown key leaves papers untouched.
+func SortPapers(papers []ArxivPaper, key SortKey, ascending bool) {
+	var less func(a, b ArxivPaper) bool
+	switch key {
+	case SortByPublished:
+		less = func(a, b ArxivPaper) bool { return a.PublishedTime().Before(b.PublishedTime()) }
+	case SortByUpdated:
+		less = func(a, b ArxivPaper) bool { return a.UpdatedTime().Before(b.UpdatedTime()) }
+	case SortByTitle:
+		less = func(a, b ArxivPaper) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	case SortByID:
+		less = func(a, b ArxivPaper) bool { return BaseID(a.ID) < BaseID(b.ID) }
+	default:
+		return
+	}
+
+	sort.SliceStable(papers, func(i, j int) bool {
+		a, b := papers[i], papers[j]
+		if !ascending {
+			a, b = b, a
+		}
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		return idLess(papers[i], papers[j])
+	})
+}
+
+// idLess orders papers by base arXiv ID, then by version.
+func idLess(a, b ArxivPaper) bool {
+	if baseA, baseB := BaseID(a.ID), BaseID(b.ID); baseA != baseB {
+		return baseA < baseB
+	}
+	_, versionA := splitArxivID(a.ID)
+	_, versionB := splitArxivID(b.ID)
+	return versionA < versionB
+}
@@ -0,0 +1,75 @@
+package download
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortPapers(t *testing.T) {
+	papers := func() []ArxivPaper {
+		return []ArxivPaper{
+			{ID: "http://arxiv.org/abs/2402.00002v1", Title: "beta", Published: "2024-02-01T00:00:00Z", Updated: "2024-02-01T00:00:00Z"},
+			{ID: "http://arxiv.org/abs/2401.00009v3", Title: "Alpha", Published: "2024-01-15T00:00:00Z", Updated: "2024-03-01T00:00:00Z"},
+			{ID: "http://arxiv.org/abs/2401.00010v1", Title: "gamma", Published: "2024-01-15T00:00:00+00:00", Updated: "2024-01-20T00:00:00Z"},
+		}
+	}
+
+	tests := []struct {
+		key       SortKey
+		ascending bool
+		want      string
+	}{
+		{SortByPublished, true, "Alpha,gamma,beta"},
+		{SortByPublished, false, "beta,Alpha,gamma"},
+		{SortByUpdated, true, "gamma,beta,Alpha"},
+		{SortByTitle, true, "Alpha,beta,gamma"},
+		{SortByTitle, false, "gamma,beta,Alpha"},
+		{SortByID, true, "Alpha,gamma,beta"},
+	}
+	for _, tt := range tests {
+		sorted := papers()
+		SortPapers(sorted, tt.key, tt.ascending)
+		var titles []string
+		for _, paper := range sorted {
+			titles = append(titles, paper.Title)
+		}
+		if got := strings.Join(titles, ","); got != tt.want {
+			t.Errorf("SortPapers(%s, %v) = %s, want %s", tt.key, tt.ascending, got, tt.want)
+		}
+	}
+
+	unsorted := papers()
+	SortPapers(unsorted, "citations", true)
+	if unsorted[0].Title != "beta" || unsorted[2].Title != "gamma" {
+		t.Error("SortPapers() reordered papers for an unknown key")
+	}
+}
+
+func TestSortPapersOrdersEqualKeysByID(t *testing.T) {
+	day := "2024-01-15T00:00:00Z"
+	papers := []ArxivPaper{
+		{ID: "http://arxiv.org/abs/2401.00010v1", Title: "Same", Published: day},
+		{ID: "http://arxiv.org/abs/2401.00002v2", Title: "Same", Published: day},
+		{ID: "http://arxiv.org/abs/2312.00099v1", Title: "Same", Published: "2024-01-15T00:00:00+00:00"},
+		{ID: "http://arxiv.org/abs/2401.00002v1", Title: "Same", Published: day},
+		{ID: "http://arxiv.org/abs/2401.00005v1", Title: "Same", Published: day},
+	}
+	want := "2312.00099v1,2401.00002v1,2401.00002v2,2401.00005v1,2401.00010v1"
+
+	for _, key := range []SortKey{SortByPublished, SortByTitle} {
+		for _, ascending := range []bool{true, false} {
+			// Every rotation of the input sorts the same.
+			for shift := range papers {
+				sorted := append(append([]ArxivPaper{}, papers[shift:]...), papers[:shift]...)
+				SortPapers(sorted, key, ascending)
+				var ids []string
+				for _, paper := range sorted {
+					ids = append(ids, strings.TrimPrefix(paper.ID, "http://arxiv.org/abs/"))
+				}
+				if got := strings.Join(ids, ","); got != want {
+					t.Errorf("SortPapers(%s, %v) of rotation %d = %s, want %s", key, ascending, shift, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+)
+
+// SourceDirectory holds the e-print sources saved with Options.SaveSources.
+const SourceDirectory = "sources/"
+
+// eprintBaseURL serves paper sources by arXiv ID; tests point it at a local
+// server.
+var eprintBaseURL = "https://arxiv.org/e-print/"
+
+// FetchSource downloads the e-print source of the latest version of p,
+// usually a gzipped tar of the LaTeX files, and writes the bytes as
+// received to outPath with an extension added after the content type:
+// ".tar.gz" for gzip, ".pdf" for papers submitted as PDF, none otherwise.
+// outPath is taken as a base name even when it has a dot, as arXiv IDs do.
+func (p *ArxivPaper) FetchSource(ctx context.Context, outPath string) error {
+	// Ask for no transfer encoding, so that the client cannot transparently
+	// decompress what is saved.
+	header := http.Header{"Accept-Encoding": {"identity"}}
+	resp, err := httpGet(ctx, eprintBaseURL+BaseID(p.ID), header)
+	if err != nil {
+		return fmt.Errorf("failed to fetch source: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch source: HTTP %d", resp.StatusCode)
+	}
+
+	outPath += sourceExtension(resp.Header.Get("Content-Type"))
+
+	file, err := createFile(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	_, err = io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(outPath)
+		return fmt.Errorf("failed to write source: %w", err)
+	}
+
+	return nil
+}
+
+// sourceExtension picks the file extension for an e-print of contentType.
+func sourceExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/gzip", "application/x-gzip", "application/x-eprint-tar":
+		return ".tar.gz"
+	case "application/pdf":
+		return ".pdf"
+	}
+	return ""
+}
+
+// sourceExtensions are the extensions sourceExtension adds.
+var sourceExtensions = []string{".tar.gz", ".pdf", ""}
+
+// sourceExists reports whether a source was already saved at base, under
+// any of sourceExtensions.
+func sourceExists(base string) bool {
+	return savedSource(base) != ""
+}
+
+// savedSource returns the file a source was saved to at base, under any of
+// sourceExtensions, or "" if there is none.
+func savedSource(base string) string {
+	for _, ext := range sourceExtensions {
+		if fileExists(base + ext) {
+			return base + ext
+		}
+	}
+	return ""
+}
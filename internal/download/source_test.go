@@ -0,0 +1,112 @@
+package download
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func useFakeEprints(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	old := eprintBaseURL
+	eprintBaseURL = server.URL + "/e-print/"
+	t.Cleanup(func() {
+		eprintBaseURL = old
+		server.Close()
+	})
+}
+
+func TestRunSavesSources(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	_, _ = gz.Write([]byte("\\documentclass{article}"))
+	_ = gz.Close()
+
+	var requested []string
+	useFakeEprints(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/e-print/2401.00001" {
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		_, _ = w.Write(tarball.Bytes())
+	}))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveSources: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(requested) != 2 || requested[0] != "/e-print/2401.00000" {
+		t.Errorf("requested %v, want the versionless IDs", requested)
+	}
+	data, err := os.ReadFile(filepath.Join(SourceDirectory, "Paper 0.tar.gz"))
+	if err != nil {
+		t.Fatalf("source: %v", err)
+	}
+	if !bytes.Equal(data, tarball.Bytes()) {
+		t.Error("source was not saved as received")
+	}
+	if _, err := os.Stat(filepath.Join(SourceDirectory, "Paper 1.pdf")); err != nil {
+		t.Errorf("PDF-only source: %v", err)
+	}
+
+	// Sources already on disk are found whatever their extension.
+	requested = nil
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 2, SaveSources: true, SkipExisting: true}); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if len(requested) != 0 {
+		t.Errorf("refetched existing sources %v", requested)
+	}
+}
+
+func TestRunSavesSourcesNamedByID(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(2))
+	useFakeEprints(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-eprint-tar")
+		_, _ = w.Write([]byte("tar"))
+	}))
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveSources: true, FilenameTemplate: "{id}"}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, name := range []string{"2401.00000v1.tar.gz", "2401.00001v1.tar.gz"} {
+		if _, err := os.Stat(filepath.Join(SourceDirectory, name)); err != nil {
+			t.Errorf("source named by ID: %v", err)
+		}
+	}
+
+	opts.SkipExisting = true
+	useFakeEprints(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("refetched existing source %s", r.URL.Path)
+	}))
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+}
+
+func TestFetchSourceHTTPError(t *testing.T) {
+	useFakeEprints(t, http.NotFoundHandler())
+
+	paper := ArxivPaper{ID: "http://arxiv.org/abs/2401.00001v1"}
+	out := filepath.Join(t.TempDir(), "source")
+	if err := paper.FetchSource(testingContext(t), out); err == nil {
+		t.Fatal("FetchSource() succeeded on a 404")
+	}
+	if matches, _ := filepath.Glob(out + "*"); len(matches) != 0 {
+		t.Errorf("FetchSource() left %v behind", matches)
+	}
+}
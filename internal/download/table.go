@@ -0,0 +1,95 @@
+package download
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// tableTitleWidth bounds the title column of the table view.
+const tableTitleWidth = 60
+
+// paperTable renders papers as aligned columns. Rows are buffered by the
+// tabwriter until flush so that every column can be sized to fit.
+type paperTable struct {
+	tw            *tabwriter.Writer
+	abstractWidth int
+	wrap          bool
+}
+
+func newPaperTable(opts Options) *paperTable {
+	t := &paperTable{
+		tw:            tabwriter.NewWriter(opts.out(), 0, 0, 2, ' ', 0),
+		abstractWidth: opts.AbstractWidth,
+		wrap:          opts.WrapAbstract,
+	}
+	header := "ID\tPUBLISHED\tCATEGORY\tTITLE"
+	if t.abstractWidth > 0 {
+		header += "\tABSTRACT"
+	}
+	_, _ = fmt.Fprintln(t.tw, header)
+	return t
+}
+
+// row adds paper to the table. A wrapped abstract continues on further rows
+// whose other cells are empty, which keeps the columns aligned.
+func (t *paperTable) row(paper ArxivPaper) {
+	published := paper.Published
+	if date := paper.PublishedTime(); !date.IsZero() {
+		published = date.Format("2006-01-02")
+	}
+	cells := []string{versionedID(splitArxivID(paper.ID)), published, paper.PrimaryCategory, truncate(paper.Title, tableTitleWidth)}
+	if t.abstractWidth <= 0 {
+		_, _ = fmt.Fprintln(t.tw, strings.Join(cells, "\t"))
+		return
+	}
+
+	abstract := collapseWhitespace(paper.Summary)
+	if !t.wrap {
+		_, _ = fmt.Fprintln(t.tw, strings.Join(append(cells, truncate(abstract, t.abstractWidth)), "\t"))
+		return
+	}
+	lines := wrapText(abstract, t.abstractWidth)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	_, _ = fmt.Fprintln(t.tw, strings.Join(append(cells, lines[0]), "\t"))
+	for _, line := range lines[1:] {
+		_, _ = fmt.Fprintln(t.tw, "\t\t\t\t"+line)
+	}
+}
+
+func (t *paperTable) flush() error {
+	return t.tw.Flush()
+}
+
+// wrapText breaks s into lines of at most width runes at spaces, splitting
+// words longer than width.
+func wrapText(s string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		switch {
+		case len(line) == 0:
+			line = w
+		case len(line)+1+len(w) <= width:
+			line = append(append(line, ' '), w...)
+		default:
+			lines = append(lines, string(line))
+			line = w
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
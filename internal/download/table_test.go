@@ -0,0 +1,78 @@
+package download
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func tableFeedHandler(w http.ResponseWriter, r *http.Request) {
+	long := strings.Replace(fakeEntry("2401.00001v2", "A Longer Title"),
+		"Summary of A Longer Title.", "We study how transformers generalize beyond their training distribution.", 1)
+	_, _ = w.Write([]byte(fakeFeed(2, fakeEntry("2401.00000v1", "Short"), long)))
+}
+
+func TestRunTableTruncatesAbstract(t *testing.T) {
+	dir := chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(tableFeedHandler))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "all:x", Limit: 2, SaveMetadata: true, Table: true, AbstractWidth: 20, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("table has %d lines, want 3:\n%s", len(lines), out.String())
+	}
+	column := strings.Index(lines[0], "ABSTRACT")
+	for _, line := range lines[1:] {
+		if cell := line[column:]; len([]rune(cell)) > 20 {
+			t.Errorf("abstract cell %q is wider than 20", cell)
+		}
+	}
+	if got := lines[2][column:]; got != "We study how tran..." {
+		t.Errorf("truncated abstract = %q", got)
+	}
+	if !strings.HasPrefix(lines[2], "2401.00001v2  2024-01-01  cs.CL") {
+		t.Errorf("row = %q", lines[2])
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("table mode created %d files or directories, want none", len(entries))
+	}
+}
+
+func TestRunTableWrapsAbstract(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, http.HandlerFunc(tableFeedHandler))
+
+	var out strings.Builder
+	err := Run(testingContext(t), Options{Query: "all:x", Limit: 2, Table: true, AbstractWidth: 20, WrapAbstract: true, Out: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	column := strings.Index(lines[0], "ABSTRACT")
+	var wrapped []string
+	for _, line := range lines[2:] {
+		if strings.TrimSpace(line[:column]) != "" && len(wrapped) > 0 {
+			t.Errorf("continuation row has cells outside the abstract column: %q", line)
+		}
+		wrapped = append(wrapped, line[column:])
+	}
+	want := []string{"We study how", "transformers", "generalize beyond", "their training", "distribution."}
+	if strings.Join(wrapped, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapped abstract = %q, want %q", wrapped, want)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("a supercalifragilistic word", 8)
+	want := []string{"a", "supercal", "ifragili", "stic", "word"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText() = %q, want %q", got, want)
+	}
+}
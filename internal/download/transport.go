@@ -0,0 +1,244 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHostLimits caps the number of concurrent requests per host when no
+// other limit is configured for it; hosts not listed are unlimited.
+var DefaultHostLimits = map[string]int{
+	"arxiv.org": 4,
+}
+
+// httpTransport is the RoundTripper shared by every HTTP client in the
+// package, so that per-host limits hold across all of a run's requests. It
+// honors the proxy set with SetProxy.
+var httpTransport = newHostLimitTransport(newProxyTransport(nil), DefaultHostLimits)
+
+// newProxyTransport returns a transport sending every request through
+// proxy, or through the proxy named by the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables when proxy is nil.
+func newProxyTransport(proxy *url.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport
+}
+
+// SetProxy routes every request (API queries, PDF and source downloads and
+// lookups alike) through the proxy at rawURL, such as
+// "http://proxy.example.com:3128". An empty rawURL restores the default of
+// using the proxy from the environment. It should be called before any
+// request is made.
+func SetProxy(rawURL string) error {
+	var proxy *url.URL
+	if rawURL != "" {
+		var err error
+		proxy, err = url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy URL %q (expected an http, https or socks5 URL)", rawURL)
+		}
+		if proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: no host", rawURL)
+		}
+	}
+	httpTransport.next = newProxyTransport(proxy)
+	return nil
+}
+
+// userAgent identifies the tool on every request, as arXiv asks API clients
+// to; see SetUserAgent.
+var userAgent = "arxiv-cli (+https://github.com/AstraBert/arxiv-cli)"
+
+// SetUserAgent sets the User-Agent sent with every request to name the
+// tool version and, when contactEmail is set, a mailto address arXiv can
+// reach the user at. It should be called before any request is made.
+func SetUserAgent(version, contactEmail string) {
+	agent := "arxiv-cli"
+	if version != "" {
+		agent += "/" + version
+	}
+	agent += " (+https://github.com/AstraBert/arxiv-cli"
+	if contactEmail != "" {
+		agent += "; mailto:" + contactEmail
+	}
+	userAgent = agent + ")"
+}
+
+// newHTTPClient returns a client using the shared transport.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: auditTransport{next: httpTransport},
+	}
+}
+
+// httpGet sends a GET request for rawURL with the given headers through the
+// shared client.
+func httpGet(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	return httpDo(ctx, "GET", rawURL, header, nil)
+}
+
+// httpDo sends a request through the shared client. Every outbound call in
+// the package goes through here, so that none can be made without a context
+// to cancel it by, and all of them carry the User-Agent.
+func httpDo(ctx context.Context, method, rawURL string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	logger := loggerFrom(ctx)
+	logger.Debug("sending request", "method", method, "url", rawURL)
+	started := time.Now()
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("request failed", "method", method, "url", rawURL, "error", err)
+		}
+		return nil, err
+	}
+	logger.Debug("received response", "url", rawURL, "status", resp.StatusCode, "duration", time.Since(started))
+	return resp, nil
+}
+
+// SetHostLimits configures the per-host concurrency limits of the shared
+// transport. Limits are merged over DefaultHostLimits; a limit of 0 lifts
+// the cap for that host.
+func SetHostLimits(limits map[string]int) {
+	merged := make(map[string]int, len(DefaultHostLimits)+len(limits))
+	for host, n := range DefaultHostLimits {
+		merged[host] = n
+	}
+	for host, n := range limits {
+		merged[host] = n
+	}
+	httpTransport.setLimits(merged)
+}
+
+// ParseHostLimits parses a comma-separated list of host=n pairs, as in
+// "arxiv.org=4,api.semanticscholar.org=1".
+func ParseHostLimits(value string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		host, n, ok := strings.Cut(pair, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !ok || host == "" {
+			return nil, fmt.Errorf("invalid host limit %q (expected host=n)", pair)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit for %s: %q", host, n)
+		}
+		limits[host] = limit
+	}
+	return limits, nil
+}
+
+// hostLimitTransport bounds the number of in-flight requests per host. A
+// request holds its slot until its response body is closed, so streamed
+// downloads count for as long as they last.
+type hostLimitTransport struct {
+	next http.RoundTripper
+
+	mu     sync.Mutex
+	limits map[string]int
+	slots  map[string]chan struct{}
+}
+
+func newHostLimitTransport(next http.RoundTripper, limits map[string]int) *hostLimitTransport {
+	t := &hostLimitTransport{next: next}
+	t.setLimits(limits)
+	return t
+}
+
+func (t *hostLimitTransport) setLimits(limits map[string]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits = limits
+	t.slots = map[string]chan struct{}{}
+}
+
+// slotsFor returns the semaphore governing requests to host (with or
+// without port), or nil when the host is unlimited. A configured domain
+// also covers its subdomains; the most specific match wins.
+func (t *hostLimitTransport) slotsFor(hostport, host string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := ""
+	for domain := range t.limits {
+		matches := false
+		for _, name := range []string{strings.ToLower(hostport), strings.ToLower(host)} {
+			if name == domain || strings.HasSuffix(name, "."+domain) {
+				matches = true
+			}
+		}
+		if matches && len(domain) > len(key) {
+			key = domain
+		}
+	}
+	if key == "" || t.limits[key] <= 0 {
+		return nil
+	}
+	if _, ok := t.slots[key]; !ok {
+		t.slots[key] = make(chan struct{}, t.limits[key])
+	}
+	return t.slots[key]
+}
+
+func (t *hostLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots := t.slotsFor(req.URL.Host, req.URL.Hostname())
+	if slots == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-slots })
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees a host slot when the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
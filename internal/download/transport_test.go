@@ -0,0 +1,244 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyServer records the peak number of simultaneous requests. It
+// answers each with a minimal PDF.
+type concurrencyServer struct {
+	*httptest.Server
+	inFlight, peak atomic.Int32
+}
+
+func newConcurrencyServer(t *testing.T) *concurrencyServer {
+	s := &concurrencyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			peak := s.peak.Load()
+			if n <= peak || s.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		_, _ = w.Write([]byte("%PDF-1.5\n"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestHostLimitTransport(t *testing.T) {
+	pdfs := newConcurrencyServer(t)
+	scholar := newConcurrencyServer(t)
+	other := newConcurrencyServer(t)
+
+	host := func(s *concurrencyServer) string {
+		u, _ := url.Parse(s.URL)
+		return u.Host
+	}
+	client := &http.Client{Transport: newHostLimitTransport(http.DefaultTransport, map[string]int{
+		host(pdfs):    3,
+		host(scholar): 1,
+	})}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		for _, s := range []*concurrencyServer{pdfs, scholar, other} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(s.URL)
+				if err != nil {
+					t.Errorf("GET %s: %v", s.URL, err)
+					return
+				}
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}()
+		}
+	}
+	wg.Wait()
+
+	if peak := pdfs.peak.Load(); peak > 3 {
+		t.Errorf("limited host saw %d concurrent requests, want at most 3", peak)
+	}
+	if peak := scholar.peak.Load(); peak != 1 {
+		t.Errorf("single-slot host saw %d concurrent requests, want 1", peak)
+	}
+	if peak := other.peak.Load(); peak <= 3 {
+		t.Errorf("unlimited host peaked at %d concurrent requests, want it not to be capped", peak)
+	}
+}
+
+func TestRunHostLimitsCapConcurrentDownloads(t *testing.T) {
+	pdfs := newConcurrencyServer(t)
+	var entries []string
+	for i := range 8 {
+		entries = append(entries, fakeEntry(fmt.Sprintf("2401.0000%dv1", i), fmt.Sprintf("Paper %d", i)))
+	}
+	feed := strings.ReplaceAll(fakeFeed(len(entries), entries...), "http://arxiv.org/pdf/", pdfs.URL+"/pdf/")
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, feed)
+	}))
+	chdirTemp(t)
+
+	u, _ := url.Parse(pdfs.URL)
+	SetHostLimits(map[string]int{u.Host: 2})
+	t.Cleanup(func() { SetHostLimits(nil) })
+
+	for _, tt := range []struct {
+		concurrency int
+		want        int32
+	}{
+		{1, 1},
+		{4, 2},
+	} {
+		pdfs.peak.Store(0)
+		err := Run(testingContext(t), Options{
+			Query:        "cat:cs.CL",
+			Limit:        len(entries),
+			SavePDFs:     true,
+			SaveMetadata: true,
+			Concurrency:  tt.concurrency,
+			OutputDir:    fmt.Sprint(tt.concurrency),
+			Out:          &strings.Builder{},
+		})
+		if err != nil {
+			t.Fatalf("Run() with concurrency %d error = %v", tt.concurrency, err)
+		}
+		if peak := pdfs.peak.Load(); peak != tt.want {
+			t.Errorf("concurrency %d: PDF host saw %d concurrent requests, want %d", tt.concurrency, peak, tt.want)
+		}
+
+		// The metadata keeps the order of the search results.
+		papers, err := ReadMetadata(filepath.Join(fmt.Sprint(tt.concurrency), JSONFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, paper := range papers {
+			titles = append(titles, paper.Title)
+			if paper.Files[FilePDF] == "" {
+				t.Errorf("concurrency %d: %s has no PDF recorded", tt.concurrency, paper.Title)
+			}
+		}
+		if got, want := strings.Join(titles, ","), "Paper 0,Paper 1,Paper 2,Paper 3,Paper 4,Paper 5,Paper 6,Paper 7"; got != want {
+			t.Errorf("concurrency %d: metadata order = %s, want %s", tt.concurrency, got, want)
+		}
+	}
+}
+
+func TestHostLimitTransportSubdomains(t *testing.T) {
+	transport := newHostLimitTransport(http.DefaultTransport, map[string]int{
+		"arxiv.org":        4,
+		"export.arxiv.org": 1,
+		"example.com":      0,
+	})
+
+	tests := []struct {
+		host string
+		want int // capacity of the governing semaphore, -1 if unlimited
+	}{
+		{"arxiv.org", 4},
+		{"www.arxiv.org", 4},
+		{"export.arxiv.org", 1},
+		{"notarxiv.org", -1},
+		{"example.com", -1},
+	}
+	for _, tt := range tests {
+		got := -1
+		if slots := transport.slotsFor(tt.host, tt.host); slots != nil {
+			got = cap(slots)
+		}
+		if got != tt.want {
+			t.Errorf("limit for %s = %d, want %d", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestParseHostLimits(t *testing.T) {
+	limits, err := ParseHostLimits("arxiv.org=4, API.SemanticScholar.org=1,")
+	if err != nil {
+		t.Fatalf("ParseHostLimits() error = %v", err)
+	}
+	if len(limits) != 2 || limits["arxiv.org"] != 4 || limits["api.semanticscholar.org"] != 1 {
+		t.Errorf("ParseHostLimits() = %v", limits)
+	}
+
+	for _, value := range []string{"arxiv.org", "=2", "arxiv.org=x", "arxiv.org=-1"} {
+		if _, err := ParseHostLimits(value); err == nil {
+			t.Errorf("ParseHostLimits(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestRequestsCarryUserAgent(t *testing.T) {
+	old := userAgent
+	t.Cleanup(func() { userAgent = old })
+	SetUserAgent("1.2.3", "jane@example.org")
+
+	var agents []string
+	server := useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if strings.HasPrefix(r.URL.Path, "/pdf") {
+			_, _ = w.Write([]byte("%PDF-1.4"))
+			return
+		}
+		_, _ = w.Write([]byte(fakeFeed(0)))
+	}))
+
+	if _, err := fetchArxivPapers(testingContext(t), "all:x", 0, 1); err != nil {
+		t.Fatalf("fetchArxivPapers() error = %v", err)
+	}
+	paper := ArxivPaper{PDFURL: server.URL + "/pdf/2401.00001v1"}
+	if err := paper.FetchPDF(testingContext(t), filepath.Join(t.TempDir(), "paper.pdf")); err != nil {
+		t.Fatalf("FetchPDF() error = %v", err)
+	}
+
+	want := "arxiv-cli/1.2.3 (+https://github.com/AstraBert/arxiv-cli; mailto:jane@example.org)"
+	if len(agents) != 2 || agents[0] != want || agents[1] != want {
+		t.Errorf("User-Agents = %q, want %q on both requests", agents, want)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy sees the absolute URL of the target.
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	}))
+	defer proxy.Close()
+
+	old := httpTransport.next
+	t.Cleanup(func() { httpTransport.next = old })
+	if err := SetProxy(proxy.URL); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+
+	paper := ArxivPaper{PDFURL: "http://arxiv.invalid/pdf/2401.00001v1"}
+	if err := paper.FetchPDF(testingContext(t), filepath.Join(t.TempDir(), "paper.pdf")); err != nil {
+		t.Fatalf("FetchPDF() error = %v", err)
+	}
+	if len(proxied) != 1 || proxied[0] != paper.PDFURL {
+		t.Errorf("proxy saw %q, want the PDF request", proxied)
+	}
+
+	for _, bad := range []string{"ftp://proxy:21", "http://", "://x"} {
+		if err := SetProxy(bad); err == nil {
+			t.Errorf("SetProxy(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AstraBert/arxiv-cli/internal/term"
+)
+
+// printTree prints the metadata files of the run and the PDFs and summaries
+// it wrote as trees (see term.PrintTree). Files left by earlier runs, such
+// as those SkipExisting kept, are left out.
+func printTree(opts Options, metadataFiles []string, written []artifact) error {
+	groups := []term.Group{{Title: "metadata"}, {Title: "PDFs"}, {Title: "summaries"}}
+	for _, path := range metadataFiles {
+		node, err := term.Walk(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list written files: %w", err)
+		}
+		groups[0].Nodes = append(groups[0].Nodes, node)
+	}
+
+	for _, g := range []struct {
+		group     *term.Group
+		dir, kind string
+	}{
+		{&groups[1], opts.pdfDir(), FilePDF},
+		{&groups[2], opts.textDir(), FileSummary},
+	} {
+		var paths []string
+		for _, a := range written {
+			if a.kind == g.kind {
+				paths = append(paths, a.path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		node, err := term.Files(g.dir, paths)
+		if err != nil {
+			return fmt.Errorf("failed to list written files: %w", err)
+		}
+		g.group.Nodes = []*term.Node{node}
+	}
+
+	opts.bar.clear()
+	if err := term.PrintTree(opts.out(), groups); err != nil {
+		return fmt.Errorf("failed to print file tree: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunPrintTree(t *testing.T) {
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, fakeFeed(2, fakeEntry("2401.00001v1", "First"), fakeEntry("2401.00002v1", "Second")))
+	}))
+	chdirTemp(t)
+	// A file from an earlier run is not part of this run's tree.
+	if err := os.MkdirAll(TextDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(TextDirectory, "Earlier.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	opts := Options{Query: "graphs", Limit: 2, SaveMetadata: true, SaveSummaries: true, PrintTree: true, Out: &out}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"metadata\n└── " + JSONFile + " (", "summaries\n└── texts/\n    ├── First.txt (", "    └── Second.txt (", "\n3 file(s)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Run() printed %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "Earlier.txt") {
+		t.Errorf("Run() printed %q, want no files it did not write", got)
+	}
+	if strings.Contains(got, "PDFs") {
+		t.Errorf("Run() printed %q, want no PDFs group without SavePDFs", got)
+	}
+}
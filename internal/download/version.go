@@ -0,0 +1,42 @@
+package download
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// pinVersion returns ids with every ID asking for version, rejecting IDs
+// that already name a different one.
+func pinVersion(ids []string, version int) ([]string, error) {
+	pinned := make([]string, len(ids))
+	for i, id := range ids {
+		base, v := splitArxivID(id)
+		if v != 0 && v != version {
+			return nil, fmt.Errorf("%s asks for version %d, not %d", id, v, version)
+		}
+		pinned[i] = versionedID(base, version)
+	}
+	return pinned, nil
+}
+
+// resolveVersion checks that paper is the requested version and points its
+// PDF and abstract URLs at that version, so that later revisions are not
+// downloaded instead.
+func resolveVersion(paper *ArxivPaper, requested int) error {
+	if paper.Version != requested {
+		return fmt.Errorf("arXiv returned version %d of %s instead of version %d", paper.Version, BaseID(paper.ID), requested)
+	}
+	paper.RequestedVersion = requested
+	paper.PDFURL = withVersion(paper.PDFURL, requested)
+	paper.HTMLURL = withVersion(paper.HTMLURL, requested)
+	return nil
+}
+
+// withVersion replaces the version at the end of an arXiv URL, adding one
+// if it has none. An empty URL stays empty.
+func withVersion(rawURL string, version int) string {
+	if rawURL == "" {
+		return ""
+	}
+	return versionSuffix.ReplaceAllString(rawURL, "") + "v" + strconv.Itoa(version)
+}
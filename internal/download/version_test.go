@@ -0,0 +1,85 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseFeedRecordsVersion(t *testing.T) {
+	page, err := parseFeed(strings.NewReader(fakeFeed(4,
+		fakeEntry("2401.00001v3", "New"), fakeEntry("hep-th/9901001v1", "Old"),
+		fakeEntry("cs/0112017v2", "Old with version 2"), fakeEntry("2401.00002", "Unversioned"))))
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	want := []struct {
+		arxivID string
+		version int
+	}{{"2401.00001", 3}, {"hep-th/9901001", 1}, {"cs/0112017", 2}, {"2401.00002", 1}}
+	for i, paper := range page.Papers {
+		if paper.ArxivID != want[i].arxivID || paper.Version != want[i].version {
+			t.Errorf("%s: ArxivID, Version = %q, %d, want %q, %d", paper.ID, paper.ArxivID, paper.Version, want[i].arxivID, want[i].version)
+		}
+	}
+}
+
+func TestRunPaperVersion(t *testing.T) {
+	var requested string
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Query().Get("id_list")
+		// The feed's links name no version, as for some entries.
+		entry := strings.ReplaceAll(fakeEntry(requested, "Versioned"), "arxiv.org/pdf/"+requested, "arxiv.org/pdf/2401.12345")
+		_, _ = fmt.Fprint(w, fakeFeed(1, entry))
+	}))
+	chdirTemp(t)
+
+	err := Run(testingContext(t), Options{IDs: []string{"2401.12345"}, PaperVersion: 1, SaveMetadata: true, Out: &strings.Builder{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if requested != "2401.12345v1" {
+		t.Errorf("id_list = %q, want 2401.12345v1", requested)
+	}
+	content, err := os.ReadFile(JSONFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"pdf_url":"http://arxiv.org/pdf/2401.12345v1"`, `"html_url":"http://arxiv.org/abs/2401.12345v1"`, `"arxiv_id":"2401.12345"`, `"version":1`, `"requested_version":1`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("metadata lacks %s:\n%s", want, content)
+		}
+	}
+}
+
+func TestRunPaperVersionMustExist(t *testing.T) {
+	tests := map[string]string{
+		"missing":       fakeFeed(0),
+		"other version": fakeFeed(1, fakeEntry("2401.12345v3", "Latest")),
+	}
+	for name, feed := range tests {
+		t.Run(name, func(t *testing.T) {
+			useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, feed)
+			}))
+			chdirTemp(t)
+
+			err := Run(testingContext(t), Options{IDs: []string{"2401.12345"}, PaperVersion: 7, SaveMetadata: true, Out: &strings.Builder{}})
+			if err == nil || !strings.Contains(err.Error(), "version") {
+				t.Errorf("Run() error = %v, want one about the missing version", err)
+			}
+		})
+	}
+}
+
+func TestPinVersion(t *testing.T) {
+	ids, err := pinVersion([]string{"2401.00001", "hep-th/9901001v2"}, 2)
+	if err != nil || strings.Join(ids, ",") != "2401.00001v2,hep-th/9901001v2" {
+		t.Errorf("pinVersion() = %q, %v", ids, err)
+	}
+	if _, err := pinVersion([]string{"2401.00001v1"}, 2); err == nil {
+		t.Error("pinVersion() accepted an ID naming another version")
+	}
+}
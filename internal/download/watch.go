@@ -0,0 +1,318 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/arxiv-cli/internal/names"
+)
+
+// AuthorStateFile keeps, per watched author, the papers already reported.
+const AuthorStateFile = ".arxiv-cli-authors.json"
+
+// orcidAPIBase is the public ORCID API used to derive name variants.
+var orcidAPIBase = "https://pub.orcid.org/v3.0"
+
+// WatchedAuthor is a person to poll arXiv for. Name labels the hits; any
+// Variants (for example from an ORCID record) are searched and matched too.
+type WatchedAuthor struct {
+	Name     string
+	Variants []string
+}
+
+// names returns the primary name followed by its variants.
+func (a WatchedAuthor) names() []string {
+	return append([]string{a.Name}, a.Variants...)
+}
+
+// AuthorWatchOptions configures PollAuthors and WatchAuthors.
+type AuthorWatchOptions struct {
+	Authors     []WatchedAuthor
+	Limit       int  // papers fetched per name per poll
+	StrictMatch bool // require an exact normalized name match
+	StatePath   string
+	Interval    time.Duration
+	Flush       FlushPolicy // when WatchAuthors writes metadata to disk
+	FileMode    os.FileMode // of the metadata and state files; see Options.FileMode
+	Out         io.Writer
+}
+
+// AuthorHit is a newly seen paper together with the watched authors whose
+// names appear on it.
+type AuthorHit struct {
+	Paper   ArxivPaper
+	Authors []string
+}
+
+// AuthorWatchState records the base arXiv IDs already reported per author.
+type AuthorWatchState struct {
+	Seen map[string][]string `json:"seen"`
+}
+
+// LoadAuthorWatchState reads the state file at path; a missing file yields
+// an empty state.
+func LoadAuthorWatchState(path string) (*AuthorWatchState, error) {
+	state := &AuthorWatchState{Seen: map[string][]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read author watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse author watch state: %w", err)
+	}
+	if state.Seen == nil {
+		state.Seen = map[string][]string{}
+	}
+	return state, nil
+}
+
+// Save writes the state to path.
+func (s *AuthorWatchState) Save(path string) error {
+	return s.save(Options{}, path)
+}
+
+// save writes the state to path with the file mode of opts.
+func (s *AuthorWatchState) save(opts Options, path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal author watch state: %w", err)
+	}
+	if err := opts.writeFile(path, data); err != nil {
+		return fmt.Errorf("failed to write author watch state: %w", err)
+	}
+	return nil
+}
+
+// clone returns a deep copy of s.
+func (s *AuthorWatchState) clone() *AuthorWatchState {
+	clone := &AuthorWatchState{Seen: make(map[string][]string, len(s.Seen))}
+	for author, ids := range s.Seen {
+		clone.Seen[author] = append([]string(nil), ids...)
+	}
+	return clone
+}
+
+func (s *AuthorWatchState) seen(author, id string) bool {
+	for _, seen := range s.Seen[author] {
+		if seen == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthorWatchState) markSeen(author, id string) {
+	if !s.seen(author, id) {
+		s.Seen[author] = append(s.Seen[author], id)
+	}
+}
+
+// PollAuthors runs one polling cycle: it searches au: for every name of
+// every watched author, keeps the papers on which a watched author's name
+// matches one of the listed authors, and returns those not yet recorded in
+// state, marking them as seen.
+func PollAuthors(ctx context.Context, opts AuthorWatchOptions, state *AuthorWatchState) ([]AuthorHit, error) {
+	var fetched []ArxivPaper
+	for _, author := range opts.Authors {
+		for _, name := range author.names() {
+			page, err := fetchArxivPapers(ctx, authorQuery(name), 0, opts.Limit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch papers for %s: %w", name, err)
+			}
+			fetched = append(fetched, page.Papers...)
+		}
+	}
+
+	var hits []AuthorHit
+	reported := map[string]bool{}
+	for _, paper := range fetched {
+		id, _ := splitArxivID(paper.ID)
+		if reported[id] {
+			continue
+		}
+
+		var matched []string
+		for _, author := range opts.Authors {
+			if state.seen(author.Name, id) || !authorOnPaper(author, paper, opts.StrictMatch) {
+				continue
+			}
+			matched = append(matched, author.Name)
+			state.markSeen(author.Name, id)
+		}
+		if len(matched) > 0 {
+			reported[id] = true
+			paper.WatchedAuthors = matched
+			hits = append(hits, AuthorHit{Paper: paper, Authors: matched})
+		}
+	}
+
+	return hits, nil
+}
+
+// WatchAuthors polls every opts.Interval until ctx is cancelled, printing
+// each new hit and appending it to the metadata file. With a zero interval
+// it polls once.
+//
+// Metadata reaches the disk according to opts.Flush, and is always fsynced
+// at the end of a cycle and on shutdown. The state file is only saved after
+// that, and only lists the papers whose metadata was written, so every
+// paper the state reports as seen is in the metadata file even when the
+// watch is stopped mid-cycle.
+func WatchAuthors(ctx context.Context, opts AuthorWatchOptions) (err error) {
+	state, err := LoadAuthorWatchState(opts.StatePath)
+	if err != nil {
+		return err
+	}
+
+	metadata := newMetadataWriter(JSONFile, false)
+	metadata.appendMode = true
+	metadata.policy = opts.Flush
+	metadata.mode = opts.FileMode
+	defer func() {
+		if closeErr := metadata.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write metadata file: %w", closeErr)
+		}
+	}()
+
+	for {
+		// Poll against a copy of the state: a hit only becomes seen once
+		// its metadata is on disk.
+		hits, err := PollAuthors(ctx, opts, state.clone())
+		if err != nil {
+			return err
+		}
+
+		written, reportErr := reportAuthorHits(ctx, opts.Out, metadata, hits)
+		if err := metadata.Sync(); err != nil {
+			return errors.Join(reportErr, err)
+		}
+		for _, hit := range hits[:written] {
+			id, _ := splitArxivID(hit.Paper.ID)
+			for _, author := range hit.Authors {
+				state.markSeen(author, id)
+			}
+		}
+		if err := state.save(Options{FileMode: opts.FileMode}, opts.StatePath); err != nil {
+			return err
+		}
+		if reportErr != nil {
+			return reportErr
+		}
+
+		if opts.Interval <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, opts.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// reportAuthorHits appends hits to the metadata file and prints each once
+// it is written. It stops early when ctx is done, returning how many hits
+// were written.
+func reportAuthorHits(ctx context.Context, out io.Writer, metadata *metadataWriter, hits []AuthorHit) (int, error) {
+	for i, hit := range hits {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := metadata.Write(hit.Paper); err != nil {
+			return i, err
+		}
+		if _, err := fmt.Fprintf(out, "[%s] %s (%s)\n", strings.Join(hit.Authors, ", "), hit.Paper.Title, hit.Paper.ID); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(hits), nil
+}
+
+// authorOnPaper reports whether any name of author matches a paper author.
+func authorOnPaper(author WatchedAuthor, paper ArxivPaper, strict bool) bool {
+	for _, watched := range author.names() {
+		for _, listed := range paper.Authors {
+			if names.Matches(watched, listed, strict) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorQuery builds an au: search for a quoted author name.
+func authorQuery(name string) string {
+	return fmt.Sprintf("au:\"%s\"", strings.ReplaceAll(name, "\"", ""))
+}
+
+// orcidPersonalDetails is the subset of the ORCID personal-details record
+// used to derive name variants.
+type orcidPersonalDetails struct {
+	Name struct {
+		GivenNames struct {
+			Value string `json:"value"`
+		} `json:"given-names"`
+		FamilyName struct {
+			Value string `json:"value"`
+		} `json:"family-name"`
+		CreditName *struct {
+			Value string `json:"value"`
+		} `json:"credit-name"`
+	} `json:"name"`
+	OtherNames struct {
+		OtherName []struct {
+			Content string `json:"content"`
+		} `json:"other-name"`
+	} `json:"other-names"`
+}
+
+// AuthorFromORCID looks up an ORCID iD and returns a watched author named
+// after the record, with its credit name and other names as variants.
+func AuthorFromORCID(ctx context.Context, orcid string) (WatchedAuthor, error) {
+	resp, err := httpGet(ctx, orcidAPIBase+"/"+orcid+"/personal-details", http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return WatchedAuthor{}, fmt.Errorf("failed to fetch ORCID record: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return WatchedAuthor{}, fmt.Errorf("ORCID API returned HTTP %d", resp.StatusCode)
+	}
+
+	var details orcidPersonalDetails
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return WatchedAuthor{}, fmt.Errorf("failed to parse ORCID record: %w", err)
+	}
+
+	name := strings.TrimSpace(details.Name.GivenNames.Value + " " + details.Name.FamilyName.Value)
+	if name == "" {
+		return WatchedAuthor{}, fmt.Errorf("ORCID record %s has no public name", orcid)
+	}
+
+	variants := map[string]bool{}
+	if details.Name.CreditName != nil && details.Name.CreditName.Value != "" {
+		variants[details.Name.CreditName.Value] = true
+	}
+	for _, other := range details.OtherNames.OtherName {
+		if other.Content != "" {
+			variants[other.Content] = true
+		}
+	}
+	delete(variants, name)
+
+	author := WatchedAuthor{Name: name}
+	for variant := range variants {
+		author.Variants = append(author.Variants, variant)
+	}
+	sort.Strings(author.Variants)
+	return author, nil
+}
@@ -0,0 +1,130 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authorEntry renders a fake entry with the given authors.
+func authorEntry(id, title string, authors ...string) string {
+	var names []string
+	for _, author := range authors {
+		names = append(names, fmt.Sprintf("<author><name>%s</name></author>", author))
+	}
+	return strings.Replace(fakeEntry(id, title), "<author><name>Jane Doe</name></author>", strings.Join(names, ""), 1)
+}
+
+func TestPollAuthorsAcrossCycles(t *testing.T) {
+	var mu sync.Mutex
+	cycle := 1
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query().Get("search_query")
+		var entries []string
+		switch query {
+		case `au:"Jane Doe"`:
+			entries = append(entries,
+				authorEntry("2401.00001v1", "Joint Work", "Jane Doe", "Richard Roe"),
+				authorEntry("2401.00002v1", "Namesake Paper", "John Doe"),
+			)
+			if cycle > 1 {
+				entries = append(entries, authorEntry("2401.00003v1", "Fresh Work", "J. Doe"))
+			}
+		case `au:"Richard Roe"`:
+			entries = append(entries, authorEntry("2401.00001v2", "Joint Work", "Jane Doe", "Richard Roe"))
+		}
+		_, _ = w.Write([]byte(fakeFeed(len(entries), entries...)))
+	}))
+
+	opts := AuthorWatchOptions{
+		Authors: []WatchedAuthor{{Name: "Jane Doe"}, {Name: "Richard Roe"}},
+		Limit:   10,
+	}
+	statePath := filepath.Join(t.TempDir(), AuthorStateFile)
+
+	state, err := LoadAuthorWatchState(statePath)
+	if err != nil {
+		t.Fatalf("LoadAuthorWatchState() error = %v", err)
+	}
+	hits, err := PollAuthors(testingContext(t), opts, state)
+	if err != nil {
+		t.Fatalf("PollAuthors() error = %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("first cycle got %d hits, want 1 (the namesake must not match)", len(hits))
+	}
+	if strings.Join(hits[0].Authors, ",") != "Jane Doe,Richard Roe" {
+		t.Errorf("hit authors = %v, want both watched authors", hits[0].Authors)
+	}
+	if err := state.Save(statePath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	mu.Lock()
+	cycle = 2
+	mu.Unlock()
+
+	state, err = LoadAuthorWatchState(statePath)
+	if err != nil {
+		t.Fatalf("LoadAuthorWatchState() error = %v", err)
+	}
+	hits, err = PollAuthors(testingContext(t), opts, state)
+	if err != nil {
+		t.Fatalf("PollAuthors() error = %v", err)
+	}
+	if len(hits) != 1 || hits[0].Paper.Title != "Fresh Work" {
+		t.Fatalf("second cycle hits = %+v, want only the fresh paper", hits)
+	}
+	if strings.Join(hits[0].Authors, ",") != "Jane Doe" {
+		t.Errorf("hit authors = %v, want [Jane Doe]", hits[0].Authors)
+	}
+
+	hits, err = PollAuthors(testingContext(t), AuthorWatchOptions{Authors: opts.Authors, Limit: 10, StrictMatch: true}, &AuthorWatchState{Seen: map[string][]string{}})
+	if err != nil {
+		t.Fatalf("PollAuthors() error = %v", err)
+	}
+	for _, hit := range hits {
+		if hit.Paper.Title == "Fresh Work" {
+			t.Error("strict matching should not match the initialed name")
+		}
+	}
+}
+
+func TestAuthorFromORCID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0000-0002-1825-0097/personal-details" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"name": {
+				"given-names": {"value": "Jane"},
+				"family-name": {"value": "Doe"},
+				"credit-name": {"value": "J. A. Doe"}
+			},
+			"other-names": {"other-name": [{"content": "Jane Anne Doe"}, {"content": "Jane Doe"}]}
+		}`))
+	}))
+	defer server.Close()
+
+	oldBase := orcidAPIBase
+	orcidAPIBase = server.URL
+	t.Cleanup(func() { orcidAPIBase = oldBase })
+
+	author, err := AuthorFromORCID(testingContext(t), "0000-0002-1825-0097")
+	if err != nil {
+		t.Fatalf("AuthorFromORCID() error = %v", err)
+	}
+	if author.Name != "Jane Doe" {
+		t.Errorf("Name = %q, want %q", author.Name, "Jane Doe")
+	}
+	if strings.Join(author.Variants, "|") != "J. A. Doe|Jane Anne Doe" {
+		t.Errorf("Variants = %v, want credit and other names without duplicates", author.Variants)
+	}
+}
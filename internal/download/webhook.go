@@ -0,0 +1,96 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds the completion webhook, so that a slow receiver
+// cannot hold up the end of a run.
+var webhookTimeout = 10 * time.Second
+
+// RunSummary is the JSON body posted to Options.Webhook when a run ends.
+// Text repeats the essentials as a sentence, which is what Slack-compatible
+// incoming webhooks display.
+type RunSummary struct {
+	Text    string       `json:"text"`
+	Query   string       `json:"query,omitempty"`
+	Queries []string     `json:"queries,omitempty"`
+	Count   int          `json:"count"`
+	Stats   SummaryStats `json:"stats"`
+	IDs     []string     `json:"ids"`
+	Error   string       `json:"error,omitempty"`
+
+	// ExcludedIDs lists the IDs of Options.IDs that Options.IDQuery left
+	// out of the results.
+	ExcludedIDs []string `json:"excluded_ids,omitempty"`
+}
+
+// SummaryStats counts the fetched papers and those filtered out, by reason.
+type SummaryStats struct {
+	Fetched  int            `json:"fetched"`
+	Filtered map[string]int `json:"filtered"`
+}
+
+func newRunSummary(opts Options, ids []string, stats *runStats, runErr error) RunSummary {
+	summary := RunSummary{
+		Query:   opts.Query,
+		Queries: opts.Queries,
+		Count:   len(ids),
+		Stats:   SummaryStats{Fetched: stats.fetched, Filtered: map[string]int{}},
+		IDs:     ids,
+
+		ExcludedIDs: stats.excludedIDs,
+	}
+	if summary.IDs == nil {
+		summary.IDs = []string{}
+	}
+	for reason, n := range stats.dropped {
+		summary.Stats.Filtered[reason] = n
+	}
+
+	query := opts.Query
+	if len(opts.Queries) > 0 {
+		query = strings.Join(opts.Queries, "; ")
+	}
+	summary.Text = fmt.Sprintf("arxiv-cli: %d papers for %s", len(ids), query)
+	if runErr != nil {
+		summary.Error = runErr.Error()
+		summary.Text += " (failed: " + summary.Error + ")"
+	}
+	return summary
+}
+
+// postWebhook sends summary to url as JSON.
+func postWebhook(ctx context.Context, url string, summary RunSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run summary: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	resp, err := httpDo(withSecretPath(ctx), "POST", url, http.Header{"Content-Type": {"application/json"}}, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyWebhook posts the summary of a finished run to opts.Webhook. A
+// failure is only reported, since the run itself is already done.
+func notifyWebhook(ctx context.Context, opts Options, ids []string, stats *runStats, runErr error) {
+	if err := postWebhook(ctx, opts.Webhook, newRunSummary(opts, ids, stats, runErr)); err != nil {
+		opts.printf("failed to notify webhook: %v\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPostsWebhook(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	received := make(chan RunSummary, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var summary RunSummary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		received <- summary
+	}))
+	t.Cleanup(server.Close)
+
+	opts := Options{Query: "cat:cs.CL", Limit: 2, SaveMetadata: true, Author: "Doe", Webhook: server.URL, Out: &bytes.Buffer{}}
+	if err := Run(testingContext(t), opts); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	select {
+	case summary := <-received:
+		want := RunSummary{
+			Text:  "arxiv-cli: 2 papers for cat:cs.CL",
+			Query: "cat:cs.CL",
+			Count: 2,
+			Stats: SummaryStats{Fetched: 3, Filtered: map[string]int{}},
+			IDs:   []string{"2401.00000", "2401.00001"},
+		}
+		if !reflect.DeepEqual(summary, want) {
+			t.Errorf("webhook got %+v, want %+v", summary, want)
+		}
+	default:
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestRunSurvivesWebhookFailure(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(1))
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	old := webhookTimeout
+	webhookTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { webhookTimeout = old })
+
+	var out bytes.Buffer
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 1, SaveMetadata: true, Webhook: server.URL, Out: &out}); err != nil {
+		t.Fatalf("Run() error = %v, want the webhook failure to be logged only", err)
+	}
+	if !strings.Contains(out.String(), "failed to notify webhook") {
+		t.Errorf("webhook failure was not logged:\n%s", out.String())
+	}
+}
@@ -0,0 +1,40 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AstraBert/arxiv-cli/internal/text"
+)
+
+// Word cloud files written with Options.WordCloudData.
+const (
+	WordCloudJSONFile = "wordcloud.json"
+	WordCloudTSVFile  = "wordcloud.tsv"
+)
+
+// writeWordCloud writes the term frequencies of the abstracts (see
+// text.CountTerms) as WordCloudJSONFile, an array of {"text", "count"}
+// objects as browser word cloud libraries expect, and as WordCloudTSVFile,
+// one "term<TAB>count" line per term.
+func writeWordCloud(opts Options, abstracts []string) error {
+	terms := text.CountTerms(abstracts)
+
+	data, err := json.MarshalIndent(terms, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal word cloud data: %w", err)
+	}
+	if err := opts.writeFile(opts.path(WordCloudJSONFile), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write word cloud data: %w", err)
+	}
+
+	var tsv strings.Builder
+	for _, term := range terms {
+		fmt.Fprintf(&tsv, "%s\t%d\n", term.Text, term.Count)
+	}
+	if err := opts.writeFile(opts.path(WordCloudTSVFile), []byte(tsv.String())); err != nil {
+		return fmt.Errorf("failed to write word cloud data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package download
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/AstraBert/arxiv-cli/internal/text"
+)
+
+func TestRunWritesWordCloudData(t *testing.T) {
+	chdirTemp(t)
+	useFakeAPI(t, pagedFeedHandler(3))
+
+	if err := Run(testingContext(t), Options{Query: "cat:cs.CL", Limit: 3, WordCloudData: true}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(WordCloudJSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", WordCloudJSONFile, err)
+	}
+	var terms []text.TermCount
+	if err := json.Unmarshal(data, &terms); err != nil {
+		t.Fatalf("%s is not a JSON array: %v", WordCloudJSONFile, err)
+	}
+	// Every summary reads "Summary of Paper N.": "of" is a stop word and
+	// the numbers are dropped.
+	want := []text.TermCount{{Text: "paper", Count: 3}, {Text: "summary", Count: 3}}
+	if !reflect.DeepEqual(terms, want) {
+		t.Errorf("%s = %v, want %v", WordCloudJSONFile, terms, want)
+	}
+
+	tsv, err := os.ReadFile(WordCloudTSVFile)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", WordCloudTSVFile, err)
+	}
+	if string(tsv) != "paper\t3\nsummary\t3\n" {
+		t.Errorf("%s = %q", WordCloudTSVFile, tsv)
+	}
+}
@@ -0,0 +1,154 @@
+// Package epub writes minimal EPUB 3 books, enough for e-readers and
+// Send to Kindle to display a paper's text without a converter.
+package epub
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Book is the content of an EPUB file.
+type Book struct {
+	ID       string // unique identifier, e.g. the arXiv abs URL
+	Title    string
+	Authors  []string
+	Language string    // BCP 47 tag; empty means "en"
+	Modified time.Time // last modification; required by EPUB 3
+	Chapters []Chapter
+}
+
+// Chapter is one XHTML document of a Book, listed in its table of contents.
+type Chapter struct {
+	Title string
+	// Body is the XHTML content of the chapter's <body>; it must be well
+	// formed, with text escaped (see Escape).
+	Body string
+}
+
+// Escape escapes s for use as text or an attribute value in XHTML.
+func Escape(s string) string {
+	return html.EscapeString(s)
+}
+
+// Write writes book to w as an EPUB 3 container: the uncompressed mimetype
+// first, then the container file, the package document, the navigation
+// document and one XHTML file per chapter.
+func Write(w io.Writer, book Book) error {
+	if len(book.Chapters) == 0 {
+		return fmt.Errorf("book %q has no chapters", book.Title)
+	}
+	zw := zip.NewWriter(w)
+
+	// Readers identify the format by the first, stored member.
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to write EPUB: %w", err)
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return fmt.Errorf("failed to write EPUB: %w", err)
+	}
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", containerXML},
+		{"OEBPS/content.opf", packageDocument(book)},
+		{"OEBPS/nav.xhtml", navDocument(book)},
+	}
+	for i, chapter := range book.Chapters {
+		files = append(files, struct{ name, content string }{"OEBPS/" + chapterFile(i), xhtmlDocument(chapter.Title, chapter.Body, "")})
+	}
+	for _, file := range files {
+		member, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to write EPUB: %w", err)
+		}
+		if _, err := io.WriteString(member, file.content); err != nil {
+			return fmt.Errorf("failed to write EPUB: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write EPUB: %w", err)
+	}
+	return nil
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter-%d.xhtml", i+1)
+}
+
+func language(book Book) string {
+	if book.Language == "" {
+		return "en"
+	}
+	return book.Language
+}
+
+// packageDocument renders the package document listing the book's metadata,
+// files and reading order.
+func packageDocument(book Book) string {
+	modified := book.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", Escape(book.ID))
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", Escape(book.Title))
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", Escape(language(book)))
+	for _, author := range book.Authors {
+		fmt.Fprintf(&b, "    <dc:creator>%s</dc:creator>\n", Escape(author))
+	}
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", modified.UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString(`  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+`)
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, "    <item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterFile(i))
+	}
+	b.WriteString("  </manifest>\n  <spine>\n")
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, "    <itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+// navDocument renders the table of contents.
+func navDocument(book Book) string {
+	var b strings.Builder
+	b.WriteString("<nav epub:type=\"toc\">\n<h1>Contents</h1>\n<ol>\n")
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", chapterFile(i), Escape(chapter.Title))
+	}
+	b.WriteString("</ol>\n</nav>\n")
+	return xhtmlDocument(book.Title, b.String(), ` xmlns:epub="http://www.idpf.org/2007/ops"`)
+}
+
+// xhtmlDocument wraps body in an XHTML document titled title; namespaces
+// adds attributes to the root element.
+func xhtmlDocument(title, body, namespaces string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml"%s>
+<head><title>%s</title></head>
+<body>
+%s</body>
+</html>
+`, namespaces, Escape(title), body)
+}
@@ -0,0 +1,80 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	book := Book{
+		ID:       "http://arxiv.org/abs/2401.00001v1",
+		Title:    "Graphs & <Trees>",
+		Authors:  []string{"Jane Doe", "John Roe"},
+		Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Chapters: []Chapter{{Title: "Graphs & <Trees>", Body: "<h1>" + Escape("Graphs & <Trees>") + "</h1>\n<p>Text.</p>\n"}},
+	}
+	var out bytes.Buffer
+	if err := Write(&out, book); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("first member = %s (method %d), want a stored mimetype", first.Name, first.Method)
+	}
+	members := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		members[f.Name] = string(data)
+	}
+	if members["mimetype"] != "application/epub+zip" {
+		t.Errorf("mimetype = %q", members["mimetype"])
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/chapter-1.xhtml"} {
+		content, ok := members[name]
+		if !ok {
+			t.Errorf("missing %s", name)
+			continue
+		}
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed: %v", name, err)
+				break
+			}
+		}
+	}
+	opf := members["OEBPS/content.opf"]
+	for _, want := range []string{
+		`<dc:title>Graphs &amp; &lt;Trees&gt;</dc:title>`,
+		`<dc:creator>Jane Doe</dc:creator>`,
+		`<dc:creator>John Roe</dc:creator>`,
+		`<meta property="dcterms:modified">2024-01-02T03:04:05Z</meta>`,
+		`<itemref idref="chapter-1"/>`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("content.opf lacks %s:\n%s", want, opf)
+		}
+	}
+}
+
+func TestWriteNeedsChapters(t *testing.T) {
+	if err := Write(io.Discard, Book{Title: "Empty"}); err == nil {
+		t.Error("Write() accepted a book without chapters")
+	}
+}
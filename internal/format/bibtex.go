@@ -0,0 +1,154 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bibtexEscaper escapes the characters that break a BibTeX field. Dollar
+// signs, underscores and braces are left alone since arXiv titles routinely
+// contain LaTeX markup.
+var bibtexEscaper = strings.NewReplacer(`&`, `\&`, `%`, `\%`, `#`, `\#`)
+
+// BibTeX returns a BibTeX entry keyed by the arXiv ID: an @article when
+// there is a journal reference and a @misc otherwise.
+func (e Entry) BibTeX() string {
+	entryType := "misc"
+	if e.JournalRef != "" {
+		entryType = "article"
+	}
+
+	type field struct{ name, value string }
+	fields := []field{
+		{"title", "{" + bibtexEscaper.Replace(e.Title) + "}"},
+		{"author", bibtexEscaper.Replace(strings.Join(e.Authors, " and "))},
+	}
+	if !e.Published.IsZero() {
+		fields = append(fields, field{"year", fmt.Sprint(e.Published.Year())})
+	}
+	if e.JournalRef != "" {
+		fields = append(fields, field{"journal", bibtexEscaper.Replace(e.JournalRef)})
+	}
+	if e.DOI != "" {
+		fields = append(fields, field{"doi", e.DOI})
+	}
+	fields = append(fields,
+		field{"eprint", e.ID},
+		field{"archivePrefix", "arXiv"},
+		field{"primaryClass", e.PrimaryCategory},
+		field{"url", e.URL()},
+	)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@%s{%s,\n", entryType, e.ID)
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s = {%s},\n", f.name, f.value)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ParseBibTeX returns the fields of each entry of a BibTeX file, by
+// lowercase field name, with the outer braces or quotes of each value
+// removed but the value otherwise as written. @comment, @string and
+// @preamble blocks are skipped; parsing stops at the first malformed entry.
+func ParseBibTeX(data string) []map[string]string {
+	var entries []map[string]string
+	for {
+		at := strings.IndexByte(data, '@')
+		if at < 0 {
+			return entries
+		}
+		data = data[at+1:]
+		open := strings.IndexAny(data, "{(")
+		if open < 0 {
+			return entries
+		}
+		entryType := strings.ToLower(strings.TrimSpace(data[:open]))
+		body, rest, ok := bibtexBlock(data[open:])
+		if !ok {
+			return entries
+		}
+		data = rest
+		switch entryType {
+		case "comment", "string", "preamble":
+			continue
+		}
+		// The citation key runs up to the first comma.
+		_, fields, ok := strings.Cut(body, ",")
+		if !ok {
+			continue
+		}
+		entries = append(entries, bibtexFields(fields))
+	}
+}
+
+// bibtexBlock splits s, starting with an opening brace or parenthesis, into
+// the text up to the matching closing one and what follows it.
+func bibtexBlock(s string) (body, rest string, ok bool) {
+	closing := byte('}')
+	if s[0] == '(' {
+		closing = ')'
+	}
+	depth := 0
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '{':
+			depth++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case s[i] == closing && depth == 0:
+			return s[1:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// bibtexFields parses the name = value pairs of an entry body.
+func bibtexFields(s string) map[string]string {
+	fields := map[string]string{}
+	for {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return fields
+		}
+		name = strings.ToLower(strings.TrimSpace(strings.TrimLeft(name, ", \t\r\n")))
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			return fields
+		}
+
+		var value string
+		switch rest[0] {
+		case '{':
+			body, after, ok := bibtexBlock(rest)
+			if !ok {
+				return fields
+			}
+			value, s = body, after
+		case '"':
+			end := 1
+			for depth := 0; end < len(rest) && (rest[end] != '"' || depth > 0); end++ {
+				if rest[end] == '{' {
+					depth++
+				} else if rest[end] == '}' {
+					depth--
+				}
+			}
+			if end >= len(rest) {
+				return fields
+			}
+			value, s = rest[1:end], rest[end+1:]
+		default:
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value, s = rest[:end], rest[end:]
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+}
@@ -0,0 +1,33 @@
+package format
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// CSVHeader names the columns of a CSV row, see CSVRower.
+var CSVHeader = []string{
+	"id", "title", "authors", "published", "updated",
+	"primary_category", "categories", "pdf_url", "html_url", "comment",
+}
+
+// CSVRower is a paper that can be written as a CSV row matching CSVHeader.
+type CSVRower interface {
+	ToCSVRow() []string
+}
+
+// WriteCSV writes a header row and one row per paper to w.
+func WriteCSV[T CSVRower](papers []T, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, paper := range papers {
+		if err := cw.Write(paper.ToCSVRow()); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,26 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+type csvPaper []string
+
+func (p csvPaper) ToCSVRow() []string { return p }
+
+func TestWriteCSV(t *testing.T) {
+	papers := []csvPaper{
+		{"2401.00001v1", "Commas, \"Quotes\"", "Jane Doe; Richard Roe", "", "", "cs.CL", "cs.CL; cs.AI", "", "", ""},
+	}
+
+	var b strings.Builder
+	if err := WriteCSV(papers, &b); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	want := "id,title,authors,published,updated,primary_category,categories,pdf_url,html_url,comment\n" +
+		"2401.00001v1,\"Commas, \"\"Quotes\"\"\",Jane Doe; Richard Roe,,,cs.CL,cs.CL; cs.AI,,,\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,57 @@
+package format
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Namespaces of a Dublin Core record in the oai_dc schema.
+const (
+	oaiDCNamespace = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	dcNamespace    = "http://purl.org/dc/elements/1.1/"
+)
+
+// dublinCore is the oai_dc XML of a record. encoding/xml writes the
+// prefixed names as they are, so the prefixes are declared explicitly.
+type dublinCore struct {
+	XMLName     xml.Name `xml:"oai_dc:dc"`
+	OAIDC       string   `xml:"xmlns:oai_dc,attr"`
+	DC          string   `xml:"xmlns:dc,attr"`
+	Title       string   `xml:"dc:title"`
+	Creators    []string `xml:"dc:creator"`
+	Date        string   `xml:"dc:date,omitempty"`
+	Description string   `xml:"dc:description,omitempty"`
+	Identifiers []string `xml:"dc:identifier"`
+}
+
+// DublinCore returns a Dublin Core record for the entry in the oai_dc XML
+// schema read by library catalogs and repositories: its title, a creator
+// per author, the publication date, the abstract as description and, as
+// identifiers, its abstract page and DOI. Field values are collapsed onto
+// one line and escaped.
+func (e Entry) DublinCore() (string, error) {
+	collapse := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+	record := dublinCore{
+		OAIDC:       oaiDCNamespace,
+		DC:          dcNamespace,
+		Title:       collapse(e.Title),
+		Description: collapse(e.Abstract),
+		Identifiers: []string{e.URL()},
+	}
+	for _, author := range e.Authors {
+		record.Creators = append(record.Creators, collapse(author))
+	}
+	if !e.Published.IsZero() {
+		record.Date = e.Published.Format("2006-01-02")
+	}
+	if e.DOI != "" {
+		record.Identifiers = append(record.Identifiers, "doi:"+e.DOI)
+	}
+
+	data, err := xml.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Dublin Core record: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
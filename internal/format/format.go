@@ -0,0 +1,131 @@
+// Package format renders paper metadata in the formats a run can save it
+// in, including the bibliography formats reference managers import.
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is a metadata output format.
+type Format int
+
+const (
+	JSONL    Format = iota // one JSON record per line, the default
+	BibTeX                 // BibTeX entries keyed by arXiv ID
+	RIS                    // RIS records, as imported by Zotero, Mendeley and EndNote
+	CSV                    // a header row and one row per paper, see CSVHeader
+	Markdown               // a section per paper, separated by rules
+)
+
+// Metadata file names, one per format.
+const (
+	JSONLFile    = "metadata.jsonl"
+	BibTeXFile   = "papers.bib"
+	RISFile      = "papers.ris"
+	CSVFile      = "metadata.csv"
+	MarkdownFile = "papers.md"
+)
+
+var formats = []struct {
+	format Format
+	name   string
+	file   string
+}{
+	{JSONL, "jsonl", JSONLFile},
+	{BibTeX, "bibtex", BibTeXFile},
+	{RIS, "ris", RISFile},
+	{CSV, "csv", CSVFile},
+	{Markdown, "markdown", MarkdownFile},
+}
+
+// Parse returns the format called name; an empty name means JSONL.
+func Parse(name string) (Format, error) {
+	if name == "" {
+		return JSONL, nil
+	}
+	for _, f := range formats {
+		if f.name == name {
+			return f.format, nil
+		}
+	}
+	return JSONL, fmt.Errorf("unknown format %q (expected one of %s)", name, strings.Join(Names(), ", "))
+}
+
+// Names lists the names Parse accepts.
+func Names() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// String returns the name of f, as accepted by Parse.
+func (f Format) String() string {
+	for _, known := range formats {
+		if known.format == f {
+			return known.name
+		}
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Filename is the metadata file f writes to.
+func (f Format) Filename() string {
+	for _, known := range formats {
+		if known.format == f {
+			return known.file
+		}
+	}
+	return JSONLFile
+}
+
+// Entry is the bibliographic data of a paper.
+type Entry struct {
+	ID              string // arXiv ID without version
+	Title           string
+	Authors         []string
+	Published       time.Time // zero if unknown
+	Abstract        string
+	JournalRef      string
+	DOI             string
+	PrimaryCategory string
+	Categories      []string
+	PDFURL          string // empty means the arXiv PDF of ID
+	Notes           []Note // the user's notes on the paper, shown in Markdown
+}
+
+// Note is a user's timestamped note on an entry.
+type Note struct {
+	Time time.Time
+	Text string
+}
+
+// URL is the abstract page of the entry.
+func (e Entry) URL() string {
+	return "https://arxiv.org/abs/" + e.ID
+}
+
+// PDF is the PDF link of the entry.
+func (e Entry) PDF() string {
+	if e.PDFURL != "" {
+		return e.PDFURL
+	}
+	return "https://arxiv.org/pdf/" + e.ID
+}
+
+// Render formats e as a BibTeX, RIS or Markdown record. JSONL and CSV records carry
+// more than an Entry and are encoded by the caller.
+func (f Format) Render(e Entry) (string, error) {
+	switch f {
+	case BibTeX:
+		return e.BibTeX(), nil
+	case RIS:
+		return e.RIS(), nil
+	case Markdown:
+		return e.Markdown(), nil
+	}
+	return "", fmt.Errorf("format %s does not render entries", f)
+}
@@ -0,0 +1,169 @@
+package format
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEntry = Entry{
+	ID:              "2401.00001",
+	Title:           "Scaling $O(n)$ Attention & Friends",
+	Authors:         []string{"Jane Doe", "Richard Roe"},
+	Published:       time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	Abstract:        "We scale\n  attention.",
+	PrimaryCategory: "cs.CL",
+}
+
+func TestBibTeX(t *testing.T) {
+	want := `@misc{2401.00001,
+  title = {{Scaling $O(n)$ Attention \& Friends}},
+  author = {Jane Doe and Richard Roe},
+  year = {2024},
+  eprint = {2401.00001},
+  archivePrefix = {arXiv},
+  primaryClass = {cs.CL},
+  url = {https://arxiv.org/abs/2401.00001},
+}
+`
+	if got := testEntry.BibTeX(); got != want {
+		t.Errorf("BibTeX() =\n%s\nwant\n%s", got, want)
+	}
+
+	entry := testEntry
+	entry.JournalRef = "J. Test 12 (2024) 1-10"
+	entry.DOI = "10.1000/xyz123"
+	got := entry.BibTeX()
+	for _, line := range []string{"@article{2401.00001,", "  journal = {J. Test 12 (2024) 1-10},", "  doi = {10.1000/xyz123},"} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("BibTeX() lacks %q:\n%s", line, got)
+		}
+	}
+}
+
+func TestRIS(t *testing.T) {
+	want := `TY  - JOUR
+TI  - Scaling $O(n)$ Attention & Friends
+AU  - Jane Doe
+AU  - Richard Roe
+PY  - 2024
+UR  - https://arxiv.org/abs/2401.00001
+AB  - We scale attention.
+ER  - 
+`
+	if got := testEntry.RIS(); got != want {
+		t.Errorf("RIS() =\n%s\nwant\n%s", got, want)
+	}
+
+	entry := testEntry
+	entry.DOI = "10.1000/xyz123"
+	if got := entry.RIS(); !strings.Contains(got, "DO  - 10.1000/xyz123\n") {
+		t.Errorf("RIS() lacks the DOI:\n%s", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	want := `## Scaling $O(n)$ Attention & Friends
+
+*Jane Doe, Richard Roe*
+
+**Published:** 2024-01-02  
+**Categories:** cs.CL  
+**PDF:** [https://arxiv.org/pdf/2401.00001](https://arxiv.org/pdf/2401.00001)
+
+> We scale
+> attention.
+`
+	if got := testEntry.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	entry := Entry{ID: "2401.00002", Title: "Untitled", Abstract: "First.\n\nSecond."}
+	if got := entry.Markdown(); !strings.HasSuffix(got, "> First.\n>\n> Second.\n") {
+		t.Errorf("Markdown() does not keep the abstract's paragraphs:\n%s", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, name := range Names() {
+		f, err := Parse(name)
+		if err != nil || f.String() != name {
+			t.Errorf("Parse(%q) = %v, %v", name, f, err)
+		}
+	}
+	if f, err := Parse(""); err != nil || f != JSONL {
+		t.Errorf("Parse(\"\") = %v, %v, want jsonl", f, err)
+	}
+	if _, err := Parse("endnote"); err == nil {
+		t.Error("Parse(endnote) succeeded")
+	}
+	if RIS.Filename() != "papers.ris" {
+		t.Errorf("RIS.Filename() = %q", RIS.Filename())
+	}
+}
+
+func TestDublinCore(t *testing.T) {
+	entry := testEntry
+	entry.Authors = []string{"Jane <Doe>", "Richard Roe"}
+	entry.DOI = "10.1000/xyz123"
+	got, err := entry.DublinCore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := xml.Header + `<oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <dc:title>Scaling $O(n)$ Attention &amp; Friends</dc:title>
+  <dc:creator>Jane &lt;Doe&gt;</dc:creator>
+  <dc:creator>Richard Roe</dc:creator>
+  <dc:date>2024-01-02</dc:date>
+  <dc:description>We scale attention.</dc:description>
+  <dc:identifier>https://arxiv.org/abs/2401.00001</dc:identifier>
+  <dc:identifier>doi:10.1000/xyz123</dc:identifier>
+</oai_dc:dc>
+`
+	if got != want {
+		t.Errorf("DublinCore() =\n%s\nwant\n%s", got, want)
+	}
+
+	var parsed struct {
+		Title    string   `xml:"title"`
+		Creators []string `xml:"creator"`
+	}
+	if err := xml.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("DublinCore() is not well-formed XML: %v", err)
+	}
+	if parsed.Title != "Scaling $O(n)$ Attention & Friends" || parsed.Creators[0] != "Jane <Doe>" {
+		t.Errorf("DublinCore() parses back as %+v", parsed)
+	}
+}
+
+func TestParseBibTeX(t *testing.T) {
+	data := `@comment{ignored}
+@misc{2401.00001,
+  title = {{Scaling $O(n)$ Attention \& Friends}},
+  author = "Jane Doe and {Richard} Roe",
+  year = 2024,
+  eprint = {2401.00001},
+}
+
+@Article(smith2020, DOI = {10.1000/XYZ}, Title = {Graphs})
+`
+	got := ParseBibTeX(data)
+	want := []map[string]string{
+		{"title": `{Scaling $O(n)$ Attention \& Friends}`, "author": "Jane Doe and {Richard} Roe", "year": "2024", "eprint": "2401.00001"},
+		{"doi": "10.1000/XYZ", "title": "Graphs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseBibTeX() = %q, want %q", got, want)
+	}
+
+	// The BibTeX this package writes parses back.
+	entry := testEntry
+	entry.DOI = "10.1000/xyz123"
+	parsed := ParseBibTeX(entry.BibTeX())
+	if len(parsed) != 1 || parsed[0]["eprint"] != "2401.00001" || parsed[0]["doi"] != "10.1000/xyz123" {
+		t.Errorf("ParseBibTeX(BibTeX()) = %q", parsed)
+	}
+}
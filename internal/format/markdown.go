@@ -0,0 +1,55 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarkdownSeparator is written between the Markdown sections of two papers.
+const MarkdownSeparator = "---\n\n"
+
+// Markdown returns a Markdown section for the entry: a level-two heading
+// with the title, the authors in italics, the publication date, categories
+// and PDF link, the abstract as a blockquote and any notes as a list.
+func (e Entry) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", strings.Join(strings.Fields(e.Title), " "))
+	if len(e.Authors) > 0 {
+		fmt.Fprintf(&b, "*%s*\n\n", strings.Join(e.Authors, ", "))
+	}
+
+	// Two trailing spaces keep the details on separate lines.
+	var details []string
+	if !e.Published.IsZero() {
+		details = append(details, "**Published:** "+e.Published.Format("2006-01-02"))
+	}
+	categories := e.Categories
+	if len(categories) == 0 && e.PrimaryCategory != "" {
+		categories = []string{e.PrimaryCategory}
+	}
+	if len(categories) > 0 {
+		details = append(details, "**Categories:** "+strings.Join(categories, ", "))
+	}
+	details = append(details, fmt.Sprintf("**PDF:** [%s](%s)", e.PDF(), e.PDF()))
+	b.WriteString(strings.Join(details, "  \n") + "\n")
+
+	if abstract := strings.TrimSpace(e.Abstract); abstract != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(abstract, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				b.WriteString(">\n")
+			} else {
+				b.WriteString("> " + line + "\n")
+			}
+		}
+	}
+
+	if len(e.Notes) > 0 {
+		b.WriteString("\n**Notes:**\n\n")
+		for _, note := range e.Notes {
+			text := strings.Join(strings.Fields(note.Text), " ")
+			fmt.Fprintf(&b, "- %s: %s\n", note.Time.Format("2006-01-02 15:04"), text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RIS returns a RIS record for the entry, ending with the ER tag. Field
+// values are collapsed onto one line, as every tag must be.
+func (e Entry) RIS() string {
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			fmt.Fprintf(&b, "%s  - %s\n", name, value)
+		}
+	}
+
+	tag("TY", "JOUR")
+	tag("TI", e.Title)
+	for _, author := range e.Authors {
+		tag("AU", author)
+	}
+	if !e.Published.IsZero() {
+		tag("PY", fmt.Sprint(e.Published.Year()))
+	}
+	tag("JO", e.JournalRef)
+	tag("DO", e.DOI)
+	tag("UR", e.URL())
+	tag("AB", e.Abstract)
+	b.WriteString("ER  - \n")
+	return b.String()
+}
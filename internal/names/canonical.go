@@ -0,0 +1,130 @@
+package names
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rules controls which name variants Canonicalize merges. With no rules set
+// only names that normalize identically are merged.
+type Rules struct {
+	// Initials lets an initial stand for a given name starting with it, so
+	// "J. Smith" merges with "John Smith".
+	Initials bool
+
+	// MiddleNames lets a name with fewer given names merge with one that
+	// has more, so "Jane Doe" merges with "Jane A. Doe".
+	MiddleNames bool
+}
+
+// DefaultRules enables every rule.
+var DefaultRules = Rules{Initials: true, MiddleNames: true}
+
+// ParseRules parses a comma-separated list of rule names: "initials" and
+// "middle-names", or "none" for exact matches only.
+func ParseRules(value string) (Rules, error) {
+	var rules Rules
+	for _, name := range strings.Split(value, ",") {
+		switch strings.TrimSpace(name) {
+		case "initials":
+			rules.Initials = true
+		case "middle-names":
+			rules.MiddleNames = true
+		case "none", "":
+		default:
+			return Rules{}, fmt.Errorf("unknown name rule %q (expected initials, middle-names or none)", name)
+		}
+	}
+	return rules, nil
+}
+
+// Same reports whether a and b can name the same person under the rules.
+func (r Rules) Same(a, b string) bool {
+	x, y := Parse(a), Parse(b)
+	if x.Family == "" || x.Family != y.Family {
+		return false
+	}
+	if len(x.Given) != len(y.Given) && (!r.MiddleNames || len(x.Given) == 0 || len(y.Given) == 0) {
+		return false
+	}
+	for i := 0; i < len(x.Given) && i < len(y.Given); i++ {
+		if x.Given[i] == y.Given[i] {
+			continue
+		}
+		if !r.Initials || !compatibleGiven(x.Given[i], y.Given[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Canonicalize groups variants that name the same person under rules and
+// maps each variant to the canonical name of its group: the most complete
+// variant, preferring spelled-out given names over initials. A variant that
+// could belong to several groups that do not match each other, such as
+// "J. Smith" next to "John Smith" and "Jane Smith", is ambiguous and kept
+// on its own.
+func Canonicalize(variants []string, rules Rules) map[string]string {
+	sorted := append([]string(nil), variants...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := completeness(sorted[i]), completeness(sorted[j])
+		if a != b {
+			return a > b
+		}
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	var groups [][]string
+	canonical := make(map[string]string, len(sorted))
+	for _, variant := range sorted {
+		if _, ok := canonical[variant]; ok {
+			continue
+		}
+		match := -1
+		for i, group := range groups {
+			if !sameAsAll(rules, variant, group) {
+				continue
+			}
+			if match >= 0 {
+				match = -2
+				break
+			}
+			match = i
+		}
+		if match < 0 {
+			groups = append(groups, []string{variant})
+			canonical[variant] = variant
+			continue
+		}
+		groups[match] = append(groups[match], variant)
+		canonical[variant] = groups[match][0]
+	}
+	return canonical
+}
+
+func sameAsAll(rules Rules, name string, group []string) bool {
+	for _, member := range group {
+		if !rules.Same(name, member) {
+			return false
+		}
+	}
+	return true
+}
+
+// completeness scores how much of a name is spelled out: two points per
+// full given name and one per initial.
+func completeness(name string) int {
+	score := 0
+	for _, given := range Parse(name).Given {
+		if len([]rune(given)) == 1 {
+			score++
+		} else {
+			score += 2
+		}
+	}
+	return score
+}
@@ -0,0 +1,57 @@
+package names
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	variants := []string{"J. Smith", "John Smith", "Smith, John", "John A. Smith", "Jane Doe", "J. Doe", "Joe Doe"}
+
+	got := Canonicalize(variants, DefaultRules)
+	want := map[string]string{
+		"J. Smith":      "John A. Smith",
+		"John Smith":    "John A. Smith",
+		"Smith, John":   "John A. Smith",
+		"John A. Smith": "John A. Smith",
+		"Jane Doe":      "Jane Doe",
+		"Joe Doe":       "Joe Doe",
+		// Could be either Jane or Joe.
+		"J. Doe": "J. Doe",
+	}
+	for variant, canonical := range want {
+		if got[variant] != canonical {
+			t.Errorf("Canonicalize()[%q] = %q, want %q", variant, got[variant], canonical)
+		}
+	}
+}
+
+func TestCanonicalizeRules(t *testing.T) {
+	variants := []string{"J. Smith", "John Smith", "John A. Smith"}
+	tests := []struct {
+		rules Rules
+		want  map[string]string
+	}{
+		{Rules{}, map[string]string{"J. Smith": "J. Smith", "John Smith": "John Smith", "John A. Smith": "John A. Smith"}},
+		{Rules{Initials: true}, map[string]string{"J. Smith": "John Smith", "John Smith": "John Smith", "John A. Smith": "John A. Smith"}},
+		{Rules{MiddleNames: true}, map[string]string{"J. Smith": "J. Smith", "John Smith": "John A. Smith", "John A. Smith": "John A. Smith"}},
+	}
+
+	for _, tt := range tests {
+		got := Canonicalize(variants, tt.rules)
+		for variant, canonical := range tt.want {
+			if got[variant] != canonical {
+				t.Errorf("Canonicalize(%+v)[%q] = %q, want %q", tt.rules, variant, got[variant], canonical)
+			}
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	if rules, err := ParseRules("initials, middle-names"); err != nil || rules != DefaultRules {
+		t.Errorf("ParseRules() = %+v, %v", rules, err)
+	}
+	if rules, err := ParseRules("none"); err != nil || rules != (Rules{}) {
+		t.Errorf("ParseRules(none) = %+v, %v", rules, err)
+	}
+	if _, err := ParseRules("nicknames"); err == nil {
+		t.Error("ParseRules(nicknames) succeeded")
+	}
+}
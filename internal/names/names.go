@@ -0,0 +1,121 @@
+// Package names normalizes and compares author names as they appear in arXiv
+// metadata, where the same person may be listed as "Jane Doe", "J. Doe" or
+// "Doe, Jane".
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Name is an author name split into given names and family name, both in
+// normalized form.
+type Name struct {
+	Given  []string
+	Family string
+}
+
+// familyParticles are lowercase words that belong to the family name when
+// they precede it, as in "Ludwig van Beethoven".
+var familyParticles = map[string]bool{
+	"da": true, "de": true, "del": true, "della": true, "der": true, "di": true,
+	"du": true, "la": true, "le": true, "van": true, "von": true,
+}
+
+// accentFolds maps common accented Latin letters to their ASCII base.
+var accentFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a",
+	'ç': "c", 'č': "c", 'ć': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ñ': "n", 'ń': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o",
+	'ř': "r", 'š': "s", 'ś': "s", 'ß': "ss",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u",
+	'ý': "y", 'ÿ': "y", 'ž': "z", 'ź': "z", 'ż': "z", 'ł': "l",
+}
+
+// Normalize lowercases name, folds common accents, turns punctuation other
+// than hyphens into spaces and collapses whitespace. A "Family, Given" name
+// is reordered to "given family".
+func Normalize(name string) string {
+	parsed := Parse(name)
+	parts := append(append([]string{}, parsed.Given...), parsed.Family)
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// Parse splits name into given names and family name.
+func Parse(name string) Name {
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return Name{Given: tokens(given), Family: strings.Join(tokens(family), " ")}
+	}
+
+	words := tokens(name)
+	if len(words) == 0 {
+		return Name{}
+	}
+
+	familyStart := len(words) - 1
+	for familyStart > 1 && familyParticles[words[familyStart-1]] {
+		familyStart--
+	}
+	return Name{Given: words[:familyStart], Family: strings.Join(words[familyStart:], " ")}
+}
+
+// Matches reports whether candidate plausibly names the same person as
+// watched. In strict mode the normalized names must be identical; otherwise
+// the family names must agree and every given name present in both must be
+// equal or an initial of the other, so "J. Doe" matches "Jane Doe" but
+// neither matches "John Doe" against "Jane Doe".
+func Matches(watched, candidate string, strict bool) bool {
+	if strict {
+		return Normalize(watched) == Normalize(candidate)
+	}
+
+	a, b := Parse(watched), Parse(candidate)
+	if a.Family == "" || a.Family != b.Family {
+		return false
+	}
+	if len(a.Given) == 0 || len(b.Given) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(a.Given) && i < len(b.Given); i++ {
+		if !compatibleGiven(a.Given[i], b.Given[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleGiven reports whether two given names can refer to the same
+// person, treating single letters as initials.
+func compatibleGiven(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len([]rune(a)) == 1 || len([]rune(b)) == 1 {
+		return []rune(a)[0] == []rune(b)[0]
+	}
+	return false
+}
+
+// tokens normalizes s and splits it into words.
+func tokens(s string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if folded, ok := accentFolds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-':
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+			// "O'Brien" and "OBrien" are the same name.
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Fields(b.String())
+}
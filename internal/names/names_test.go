@@ -0,0 +1,57 @@
+package names
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Jane Doe", "jane doe"},
+		{"  J.  Doe ", "j doe"},
+		{"Doe, Jane", "jane doe"},
+		{"José Núñez", "jose nunez"},
+		{"Ludwig van Beethoven", "ludwig van beethoven"},
+		{"Sinéad O'Connor", "sinead oconnor"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.input); got != tt.expected {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseFamilyParticles(t *testing.T) {
+	got := Parse("Ludwig van Beethoven")
+	if got.Family != "van beethoven" || len(got.Given) != 1 || got.Given[0] != "ludwig" {
+		t.Errorf("Parse() = %+v, want given [ludwig] and family %q", got, "van beethoven")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		watched   string
+		candidate string
+		strict    bool
+		expected  bool
+	}{
+		{"Jane Doe", "Jane Doe", false, true},
+		{"Jane Doe", "J. Doe", false, true},
+		{"Jane Doe", "Doe, Jane", false, true},
+		{"Jane Doe", "Jane A. Doe", false, true},
+		{"Jane Doe", "John Doe", false, false},
+		{"Jane Doe", "Jane Smith", false, false},
+		{"Jane Doe", "Doe", false, false},
+		{"Jane Doe", "J. Doe", true, false},
+		{"Jane Doe", "jane  DOE", true, true},
+	}
+
+	for _, tt := range tests {
+		if got := Matches(tt.watched, tt.candidate, tt.strict); got != tt.expected {
+			t.Errorf("Matches(%q, %q, %v) = %v, want %v", tt.watched, tt.candidate, tt.strict, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-02-28 was a Wednesday.
+	base := time.Date(2024, 2, 28, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 2, 28, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 2, 28, 10, 15, 0, 0, time.UTC)},
+		{"30 6 * * *", time.Date(2024, 2, 29, 6, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+		}
+		if got := c.Next(base); !got.Equal(tt.expected) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, base, got, tt.expected)
+		}
+	}
+}
+
+func TestCronNextImpossibleDate(t *testing.T) {
+	c, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time for 31 February", got)
+	}
+}
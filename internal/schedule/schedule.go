@@ -0,0 +1,171 @@
+// Package schedule runs named jobs sequentially on fixed intervals or cron
+// expressions, against an injectable clock so the timing logic can be tested.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Schedule yields the next run time after a given instant.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Every runs a job at a fixed interval.
+type Every time.Duration
+
+// Next returns after plus the interval.
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// Clock abstracts time so the scheduler can be driven by a fake in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// RealClock is the wall clock.
+type RealClock struct{}
+
+// Now returns the current time.
+func (RealClock) Now() time.Time { return time.Now() }
+
+// Sleep waits for d or until ctx is done.
+func (RealClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Job is a named unit of work and the schedule it runs on.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// JobState is the persisted scheduling state of one job.
+type JobState struct {
+	NextRun    time.Time `json:"next_run"`
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastResult string    `json:"last_result,omitempty"`
+}
+
+// Result values recorded in JobState.LastResult besides error messages.
+const (
+	ResultOK          = "ok"
+	ResultInterrupted = "interrupted"
+)
+
+// Scheduler runs jobs one at a time, always picking the job due soonest.
+type Scheduler struct {
+	Clock Clock
+	Jobs  []Job
+	// State holds each job's next run time and last result, keyed by name;
+	// entries loaded from a previous process are honored.
+	State map[string]*JobState
+	// OnUpdate, if set, is called whenever State changes so it can be
+	// persisted.
+	OnUpdate func(map[string]*JobState) error
+	// Jitter moves each computed run time by up to this fraction of the
+	// job's period either way, so that jobs on the same cadence drift
+	// apart; 0 disables it (see Jittered).
+	Jitter float64
+	// Random returns the numbers in [0, 1) the jitter is drawn from;
+	// rand.Float64 if nil.
+	Random func() float64
+}
+
+// Run schedules jobs until ctx is cancelled, starting from the times
+// WarmStart derives from the saved state: the jobs that were due while the
+// process was down and the interval jobs without saved state are spread
+// over their first period, the first running right away, and a cron job
+// without saved state waits for its next match. When ctx is cancelled
+// mid-job, the job is recorded as interrupted and its next run time is left
+// unchanged so it runs again on restart.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.Jobs) == 0 {
+		return errors.New("no jobs to schedule")
+	}
+	if s.State == nil {
+		s.State = map[string]*JobState{}
+	}
+
+	starts := WarmStart(s.Clock.Now(), s.Jobs, s.State, s.Jitter, s.random)
+	for _, job := range s.Jobs {
+		state, ok := s.State[job.Name]
+		if !ok {
+			state = &JobState{}
+			s.State[job.Name] = state
+		}
+		state.NextRun = starts[job.Name]
+	}
+	if err := s.update(); err != nil {
+		return err
+	}
+
+	for {
+		job := s.dueNext()
+		state := s.State[job.Name]
+		if err := s.Clock.Sleep(ctx, state.NextRun.Sub(s.Clock.Now())); err != nil {
+			return err
+		}
+
+		err := job.Run(ctx)
+		state.LastRun = s.Clock.Now()
+		switch {
+		case ctx.Err() != nil:
+			state.LastResult = ResultInterrupted
+			if updateErr := s.update(); updateErr != nil {
+				return updateErr
+			}
+			return ctx.Err()
+		case err != nil:
+			state.LastResult = err.Error()
+		default:
+			state.LastResult = ResultOK
+		}
+		state.NextRun = Jittered(state.LastRun, job.Schedule, s.Jitter, s.random())
+		if err := s.update(); err != nil {
+			return err
+		}
+	}
+}
+
+// dueNext returns the job with the earliest next run, ties going to the job
+// listed first.
+func (s *Scheduler) dueNext() Job {
+	best := s.Jobs[0]
+	for _, job := range s.Jobs[1:] {
+		if s.State[job.Name].NextRun.Before(s.State[best.Name].NextRun) {
+			best = job
+		}
+	}
+	return best
+}
+
+func (s *Scheduler) random() float64 {
+	if s.Random != nil {
+		return s.Random()
+	}
+	return rand.Float64()
+}
+
+func (s *Scheduler) update() error {
+	if s.OnUpdate == nil {
+		return nil
+	}
+	return s.OnUpdate(s.State)
+}
@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock advances instantly when slept on.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	return nil
+}
+
+func TestSchedulerRunsJobsInOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			runs = append(runs, name+"@"+clock.Now().Format("15:04"))
+			clock.now = clock.now.Add(time.Minute) // each run takes a minute
+			if len(runs) == 6 {
+				cancel()
+			}
+			return nil
+		}
+	}
+	daily, err := ParseCron("30 1 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	s := &Scheduler{
+		Clock: clock,
+		Jobs: []Job{
+			{Name: "hourly", Schedule: Every(time.Hour), Run: record("hourly")},
+			{Name: "daily", Schedule: daily, Run: record("daily")},
+		},
+	}
+	if err := s.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+
+	want := "hourly@00:00,hourly@01:01,daily@01:30,hourly@02:02,hourly@03:03,hourly@04:04"
+	if got := strings.Join(runs, ","); got != want {
+		t.Errorf("runs = %s, want %s", got, want)
+	}
+	if s.State["hourly"].LastResult != ResultInterrupted {
+		t.Errorf("last result = %q, want the cancelled run recorded as interrupted", s.State["hourly"].LastResult)
+	}
+	if !s.State["hourly"].NextRun.Equal(time.Date(2024, 1, 1, 4, 4, 0, 0, time.UTC)) {
+		t.Errorf("next run = %v, want the interrupted run to stay due", s.State["hourly"].NextRun)
+	}
+}
+
+func TestSchedulerHonorsSavedState(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: now}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ranAt time.Time
+	var updates int
+	s := &Scheduler{
+		Clock: clock,
+		Jobs: []Job{{Name: "job", Schedule: Every(time.Hour), Run: func(context.Context) error {
+			ranAt = clock.Now()
+			cancel()
+			return errors.New("boom")
+		}}},
+		State:    map[string]*JobState{"job": {NextRun: now.Add(20 * time.Minute)}},
+		OnUpdate: func(map[string]*JobState) error { updates++; return nil },
+	}
+	_ = s.Run(ctx)
+
+	if !ranAt.Equal(now.Add(20 * time.Minute)) {
+		t.Errorf("job ran at %v, want the saved next run time", ranAt)
+	}
+	if updates < 2 {
+		t.Errorf("OnUpdate called %d times, want state persisted on start and after the run", updates)
+	}
+}
+
+func TestSchedulerRecordsErrors(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	s := &Scheduler{
+		Clock: clock,
+		Jobs: []Job{{Name: "job", Schedule: Every(time.Hour), Run: func(context.Context) error {
+			runs++
+			return errors.New("boom")
+		}}},
+		OnUpdate: func(state map[string]*JobState) error {
+			if runs == 2 {
+				cancel()
+			}
+			return nil
+		},
+	}
+	_ = s.Run(ctx)
+
+	if got := s.State["job"].LastResult; got != "boom" {
+		t.Errorf("last result = %q, want the error message", got)
+	}
+	if runs != 2 {
+		t.Errorf("job ran %d times, want a failed run not to stop the scheduler", runs)
+	}
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// WarmStart returns the first run time of each job for a scheduler starting
+// at now with the state saved by a previous process, which may be empty:
+//
+//   - A job whose saved next run is still ahead keeps it, so its cadence is
+//     preserved across the restart.
+//   - A job with only a saved last run is due at the schedule's next time
+//     after it, with jitter applied (see Jittered).
+//   - The jobs that came due while the process was down, and the interval
+//     jobs without saved state, are spread over their first period instead
+//     of all running at once: taken longest overdue first, then new jobs in
+//     the order listed, the k-th of n runs at now plus k/n of its period.
+//   - A cron job without saved state waits for its next match.
+//
+// random returns numbers in [0, 1) for the jitter, which is a fraction of
+// the period; WarmStart has no other inputs, so it can be tested with a
+// fixed random.
+func WarmStart(now time.Time, jobs []Job, state map[string]*JobState, jitter float64, random func() float64) map[string]time.Time {
+	starts := make(map[string]time.Time, len(jobs))
+	type dueJob struct {
+		job Job
+		due time.Time // zero for a job that never ran
+	}
+	var due []dueJob
+	for _, job := range jobs {
+		var next time.Time
+		if saved := state[job.Name]; saved != nil {
+			next = saved.NextRun
+			if next.IsZero() && !saved.LastRun.IsZero() {
+				next = Jittered(saved.LastRun, job.Schedule, jitter, random())
+			}
+		}
+		switch _, isInterval := job.Schedule.(Every); {
+		case next.After(now):
+			starts[job.Name] = next
+		case !next.IsZero() || isInterval:
+			due = append(due, dueJob{job, next})
+		default:
+			starts[job.Name] = job.Schedule.Next(now)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		a, b := due[i].due, due[j].due
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+	for k, d := range due {
+		offset := period(d.job.Schedule, now) * time.Duration(k) / time.Duration(len(due))
+		starts[d.job.Name] = now.Add(offset)
+	}
+	return starts
+}
+
+// Jittered returns the schedule's next time after after, moved by up to
+// jitter times its period either way: by (2r-1) jitter periods for r in
+// [0, 1). A move that would not leave the time after after is dropped.
+func Jittered(after time.Time, s Schedule, jitter float64, r float64) time.Time {
+	next := s.Next(after)
+	if jitter <= 0 {
+		return next
+	}
+	moved := next.Add(time.Duration((2*r - 1) * jitter * float64(period(s, after))))
+	if !moved.After(after) {
+		return next
+	}
+	return moved
+}
+
+// period is the time between two runs of s around now: its interval, or the
+// gap between a cron expression's next two matches.
+func period(s Schedule, now time.Time) time.Duration {
+	if every, ok := s.(Every); ok {
+		return time.Duration(every)
+	}
+	first := s.Next(now)
+	return s.Next(first).Sub(first)
+}
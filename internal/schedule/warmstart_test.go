@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarmStart(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) time.Time { return now.Add(offset) }
+	daily, err := ParseCron("0 6 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	hourly := func(name string) Job { return Job{Name: name, Schedule: Every(time.Hour)} }
+
+	tests := []struct {
+		name   string
+		jobs   []Job
+		state  map[string]*JobState
+		jitter float64
+		random float64
+		want   map[string]time.Time
+	}{
+		{
+			name: "first start spreads interval jobs over their period",
+			jobs: []Job{hourly("a"), hourly("b"), hourly("c"), {Name: "d", Schedule: Every(4 * time.Hour)}},
+			want: map[string]time.Time{"a": now, "b": at(15 * time.Minute), "c": at(30 * time.Minute), "d": at(3 * time.Hour)},
+		},
+		{
+			name: "cron job without state waits for its match",
+			jobs: []Job{hourly("a"), {Name: "daily", Schedule: daily}},
+			want: map[string]time.Time{"a": now, "daily": time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "restart keeps future next runs",
+			jobs: []Job{hourly("a"), hourly("b")},
+			state: map[string]*JobState{
+				"a": {NextRun: at(10 * time.Minute), LastRun: at(-50 * time.Minute)},
+				"b": {NextRun: at(40 * time.Minute), LastRun: at(-20 * time.Minute)},
+			},
+			want: map[string]time.Time{"a": at(10 * time.Minute), "b": at(40 * time.Minute)},
+		},
+		{
+			name: "overdue jobs are staggered, longest overdue first",
+			jobs: []Job{hourly("a"), hourly("b"), hourly("c")},
+			state: map[string]*JobState{
+				"a": {NextRun: at(-time.Minute)},
+				"b": {NextRun: at(-3 * time.Hour)},
+				"c": {NextRun: at(20 * time.Minute)},
+			},
+			want: map[string]time.Time{"b": now, "a": at(30 * time.Minute), "c": at(20 * time.Minute)},
+		},
+		{
+			name: "overdue jobs go before new ones",
+			jobs: []Job{hourly("new"), hourly("old")},
+			state: map[string]*JobState{
+				"old": {NextRun: at(-time.Hour)},
+			},
+			want: map[string]time.Time{"old": now, "new": at(30 * time.Minute)},
+		},
+		{
+			name: "overdue cron job is staggered too",
+			jobs: []Job{hourly("a"), {Name: "daily", Schedule: daily}},
+			state: map[string]*JobState{
+				"a":     {NextRun: at(-2 * time.Hour)},
+				"daily": {NextRun: at(-6 * time.Hour)},
+			},
+			want: map[string]time.Time{"daily": now, "a": at(30 * time.Minute)},
+		},
+		{
+			name:   "last run without next run resumes the cadence with jitter",
+			jobs:   []Job{hourly("a")},
+			state:  map[string]*JobState{"a": {LastRun: at(-30 * time.Minute)}},
+			jitter: 0.1,
+			random: 1.0 / 4, // a move of -half the jitter
+			want:   map[string]time.Time{"a": at(27 * time.Minute)},
+		},
+		{
+			name:   "jitter leaves the spread of due jobs alone",
+			jobs:   []Job{hourly("a"), hourly("b")},
+			jitter: 0.5,
+			random: 0.9,
+			want:   map[string]time.Time{"a": now, "b": at(30 * time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WarmStart(now, tt.jobs, tt.state, tt.jitter, func() float64 { return tt.random })
+			if len(got) != len(tt.want) {
+				t.Errorf("WarmStart() = %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if !got[name].Equal(want) {
+					t.Errorf("%s starts at %v, want %v", name, got[name].Format(time.TimeOnly), want.Format(time.TimeOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestJittered(t *testing.T) {
+	after := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		jitter, r float64
+		want      time.Duration
+	}{
+		{0, 0.9, time.Hour},
+		{0.1, 0.5, time.Hour},
+		{0.1, 0, 54 * time.Minute},
+		{0.1, 0.75, 63 * time.Minute},
+		// A move back to or before after is dropped.
+		{1, 0, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Jittered(after, Every(time.Hour), tt.jitter, tt.r); !got.Equal(after.Add(tt.want)) {
+			t.Errorf("Jittered(jitter %v, r %v) = %v, want %v later", tt.jitter, tt.r, got.Sub(after), tt.want)
+		}
+	}
+}
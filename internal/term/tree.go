@@ -0,0 +1,183 @@
+// Package term renders the files a run wrote for a terminal.
+package term
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Node is a file, or a directory and everything under it.
+type Node struct {
+	Name     string
+	Size     int64 // of a file; see Total for a directory
+	Dir      bool
+	Children []*Node // sorted by name
+}
+
+// Walk reads the file or directory at path, recursively. The root node is
+// named after the cleaned path, the nodes below it after their base names.
+func Walk(path string) (*Node, error) {
+	node, err := walk(path)
+	if err != nil {
+		return nil, err
+	}
+	node.Name = filepath.Clean(path)
+	return node, nil
+}
+
+// Files returns the directory root holding only the files at paths, which
+// lie under it, each below the directories between them. Like Walk, it
+// names the root node after the cleaned root.
+func Files(root string, paths []string) (*Node, error) {
+	tree := &Node{Name: filepath.Clean(root), Dir: true}
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is not under %s", path, root)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		node := tree
+		parts := strings.Split(rel, string(filepath.Separator))
+		for i, name := range parts {
+			node = node.child(name, i < len(parts)-1)
+		}
+		node.Size = info.Size()
+	}
+	tree.sort()
+	return tree, nil
+}
+
+// child returns the child of n named name, adding it if missing.
+func (n *Node) child(name string, dir bool) *Node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	c := &Node{Name: name, Dir: dir}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// sort orders the children under n by name.
+func (n *Node) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+func walk(path string) (*Node, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{Name: filepath.Base(path), Dir: info.IsDir()}
+	if !node.Dir {
+		node.Size = info.Size()
+		return node, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		child, err := walk(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	return node, nil
+}
+
+// Total returns the number of files under n, n included, and their size.
+func (n *Node) Total() (files int, size int64) {
+	if !n.Dir {
+		return 1, n.Size
+	}
+	for _, child := range n.Children {
+		f, s := child.Total()
+		files += f
+		size += s
+	}
+	return files, size
+}
+
+// Group is a titled set of trees, such as the PDFs of a run.
+type Group struct {
+	Title string
+	Nodes []*Node
+}
+
+// PrintTree writes the groups that have nodes to w, each tree indented
+// like the tree command with the size of each file, followed by the
+// number of files and their total size.
+func PrintTree(w io.Writer, groups []Group) error {
+	files, size := 0, int64(0)
+	for _, group := range groups {
+		if len(group.Nodes) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, group.Title); err != nil {
+			return err
+		}
+		for i, node := range group.Nodes {
+			if err := printNode(w, node, "", i == len(group.Nodes)-1); err != nil {
+				return err
+			}
+			f, s := node.Total()
+			files += f
+			size += s
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d file(s), %s\n", files, FormatSize(size))
+	return err
+}
+
+// printNode writes node and its children under prefix.
+func printNode(w io.Writer, node *Node, prefix string, last bool) error {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	line := node.Name + "/"
+	if !node.Dir {
+		line = fmt.Sprintf("%s (%s)", node.Name, FormatSize(node.Size))
+	}
+	if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, line); err != nil {
+		return err
+	}
+	for i, child := range node.Children {
+		if err := printNode(w, child, prefix+indent, i == len(node.Children)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatSize formats a number of bytes with binary units, e.g. "1.5 MiB".
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
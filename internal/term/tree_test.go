@@ -0,0 +1,118 @@
+package term
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintTree(t *testing.T) {
+	dir := t.TempDir()
+	pdfs := filepath.Join(dir, "pdfs")
+	if err := os.MkdirAll(filepath.Join(pdfs, "cs.LG"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{
+		"papers.jsonl":     10,
+		"pdfs/b.pdf":       2048,
+		"pdfs/cs.LG/a.pdf": 1536,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata, err := Walk(filepath.Join(dir, "papers.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Walk(pdfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = PrintTree(&b, []Group{
+		{Title: "metadata", Nodes: []*Node{metadata}},
+		{Title: "PDFs", Nodes: []*Node{tree}},
+		{Title: "summaries"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"metadata",
+		"└── " + filepath.Join(dir, "papers.jsonl") + " (10 B)",
+		"PDFs",
+		"└── " + pdfs + "/",
+		"    ├── b.pdf (2.0 KiB)",
+		"    └── cs.LG/",
+		"        └── a.pdf (1.5 KiB)",
+		"",
+		"3 file(s), 3.5 KiB",
+		"",
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("PrintTree() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:           "0 B",
+		1023:        "1023 B",
+		1024:        "1.0 KiB",
+		5 << 20:     "5.0 MiB",
+		3 << 30 / 2: "1.5 GiB",
+	}
+	for bytes, want := range tests {
+		if got := FormatSize(bytes); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	pdfs := filepath.Join(dir, "pdfs")
+	if err := os.MkdirAll(filepath.Join(pdfs, "cs.LG"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{
+		"b.pdf":       2048,
+		"old.pdf":     100,
+		"cs.LG/a.pdf": 1536,
+	} {
+		if err := os.WriteFile(filepath.Join(pdfs, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files left by earlier runs are not listed.
+	tree, err := Files(pdfs, []string{filepath.Join(pdfs, "cs.LG", "a.pdf"), filepath.Join(pdfs, "b.pdf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := PrintTree(&b, []Group{{Title: "PDFs", Nodes: []*Node{tree}}}); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"PDFs",
+		"└── " + pdfs + "/",
+		"    ├── b.pdf (2.0 KiB)",
+		"    └── cs.LG/",
+		"        └── a.pdf (1.5 KiB)",
+		"",
+		"2 file(s), 3.5 KiB",
+		"",
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("PrintTree() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := Files(pdfs, []string{filepath.Join(dir, "elsewhere.pdf")}); err == nil {
+		t.Error("Files() accepted a path outside the root")
+	}
+}
@@ -0,0 +1,48 @@
+package text
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TermCount is the number of occurrences of a term across documents.
+type TermCount struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// CountTerms counts the Terms of every document, leaving out single
+// characters and plain numbers, which say nothing about a topic. The counts
+// are sorted by decreasing frequency, ties alphabetically.
+func CountTerms(documents []string) []TermCount {
+	counts := map[string]int{}
+	for _, document := range documents {
+		for _, term := range Terms(document) {
+			if utf8.RuneCountInString(term) > 1 && !isNumber(term) {
+				counts[term]++
+			}
+		}
+	}
+
+	terms := make([]TermCount, 0, len(counts))
+	for term, n := range counts {
+		terms = append(terms, TermCount{Text: term, Count: n})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Text < terms[j].Text
+	})
+	return terms
+}
+
+func isNumber(term string) bool {
+	for _, r := range term {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
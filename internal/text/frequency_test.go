@@ -0,0 +1,22 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountTerms(t *testing.T) {
+	got := CountTerms([]string{
+		"Transformers for graphs: a transformer with 2 heads.",
+		"Graphs and transformers in 2024.",
+	})
+	want := []TermCount{
+		{"graphs", 2},
+		{"transformers", 2},
+		{"heads", 1},
+		{"transformer", 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountTerms() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Package text computes simple statistics over English prose such as paper
+// abstracts.
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FleschKincaidGrade returns the Flesch-Kincaid grade level of text:
+//
+//	0.39 * (words / sentences) + 11.8 * (syllables / words) - 15.59
+//
+// Syllables are estimated from vowel groups, so the result is an
+// approximation. Text without any words scores 0.
+func FleschKincaidGrade(text string) float64 {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	wordCount, syllables := 0, 0
+	for _, word := range words {
+		if !strings.ContainsFunc(word, unicode.IsLetter) {
+			continue
+		}
+		wordCount++
+		syllables += countSyllables(word)
+	}
+	if wordCount == 0 {
+		return 0
+	}
+
+	sentences := countSentences(text)
+	return 0.39*float64(wordCount)/float64(sentences) + 11.8*float64(syllables)/float64(wordCount) - 15.59
+}
+
+// countSentences counts runs of sentence-ending punctuation, treating text
+// without any as a single sentence.
+func countSentences(text string) int {
+	count := 0
+	inTerminator := false
+	for _, r := range text {
+		terminator := r == '.' || r == '!' || r == '?'
+		if terminator && !inTerminator {
+			count++
+		}
+		inTerminator = terminator
+	}
+	// Trailing text without a final full stop is still a sentence.
+	if trimmed := strings.TrimRightFunc(text, unicode.IsSpace); trimmed != "" && !strings.ContainsAny(trimmed[len(trimmed)-1:], ".!?") {
+		count++
+	}
+	return max(count, 1)
+}
+
+// countSyllables estimates the syllables in word by counting groups of
+// vowels, ignoring a silent final "e". Every word has at least one.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	inVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	return max(count, 1)
+}
@@ -0,0 +1,53 @@
+package text
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCountSyllables(t *testing.T) {
+	tests := map[string]int{
+		"cat":       1,
+		"make":      1,
+		"table":     2,
+		"computer":  3,
+		"model":     2,
+		"rhythm":    1,
+		"algorithm": 3,
+	}
+	for word, want := range tests {
+		if got := countSyllables(word); got != want {
+			t.Errorf("countSyllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestFleschKincaidGrade(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want float64
+	}{
+		{"empty", "", 0},
+		{"no words", "42 - 7.", 0},
+		// 6 words, 1 sentence, 6 syllables.
+		{"simple", "The cat sat on the mat.", 0.39*6 + 11.8*1 - 15.59},
+		// 4 words, 2 sentences, 6 syllables; no final full stop.
+		{"two sentences", "Cats sleep. Models predict", 0.39*2 + 11.8*1.5 - 15.59},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FleschKincaidGrade(tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("FleschKincaidGrade(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFleschKincaidGradeOrdersByDifficulty(t *testing.T) {
+	easy := FleschKincaidGrade("We train a small model. It works well. We share the code.")
+	hard := FleschKincaidGrade("We investigate the generalization capabilities of autoregressive transformer architectures under distributional perturbations, demonstrating considerable improvements.")
+	if easy >= hard {
+		t.Errorf("easy abstract scored %v, hard abstract scored %v", easy, hard)
+	}
+}
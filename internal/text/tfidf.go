@@ -0,0 +1,86 @@
+package text
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Vector is a sparse term-weight vector.
+type Vector map[string]float64
+
+// stopWords are common English words that carry no topic and would
+// otherwise make any two abstracts look alike.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "for": true, "from": true, "has": true,
+	"have": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "our": true, "that": true, "the": true, "this": true,
+	"to": true, "we": true, "which": true, "with": true,
+}
+
+// Terms splits text into lowercase words, dropping stop words.
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, word := range words {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// TFIDF returns the TF-IDF vector of each document, weighting a term's count
+// in the document by the smoothed inverse document frequency
+// ln((1+n)/(1+df)) + 1 across the n documents.
+func TFIDF(documents []string) []Vector {
+	counts := make([]map[string]int, len(documents))
+	df := map[string]int{}
+	for i, document := range documents {
+		counts[i] = map[string]int{}
+		for _, term := range Terms(document) {
+			if counts[i][term] == 0 {
+				df[term]++
+			}
+			counts[i][term]++
+		}
+	}
+
+	n := float64(len(documents))
+	vectors := make([]Vector, len(documents))
+	for i, termCounts := range counts {
+		vectors[i] = make(Vector, len(termCounts))
+		for term, count := range termCounts {
+			vectors[i][term] = float64(count) * (math.Log((1+n)/(1+float64(df[term]))) + 1)
+		}
+	}
+	return vectors
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0
+// when either is empty.
+func CosineSimilarity(a, b Vector) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	normA, normB := a.norm(), b.norm()
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (normA * normB)
+}
+
+func (v Vector) norm() float64 {
+	var sum float64
+	for _, weight := range v {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
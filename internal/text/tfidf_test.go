@@ -0,0 +1,40 @@
+package text
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarityOfTFIDF(t *testing.T) {
+	vectors := TFIDF([]string{
+		"We propose a sparse attention mechanism for long documents.",
+		"We propose a sparse attention mechanism for very long documents.",
+		"A survey of reinforcement learning for robotic grasping.",
+		"",
+	})
+
+	if got := CosineSimilarity(vectors[0], vectors[0]); math.Abs(got-1) > 1e-9 {
+		t.Errorf("self-similarity = %v, want 1", got)
+	}
+	near := CosineSimilarity(vectors[0], vectors[1])
+	far := CosineSimilarity(vectors[0], vectors[2])
+	if near < 0.85 || far > 0.1 {
+		t.Errorf("similarity of near-duplicates = %.2f, of unrelated abstracts = %.2f", near, far)
+	}
+	if got := CosineSimilarity(vectors[0], vectors[3]); got != 0 {
+		t.Errorf("similarity to an empty document = %v, want 0", got)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("The Transformer, and its 2 heads.")
+	want := []string{"transformer", "2", "heads"}
+	if len(got) != len(want) {
+		t.Fatalf("Terms() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Terms() = %q, want %q", got, want)
+		}
+	}
+}